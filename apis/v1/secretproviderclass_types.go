@@ -21,46 +21,387 @@ import (
 )
 
 // Provider enum for all the provider names
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]{0,30}$`
 type Provider string
 
+// ProviderCapability is an optional feature of the provider API
+// +kubebuilder:validation:Enum=MountStream;UnmountNotification;PerObjectErrors;ObjectTTL
+type ProviderCapability string
+
+const (
+	// ProviderCapabilityMountStream is set if the provider implements the MountStream RPC
+	ProviderCapabilityMountStream ProviderCapability = "MountStream"
+	// ProviderCapabilityUnmountNotification is set if the provider is notified when a volume is unmounted
+	ProviderCapabilityUnmountNotification ProviderCapability = "UnmountNotification"
+	// ProviderCapabilityPerObjectErrors is set if the provider reports errors for individual objects
+	ProviderCapabilityPerObjectErrors ProviderCapability = "PerObjectErrors"
+	// ProviderCapabilityObjectTTL is set if the provider returns a time to live for the objects
+	ProviderCapabilityObjectTTL ProviderCapability = "ObjectTTL"
+)
+
+// ObjectEncoding is the encoding of the object content returned by the provider
+// +kubebuilder:validation:Enum=base64;base64url;hex;utf-8
+type ObjectEncoding string
+
+const (
+	// ObjectEncodingBase64 is the standard base64 encoding with padding
+	ObjectEncodingBase64 ObjectEncoding = "base64"
+	// ObjectEncodingBase64URL is the URL safe base64 encoding, with or without padding
+	ObjectEncodingBase64URL ObjectEncoding = "base64url"
+	// ObjectEncodingHex is the hexadecimal encoding
+	ObjectEncodingHex ObjectEncoding = "hex"
+	// ObjectEncodingUTF8 is plain UTF-8 text, the content is validated but not decoded
+	ObjectEncodingUTF8 ObjectEncoding = "utf-8"
+)
+
+// KeystoreFormat is the format of a keystore derived from PEM objects
+// +kubebuilder:validation:Enum=PKCS12;JKS
+type KeystoreFormat string
+
+const (
+	// KeystoreFormatPKCS12 is the PKCS#12 format, the default keystore type of Java 9+
+	KeystoreFormatPKCS12 KeystoreFormat = "PKCS12"
+	// KeystoreFormatJKS is the Java KeyStore format
+	KeystoreFormatJKS KeystoreFormat = "JKS"
+)
+
+// PrivateKeyEncoding is the encoding of the private key synced to the tls.key of a K8s secret object
+// +kubebuilder:validation:Enum=PKCS1;PKCS8
+type PrivateKeyEncoding string
+
+const (
+	// PrivateKeyEncodingPKCS1 encodes RSA keys as PKCS#1 and ECDSA keys as SEC 1. Ed25519
+	// and X25519 keys don't have such an encoding and are encoded as PKCS#8
+	PrivateKeyEncodingPKCS1 PrivateKeyEncoding = "PKCS1"
+	// PrivateKeyEncodingPKCS8 encodes all keys as PKCS#8
+	PrivateKeyEncodingPKCS8 PrivateKeyEncoding = "PKCS8"
+)
+
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // SecretObjectData defines the desired state of synced K8s secret object data
+// +kubebuilder:validation:XValidation:rule="has(self.objectName) != has(self.template)",message="exactly one of objectName or template is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.jsonPath) || has(self.objectName)",message="jsonPath requires objectName"
 type SecretObjectData struct {
 	// name of the object to sync
+	// +optional
+	// +kubebuilder:validation:MinLength=1
 	ObjectName string `json:"objectName,omitempty"`
 	// data field to populate
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
 	Key string `json:"key,omitempty"`
+	// template is a Go text/template rendered with the contents of the mounted
+	// objects to compose the value of the data field
+	// +optional
+	Template string `json:"template,omitempty"`
+	// jsonPath selects the scalar field of the JSON or YAML object that populates
+	// the data field, e.g. {.username}
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+}
+
+// SecretObjectDataFrom defines a JSON or YAML object whose top-level fields are each
+// synced into a data field of the K8s secret object
+type SecretObjectDataFrom struct {
+	// name of the object to sync
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ObjectName string `json:"objectName,omitempty"`
+	// jsonPath selects the map of the JSON or YAML object whose fields are synced.
+	// The top-level fields of the object are synced if not set
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
 }
 
 // SecretObject defines the desired state of synced K8s secret objects
+// +kubebuilder:validation:XValidation:rule="has(self.data) || has(self.template) || has(self.dataFrom)",message="data, dataFrom or template is required"
 type SecretObject struct {
 	// name of the K8s secret object
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
 	SecretName string `json:"secretName,omitempty"`
 	// type of K8s secret object
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
 	Type string `json:"type,omitempty"`
 	// labels of K8s secret object
 	Labels map[string]string `json:"labels,omitempty"`
 	// annotations of k8s secret object
-	Annotations map[string]string   `json:"annotations,omitempty"`
-	Data        []*SecretObjectData `json:"data,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// adoptExisting allows the driver to take over an existing K8s secret object
+	// with the same name that was not created by the driver
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=key
+	Data []*SecretObjectData `json:"data,omitempty"`
+	// dataFrom explodes the top-level fields of JSON or YAML objects into data fields
+	// of the K8s secret object
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	DataFrom []*SecretObjectDataFrom `json:"dataFrom,omitempty"`
+	// template is a Go text/template rendered with the contents of the mounted
+	// objects. It must render a YAML map of data fields to values that are added
+	// to the K8s secret object data
+	// +optional
+	Template string `json:"template,omitempty"`
+	// pkcs12Password is the source of the password used to decrypt the PKCS#12
+	// objects of a kubernetes.io/tls K8s secret object
+	// +optional
+	PKCS12Password *PKCS12PasswordSource `json:"pkcs12Password,omitempty"`
+	// privateKeyEncoding is the encoding of the private key synced to the tls.key of
+	// a kubernetes.io/tls K8s secret object. Defaults to PKCS1
+	// +optional
+	PrivateKeyEncoding PrivateKeyEncoding `json:"privateKeyEncoding,omitempty"`
+}
+
+// PKCS12PasswordSource defines where the password of PKCS#12 objects is read from
+// +kubebuilder:validation:XValidation:rule="has(self.objectName) != has(self.nodePublishSecretRefKey)",message="exactly one of objectName or nodePublishSecretRefKey is required"
+type PKCS12PasswordSource struct {
+	// name of the mounted object that contains the password
+	// +optional
+	ObjectName string `json:"objectName,omitempty"`
+	// key of the password in the nodePublishSecretRef secret of the pod volume
+	// +optional
+	NodePublishSecretRefKey string `json:"nodePublishSecretRefKey,omitempty"`
+}
+
+// MountObject defines how the driver handles the content of an object returned by the provider
+type MountObject struct {
+	// name of the object, i.e. the path of its file in the mount
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ObjectName string `json:"objectName"`
+	// encoding of the object content returned by the provider. The content is decoded
+	// before it's written to the mount and synced to K8s secret objects
+	// +optional
+	Encoding ObjectEncoding `json:"encoding,omitempty"`
+	// explode writes a file for each field of the JSON or YAML map in the object
+	// content, in a directory named after the object, instead of the object file
+	// +optional
+	Explode *ObjectExplode `json:"explode,omitempty"`
+}
+
+// ObjectExplode defines how the fields of a JSON or YAML map object are written to
+// the mount, like the keys of a K8s secret volume
+type ObjectExplode struct {
+	// jsonPath selects the map in the object content. The whole content is used
+	// if not set
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+	// defaultMode is the mode of the field files. Defaults to the mode of the
+	// object file
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+	// items are the fields written to the mount. All the fields are written to a
+	// file named after the field if not set
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	Items []ObjectExplodeItem `json:"items,omitempty"`
+}
+
+// ObjectExplodeItem defines the file of a field of an exploded object
+type ObjectExplodeItem struct {
+	// key is the name of the field
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// path of the file relative to the object directory. Defaults to the key
+	// +optional
+	Path string `json:"path,omitempty"`
+	// mode of the file. Defaults to the defaultMode
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	Mode *int32 `json:"mode,omitempty"`
+}
+
+// ObjectFile defines the file of the mount an object returned by the provider is
+// written to
+type ObjectFile struct {
+	// name of the object, i.e. the path of its file in the mount response
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ObjectName string `json:"objectName"`
+	// path of the file relative to the mount. Defaults to the object name
+	// +optional
+	Path string `json:"path,omitempty"`
+	// mode of the file. Defaults to the mode returned by the provider
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	Mode *int32 `json:"mode,omitempty"`
+	// optional lets the mount succeed if the provider doesn't return the object
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// DerivedFile defines a file the driver assembles from the objects returned by the
+// provider and writes to the mount alongside them
+type DerivedFile struct {
+	// path of the file in the mount
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// keystore assembles a keystore or truststore from PEM objects
+	// +kubebuilder:validation:Required
+	Keystore *Keystore `json:"keystore"`
+}
+
+// TemplateConfigMap references a ConfigMap with Go templates. Each key of the ConfigMap
+// is rendered with the contents of the objects and written to a file named after the key
+type TemplateConfigMap struct {
+	// name of the ConfigMap in the namespace of the SecretProviderClass
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// defaultMode is the mode of the rendered files. Defaults to 0644
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+}
+
+// Keystore defines a keystore with a private key entry built from PEM objects, or
+// a truststore with the trusted certificate entries of PEM objects
+// +kubebuilder:validation:XValidation:rule="has(self.keyObjectName) == has(self.certObjectName)",message="keyObjectName and certObjectName must be set together"
+// +kubebuilder:validation:XValidation:rule="has(self.keyObjectName) != has(self.trustedCertObjectNames)",message="exactly one of keyObjectName or trustedCertObjectNames is required"
+type Keystore struct {
+	// format of the keystore. Defaults to PKCS12
+	// +optional
+	Format KeystoreFormat `json:"format,omitempty"`
+	// name of the PEM object with the private key of the key entry
+	// +optional
+	KeyObjectName string `json:"keyObjectName,omitempty"`
+	// name of the PEM object with the certificate of the private key and its chain
+	// +optional
+	CertObjectName string `json:"certObjectName,omitempty"`
+	// names of the PEM objects with the certificates added as trusted certificate
+	// entries of a truststore
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	TrustedCertObjectNames []string `json:"trustedCertObjectNames,omitempty"`
+	// name of the object that contains the keystore password
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	PasswordObjectName string `json:"passwordObjectName"`
+	// alias of the private key entry of a JKS keystore. Defaults to 1
+	// +optional
+	Alias string `json:"alias,omitempty"`
 }
 
 // SecretProviderClassSpec defines the desired state of SecretProviderClass
+// +kubebuilder:validation:XValidation:rule="has(self.provider)",message="provider is required"
+// +kubebuilder:validation:XValidation:rule="has(self.parameters) && size(self.parameters) > 0",message="parameters are required"
 type SecretProviderClassSpec struct {
 	// Configuration for provider name
 	Provider Provider `json:"provider,omitempty"`
 	// Configuration for specific provider
-	Parameters    map[string]string `json:"parameters,omitempty"`
-	SecretObjects []*SecretObject   `json:"secretObjects,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// +listType=map
+	// +listMapKey=secretName
+	SecretObjects []*SecretObject `json:"secretObjects,omitempty"`
+	// objects declares how the driver handles the content of the objects returned
+	// by the provider
+	// +optional
+	// +listType=map
+	// +listMapKey=objectName
+	Objects []MountObject `json:"objects,omitempty"`
+	// files maps the objects returned by the provider to the files of the mount. If
+	// set, only the listed objects are written to the mount
+	// +optional
+	// +listType=map
+	// +listMapKey=objectName
+	Files []ObjectFile `json:"files,omitempty"`
+	// derivedFiles are files the driver assembles from the objects returned by the
+	// provider. They are written to the mount alongside the objects and regenerated
+	// on every rotation
+	// +optional
+	// +listType=map
+	// +listMapKey=path
+	DerivedFiles []DerivedFile `json:"derivedFiles,omitempty"`
+	// templateConfigMap references a ConfigMap with Go templates rendered with the
+	// objects returned by the provider. The rendered files are written to the mount
+	// and rendered again on every rotation
+	// +optional
+	TemplateConfigMap *TemplateConfigMap `json:"templateConfigMap,omitempty"`
+	// requiredProviderCapabilities are the capabilities the provider must advertise
+	// for the content to be mounted
+	// +optional
+	// +listType=set
+	RequiredProviderCapabilities []ProviderCapability `json:"requiredProviderCapabilities,omitempty"`
+}
+
+// ErrorReasonCount defines the number of pods failing with an error reason
+type ErrorReasonCount struct {
+	// reason is the error code reported for the failing pods
+	Reason string `json:"reason"`
+	// count is the number of pods failing with the reason
+	Count int32 `json:"count"`
+}
+
+// ByNodeStatus defines the usage of the SecretProviderClass on a node as
+// reported by the CSI driver running on that node
+type ByNodeStatus struct {
+	// nodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// podCount is the number of pods on the node that mount the SecretProviderClass
+	PodCount int32 `json:"podCount,omitempty"`
+	// failedPodCount is the number of pods on the node for which the last mount
+	// or rotation request failed
+	FailedPodCount int32 `json:"failedPodCount,omitempty"`
+	// errorReasons is the number of failing pods on the node by error reason
+	// +optional
+	// +listType=map
+	// +listMapKey=reason
+	ErrorReasons []ErrorReasonCount `json:"errorReasons,omitempty"`
+	// objects is the set of object versions mounted on the node
+	// +optional
+	Objects []SecretProviderClassObject `json:"objects,omitempty"`
+	// lastUpdateTime is the time the node entry was last updated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // SecretProviderClassStatus defines the observed state of SecretProviderClass
 type SecretProviderClassStatus struct {
+	// podCount is the number of pods that mount the SecretProviderClass
+	PodCount int32 `json:"podCount,omitempty"`
+	// nodeCount is the number of nodes with pods that mount the SecretProviderClass
+	NodeCount int32 `json:"nodeCount,omitempty"`
+	// failedPodCount is the number of pods for which the last mount or rotation
+	// request failed
+	FailedPodCount int32 `json:"failedPodCount,omitempty"`
+	// errorReasons is the number of failing pods by error reason
+	// +optional
+	// +listType=map
+	// +listMapKey=reason
+	ErrorReasons []ErrorReasonCount `json:"errorReasons,omitempty"`
+	// objects is the set of object versions currently mounted in pods
+	// +optional
+	Objects []SecretProviderClassObject `json:"objects,omitempty"`
+	// byNode is the usage of the SecretProviderClass reported by each node
+	// +optional
+	// +listType=map
+	// +listMapKey=nodeName
+	ByNode []ByNodeStatus `json:"byNode,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider"
+// +kubebuilder:printcolumn:name="Pods",type="integer",JSONPath=".status.podCount"
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.nodeCount"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedPodCount"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
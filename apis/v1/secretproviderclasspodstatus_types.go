@@ -23,6 +23,42 @@ const (
 	InternalNodeLabel = "internal.secrets-store.csi.k8s.io/node-name"
 )
 
+// Condition types reported in SecretProviderClassPodStatus status.conditions
+const (
+	// ConditionTypeMounted indicates whether the secrets store content has been
+	// mounted into the pod at least once.
+	ConditionTypeMounted = "Mounted"
+	// ConditionTypeProviderReachable indicates whether the driver was able to
+	// reach the provider during the last mount request.
+	ConditionTypeProviderReachable = "ProviderReachable"
+	// ConditionTypeContentUpToDate indicates whether the last mount or rotation
+	// request wrote the latest content from the external secrets store.
+	ConditionTypeContentUpToDate = "ContentUpToDate"
+	// ConditionTypeSecretSynced indicates whether the Kubernetes secrets defined
+	// in the SecretProviderClass secretObjects have been synced.
+	ConditionTypeSecretSynced = "SecretSynced"
+	// ConditionTypeSecretConflict indicates that Kubernetes secrets defined in the
+	// SecretProviderClass secretObjects are also defined in other SecretProviderClasses
+	// in the namespace. It's only set while there is a conflict.
+	ConditionTypeSecretConflict = "SecretConflict"
+)
+
+// Condition reasons used when an operation succeeds. Failure reasons are the
+// error codes defined in sigs.k8s.io/secrets-store-csi-driver/pkg/errors.
+const (
+	// ReasonMountSucceeded is used when the secrets store content was mounted
+	ReasonMountSucceeded = "MountSucceeded"
+	// ReasonProviderResponded is used when the provider responded to the mount request
+	ReasonProviderResponded = "ProviderResponded"
+	// ReasonContentUpdated is used when the mounted content was written successfully
+	ReasonContentUpdated = "ContentUpdated"
+	// ReasonSecretSynced is used when all the Kubernetes secrets were synced
+	ReasonSecretSynced = "SecretSynced"
+	// ReasonCachedContent is used when the provider failed and the content of the last
+	// successful mount was served from the node content cache
+	ReasonCachedContent = "CachedContent"
+)
+
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // SecretProviderClassPodStatusStatus defines the observed state of SecretProviderClassPodStatus
@@ -32,6 +68,12 @@ type SecretProviderClassPodStatusStatus struct {
 	Mounted                 bool                        `json:"mounted,omitempty"`
 	TargetPath              string                      `json:"targetPath,omitempty"`
 	Objects                 []SecretProviderClassObject `json:"objects,omitempty"`
+	// Conditions represent the latest observations of the mount and the secret
+	// sync for the pod.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // SecretProviderClassObject defines the object fetched from external secrets store
@@ -42,6 +84,12 @@ type SecretProviderClassObject struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Pod",type="string",JSONPath=".status.podName"
+// +kubebuilder:printcolumn:name="SecretProviderClass",type="string",JSONPath=".status.secretProviderClassName"
+// +kubebuilder:printcolumn:name="Mounted",type="boolean",JSONPath=".status.mounted"
+// +kubebuilder:printcolumn:name="UpToDate",type="string",JSONPath=".status.conditions[?(@.type==\"ContentUpToDate\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"ContentUpToDate\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -21,9 +21,193 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByNodeStatus) DeepCopyInto(out *ByNodeStatus) {
+	*out = *in
+	if in.ErrorReasons != nil {
+		in, out := &in.ErrorReasons, &out.ErrorReasons
+		*out = make([]ErrorReasonCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]SecretProviderClassObject, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByNodeStatus.
+func (in *ByNodeStatus) DeepCopy() *ByNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DerivedFile) DeepCopyInto(out *DerivedFile) {
+	*out = *in
+	if in.Keystore != nil {
+		in, out := &in.Keystore, &out.Keystore
+		*out = new(Keystore)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DerivedFile.
+func (in *DerivedFile) DeepCopy() *DerivedFile {
+	if in == nil {
+		return nil
+	}
+	out := new(DerivedFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorReasonCount) DeepCopyInto(out *ErrorReasonCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorReasonCount.
+func (in *ErrorReasonCount) DeepCopy() *ErrorReasonCount {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorReasonCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Keystore) DeepCopyInto(out *Keystore) {
+	*out = *in
+	if in.TrustedCertObjectNames != nil {
+		in, out := &in.TrustedCertObjectNames, &out.TrustedCertObjectNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Keystore.
+func (in *Keystore) DeepCopy() *Keystore {
+	if in == nil {
+		return nil
+	}
+	out := new(Keystore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountObject) DeepCopyInto(out *MountObject) {
+	*out = *in
+	if in.Explode != nil {
+		in, out := &in.Explode, &out.Explode
+		*out = new(ObjectExplode)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountObject.
+func (in *MountObject) DeepCopy() *MountObject {
+	if in == nil {
+		return nil
+	}
+	out := new(MountObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectExplode) DeepCopyInto(out *ObjectExplode) {
+	*out = *in
+	if in.DefaultMode != nil {
+		in, out := &in.DefaultMode, &out.DefaultMode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObjectExplodeItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectExplode.
+func (in *ObjectExplode) DeepCopy() *ObjectExplode {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectExplode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectExplodeItem) DeepCopyInto(out *ObjectExplodeItem) {
+	*out = *in
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectExplodeItem.
+func (in *ObjectExplodeItem) DeepCopy() *ObjectExplodeItem {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectExplodeItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectFile) DeepCopyInto(out *ObjectFile) {
+	*out = *in
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectFile.
+func (in *ObjectFile) DeepCopy() *ObjectFile {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKCS12PasswordSource) DeepCopyInto(out *PKCS12PasswordSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKCS12PasswordSource.
+func (in *PKCS12PasswordSource) DeepCopy() *PKCS12PasswordSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PKCS12PasswordSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretObject) DeepCopyInto(out *SecretObject) {
 	*out = *in
@@ -52,6 +236,22 @@ func (in *SecretObject) DeepCopyInto(out *SecretObject) {
 			}
 		}
 	}
+	if in.DataFrom != nil {
+		in, out := &in.DataFrom, &out.DataFrom
+		*out = make([]*SecretObjectDataFrom, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(SecretObjectDataFrom)
+				**out = **in
+			}
+		}
+	}
+	if in.PKCS12Password != nil {
+		in, out := &in.PKCS12Password, &out.PKCS12Password
+		*out = new(PKCS12PasswordSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretObject.
@@ -79,13 +279,28 @@ func (in *SecretObjectData) DeepCopy() *SecretObjectData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretObjectDataFrom) DeepCopyInto(out *SecretObjectDataFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretObjectDataFrom.
+func (in *SecretObjectDataFrom) DeepCopy() *SecretObjectDataFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretObjectDataFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretProviderClass) DeepCopyInto(out *SecretProviderClass) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderClass.
@@ -219,6 +434,13 @@ func (in *SecretProviderClassPodStatusStatus) DeepCopyInto(out *SecretProviderCl
 		*out = make([]SecretProviderClassObject, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderClassPodStatusStatus.
@@ -252,6 +474,37 @@ func (in *SecretProviderClassSpec) DeepCopyInto(out *SecretProviderClassSpec) {
 			}
 		}
 	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]MountObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]ObjectFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DerivedFiles != nil {
+		in, out := &in.DerivedFiles, &out.DerivedFiles
+		*out = make([]DerivedFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TemplateConfigMap != nil {
+		in, out := &in.TemplateConfigMap, &out.TemplateConfigMap
+		*out = new(TemplateConfigMap)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequiredProviderCapabilities != nil {
+		in, out := &in.RequiredProviderCapabilities, &out.RequiredProviderCapabilities
+		*out = make([]ProviderCapability, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderClassSpec.
@@ -267,6 +520,23 @@ func (in *SecretProviderClassSpec) DeepCopy() *SecretProviderClassSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretProviderClassStatus) DeepCopyInto(out *SecretProviderClassStatus) {
 	*out = *in
+	if in.ErrorReasons != nil {
+		in, out := &in.ErrorReasons, &out.ErrorReasons
+		*out = make([]ErrorReasonCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]SecretProviderClassObject, len(*in))
+		copy(*out, *in)
+	}
+	if in.ByNode != nil {
+		in, out := &in.ByNode, &out.ByNode
+		*out = make([]ByNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviderClassStatus.
@@ -278,3 +548,23 @@ func (in *SecretProviderClassStatus) DeepCopy() *SecretProviderClassStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateConfigMap) DeepCopyInto(out *TemplateConfigMap) {
+	*out = *in
+	if in.DefaultMode != nil {
+		in, out := &in.DefaultMode, &out.DefaultMode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateConfigMap.
+func (in *TemplateConfigMap) DeepCopy() *TemplateConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
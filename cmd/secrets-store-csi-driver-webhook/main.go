@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/version"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/webhook"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	"monis.app/mlog"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var (
+	port          = flag.Int("port", 9443, "The port the webhook server listens on")
+	certDir       = flag.String("cert-dir", "/certs", "The directory that contains the webhook server certificate tls.crt and key tls.key")
+	metricsAddr   = flag.String("metrics-addr", ":8095", "The address the metric endpoint binds to")
+	healthAddr    = flag.String("health-addr", ":9808", "The address the health endpoint binds to")
+	logFormatJSON = flag.Bool("log-format-json", false, "set log formatter to json")
+	versionInfo   = flag.Bool("version", false, "Print the version and exit")
+
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = secretsstorev1.AddToScheme(scheme)
+}
+
+func main() {
+	if err := mainErr(); err != nil {
+		mlog.Fatal(err)
+	}
+}
+
+func mainErr() error {
+	klog.InitFlags(nil)
+
+	flag.Parse()
+
+	ctx := ctrl.SetupSignalHandler()
+
+	defer mlog.Setup()()
+	format := mlog.FormatText
+	if *logFormatJSON {
+		format = mlog.FormatJSON
+	}
+	if err := mlog.ValidateAndSetKlogLevelAndFormatGlobally(ctx, getKlogLevel(), format); err != nil {
+		mlog.Error("failed to validate log level", err)
+		return err
+	}
+
+	if *versionInfo {
+		return version.PrintVersion()
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	cfg.UserAgent = version.GetUserAgent("webhook")
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: *metricsAddr},
+		HealthProbeBindAddress: *healthAddr,
+		LeaderElection:         false,
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    *port,
+			CertDir: *certDir,
+		}),
+	})
+	if err != nil {
+		klog.ErrorS(err, "failed to create manager")
+		return err
+	}
+
+	if err = (&webhook.SecretProviderClassValidator{}).SetupWithManager(mgr); err != nil {
+		klog.ErrorS(err, "failed to create secret provider class webhook")
+		return err
+	}
+	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		klog.ErrorS(err, "failed to add health check")
+		return err
+	}
+	if err = mgr.AddReadyzCheck("readyz", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		klog.ErrorS(err, "failed to add ready check")
+		return err
+	}
+
+	klog.Info("starting webhook server")
+	if err = mgr.Start(ctx); err != nil {
+		klog.ErrorS(err, "failed to run manager")
+		return err
+	}
+	return nil
+}
+
+func getKlogLevel() klog.Level {
+	// hack around klog not exposing a Get method
+	for i := klog.Level(0); i < 1_000_000; i++ {
+		if klog.V(i).Enabled() {
+			continue
+		}
+		return i - 1
+	}
+
+	return -1
+}
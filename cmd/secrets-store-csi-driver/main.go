@@ -29,7 +29,10 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
 	"sigs.k8s.io/secrets-store-csi-driver/controllers"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/certmonitor"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/contentcache"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/metrics"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
 	secretsstore "sigs.k8s.io/secrets-store-csi-driver/pkg/secrets-store"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/version"
 
@@ -62,11 +65,27 @@ var (
 	enableProfile           = flag.Bool("enable-pprof", false, "enable pprof profiling")
 	profilePort             = flag.Int("pprof-port", 6065, "port for pprof profiling")
 	maxCallRecvMsgSize      = flag.Int("max-call-recv-msg-size", 1024*1024*4, "maximum size in bytes of gRPC response from plugins")
+	maxMountStreamSize      = flag.Int("max-mount-stream-size", secretsstore.DefaultMaxMountStreamSize, "maximum total size in bytes of the files received from plugins that stream the mount response. The files are kept in memory until the stream is complete")
+	providerRegistryConfig  = flag.String("provider-registry-config", "", "Path to the provider registry config file with the endpoint and transport settings of each provider. The file is reloaded when it changes")
 	versionInfo             = flag.Bool("version", false, "Print the version and exit")
 
 	// Enable optional healthcheck for provider clients that exist in memory
 	providerHealthCheck         = flag.Bool("provider-health-check", false, "Enable health check for configured providers")
 	providerHealthCheckInterval = flag.Duration("provider-health-check-interval", 2*time.Minute, "Provider healthcheck interval duration")
+	providerHealthCheckFailures = flag.Int("provider-health-check-failure-threshold", 3, "Number of consecutive failed provider health checks after which mounts fail fast and the provider is reconnected")
+
+	// Enable aggregation of the secret provider class pod statuses into the secret provider class status
+	enableSPCStatus = flag.Bool("enable-secret-provider-class-status", false, "Aggregate the usage of SecretProviderClasses by pods on the node into the SecretProviderClass status")
+
+	// Enable the node local cache of the last successful mount response used when the provider is down
+	enableContentCache       = flag.Bool("enable-content-cache", false, "Mount the cached content of the last successful mount for new pods when the provider fails [alpha]")
+	contentCacheDir          = flag.String("content-cache-dir", "/var/lib/secrets-store-csi-driver/cache", "Directory on the node to store the encrypted content cache")
+	contentCacheKeyFile      = flag.String("content-cache-key-file", "", "File with the 32 byte node key used to encrypt the content cache. Required if the content cache is enabled, must not be in the content cache directory and is generated if it doesn't exist")
+	contentCacheMaxStaleness = flag.Duration("content-cache-max-staleness", 24*time.Hour, "Maximum age of cached content that is mounted when the provider fails")
+
+	// Enable the monitoring of the expiry of the certificates written by the driver
+	enableCertExpiryMonitor = flag.Bool("enable-cert-expiry-monitor", false, "Export the expiry of the certificates in the mounted volumes and synced kubernetes.io/tls secrets as metrics and emit warning events for expiring certificates")
+	certExpiryWarningWindow = flag.Duration("cert-expiry-warning-window", 30*24*time.Hour, "Duration before the expiry of a certificate at which warning events are emitted")
 
 	scheme = runtime.NewScheme()
 )
@@ -163,7 +182,17 @@ func mainErr() error {
 		return err
 	}
 
-	reconciler, err := controllers.New(*driverName, mgr, *nodeID)
+	var certMonitor *certmonitor.Monitor
+	if *enableCertExpiryMonitor {
+		klog.InfoS("certificate expiry monitor enabled", "warningWindow", *certExpiryWarningWindow)
+		if certMonitor, err = certmonitor.New(*certExpiryWarningWindow, mgr.GetEventRecorderFor("csi-secrets-store-cert-monitor")); err != nil {
+			klog.ErrorS(err, "failed to initialize certificate expiry monitor")
+			return err
+		}
+		go certMonitor.Run(ctx)
+	}
+
+	reconciler, err := controllers.New(*driverName, mgr, *nodeID, certMonitor)
 	if err != nil {
 		klog.ErrorS(err, "failed to create secret provider class pod status reconciler")
 		return err
@@ -172,6 +201,12 @@ func mainErr() error {
 		klog.ErrorS(err, "failed to create controller")
 		return err
 	}
+	if *enableSPCStatus {
+		if err = controllers.NewSecretProviderClassStatusReconciler(mgr, *nodeID).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "failed to create secret provider class status controller")
+			return err
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	// create provider clients
@@ -179,11 +214,39 @@ func mainErr() error {
 	providerPaths = append(providerPaths, *providerVolumePath)
 	providerClients := secretsstore.NewPluginClientBuilder(providerPaths, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(*maxCallRecvMsgSize)))
 	defer providerClients.Cleanup()
+	secretsstore.SetMaxMountStreamSize(*maxMountStreamSize)
+
+	if *providerRegistryConfig != "" {
+		registry, err := providerregistry.Load(*providerRegistryConfig)
+		if err != nil {
+			klog.ErrorS(err, "failed to load provider registry config", "path", *providerRegistryConfig)
+			return err
+		}
+		providerClients.SetRegistry(registry)
+		go func() {
+			if err := providerregistry.Watch(ctx, *providerRegistryConfig, registry, providerClients.SetRegistry); err != nil {
+				klog.ErrorS(err, "failed to watch provider registry config", "path", *providerRegistryConfig)
+			}
+		}()
+	}
+
+	reporter, err := secretsstore.NewStatsReporter()
+	if err != nil {
+		klog.ErrorS(err, "failed to initialize stats reporter")
+		return err
+	}
+
+	// watch the provider sockets to keep the inventory of the providers available on the node
+	go func() {
+		if err := providerClients.Watch(ctx, reporter); err != nil {
+			klog.ErrorS(err, "failed to watch provider sockets")
+		}
+	}()
 
 	// enable provider health check
 	if *providerHealthCheck {
-		klog.InfoS("provider health check enabled", "interval", *providerHealthCheckInterval)
-		go providerClients.HealthCheck(ctx, *providerHealthCheckInterval)
+		klog.InfoS("provider health check enabled", "interval", *providerHealthCheckInterval, "failureThreshold", *providerHealthCheckFailures)
+		go providerClients.HealthCheck(ctx, *providerHealthCheckInterval, *providerHealthCheckFailures, reporter)
 	}
 
 	go func() {
@@ -198,7 +261,16 @@ func mainErr() error {
 		reconciler.RunPatcher(ctx)
 	}()
 
-	driver := secretsstore.NewSecretsStoreDriver(*driverName, *nodeID, *endpoint, providerClients, mgr.GetClient(), mgr.GetAPIReader(), *enableSecretRotation, *rotationPollInterval)
+	var contentCache *contentcache.Cache
+	if *enableContentCache {
+		klog.InfoS("content cache enabled", "dir", *contentCacheDir, "keyFile", *contentCacheKeyFile, "maxStaleness", *contentCacheMaxStaleness)
+		if contentCache, err = contentcache.New(*contentCacheDir, *contentCacheKeyFile, *contentCacheMaxStaleness); err != nil {
+			klog.ErrorS(err, "failed to initialize content cache")
+			return err
+		}
+	}
+
+	driver := secretsstore.NewSecretsStoreDriver(*driverName, *nodeID, *endpoint, providerClients, mgr.GetClient(), mgr.GetAPIReader(), *enableSecretRotation, *rotationPollInterval, contentCache, certMonitor, reporter, mgr.GetEventRecorderFor("csi-secrets-store-node"))
 	driver.Run(ctx)
 
 	return nil
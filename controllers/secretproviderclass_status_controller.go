@@ -0,0 +1,312 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/spcpsutil"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// staleNodeStatusThreshold is the duration after which a node entry in the
+	// secret provider class status is checked for a node that no longer exists
+	staleNodeStatusThreshold = time.Hour
+	// unknownErrorReason is used for failing pods without an error reason
+	unknownErrorReason = "Unknown"
+)
+
+// SecretProviderClassStatusReconciler aggregates the secret provider class pod statuses
+// on the node into the status of the referenced SecretProviderClass. Each node only
+// writes its own entry in status.byNode and the totals are computed from all the entries.
+// The status is shared by all the nodes, so it's only read from the API server and updated
+// when the entry of the node changed.
+type SecretProviderClassStatusReconciler struct {
+	client.Client
+	// reader is the cache that contains the secret provider class pod statuses for the node
+	reader client.Reader
+	// apiReader reads directly from the API server to avoid conflicts with stale objects
+	apiReader client.Reader
+	nodeID    string
+
+	mutex sync.Mutex
+	// written is the last entry of the node written in the status of the secret provider
+	// classes. The cached secret provider class can lag behind the writes of the node.
+	written map[types.NamespacedName]secretsstorev1.ByNodeStatus
+}
+
+// NewSecretProviderClassStatusReconciler creates a new SecretProviderClassStatusReconciler
+func NewSecretProviderClassStatusReconciler(mgr manager.Manager, nodeID string) *SecretProviderClassStatusReconciler {
+	return &SecretProviderClassStatusReconciler{
+		Client:    mgr.GetClient(),
+		reader:    mgr.GetCache(),
+		apiReader: mgr.GetAPIReader(),
+		nodeID:    nodeID,
+	}
+}
+
+// +kubebuilder:rbac:groups=secrets-store.csi.x-k8s.io,resources=secretproviderclasses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get
+
+func (r *SecretProviderClassStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	klog.V(5).InfoS("secret provider class status reconcile started", "spc", req.NamespacedName.String())
+
+	spcPodStatusList := &secretsstorev1.SecretProviderClassPodStatusList{}
+	if err := r.reader.List(ctx, spcPodStatusList, client.InNamespace(req.Namespace), client.MatchingLabels{secretsstorev1.InternalNodeLabel: r.nodeID}); err != nil {
+		klog.ErrorS(err, "failed to list secret provider class pod status", "spc", req.NamespacedName.String())
+		return ctrl.Result{}, err
+	}
+	var spcPodStatuses []secretsstorev1.SecretProviderClassPodStatus
+	for i := range spcPodStatusList.Items {
+		if spcPodStatusList.Items[i].Status.SecretProviderClassName == req.Name {
+			spcPodStatuses = append(spcPodStatuses, spcPodStatusList.Items[i])
+		}
+	}
+	nodeStatus := buildNodeStatus(r.nodeID, spcPodStatuses)
+	if r.nodeStatusUpToDate(ctx, req.NamespacedName, nodeStatus) {
+		klog.V(5).InfoS("secret provider class status of the node is up to date", "spc", req.NamespacedName.String())
+		return ctrl.Result{}, nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		spc := &secretsstorev1.SecretProviderClass{}
+		if err := r.apiReader.Get(ctx, req.NamespacedName, spc); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		status := spc.Status.DeepCopy()
+		setNodeStatus(status, nodeStatus, time.Now())
+		r.pruneStaleNodeStatus(ctx, status)
+		aggregateNodeStatus(status)
+
+		if reflect.DeepEqual(*status, spc.Status) {
+			return nil
+		}
+		spc.Status = *status
+		return r.Status().Update(ctx, spc)
+	})
+	if err != nil {
+		klog.ErrorS(err, "failed to update secret provider class status", "spc", req.NamespacedName.String())
+		return ctrl.Result{}, err
+	}
+	r.mutex.Lock()
+	if r.written == nil {
+		r.written = make(map[types.NamespacedName]secretsstorev1.ByNodeStatus)
+	}
+	r.written[req.NamespacedName] = nodeStatus
+	r.mutex.Unlock()
+
+	klog.V(5).InfoS("secret provider class status reconcile complete", "spc", req.NamespacedName.String())
+	return ctrl.Result{}, nil
+}
+
+func (r *SecretProviderClassStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("secretproviderclass-status").
+		Watches(&secretsstorev1.SecretProviderClassPodStatus{}, handler.EnqueueRequestsFromMapFunc(mapSecretProviderClassPodStatusToSecretProviderClass)).
+		Complete(r)
+}
+
+// mapSecretProviderClassPodStatusToSecretProviderClass maps the secret provider class pod status
+// to the secret provider class it references
+func mapSecretProviderClassPodStatusToSecretProviderClass(_ context.Context, obj client.Object) []reconcile.Request {
+	spcPodStatus, ok := obj.(*secretsstorev1.SecretProviderClassPodStatus)
+	if !ok || spcPodStatus.Status.SecretProviderClassName == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: client.ObjectKey{Namespace: spcPodStatus.Namespace, Name: spcPodStatus.Status.SecretProviderClassName}},
+	}
+}
+
+// nodeStatusUpToDate returns true if the entry of the node in the cached secret provider class
+// status and the last entry written by the node match the node status, ignoring the last
+// update time. A node without pods has no entry.
+func (r *SecretProviderClassStatusReconciler) nodeStatusUpToDate(ctx context.Context, key types.NamespacedName, nodeStatus secretsstorev1.ByNodeStatus) bool {
+	r.mutex.Lock()
+	written, ok := r.written[key]
+	r.mutex.Unlock()
+	if ok && !equalNodeStatus(written, nodeStatus) {
+		return false
+	}
+
+	spc := &secretsstorev1.SecretProviderClass{}
+	if err := r.Client.Get(ctx, key, spc); err != nil {
+		return false
+	}
+	for _, current := range spc.Status.ByNode {
+		if current.NodeName == r.nodeID {
+			return nodeStatus.PodCount > 0 && equalNodeStatus(current, nodeStatus)
+		}
+	}
+	return nodeStatus.PodCount == 0
+}
+
+// equalNodeStatus returns true if the node entries are equal, ignoring the last update time
+func equalNodeStatus(a, b secretsstorev1.ByNodeStatus) bool {
+	a.LastUpdateTime = b.LastUpdateTime
+	return reflect.DeepEqual(a, b)
+}
+
+// pruneStaleNodeStatus removes the entries of nodes that haven't been updated in a while
+// and no longer exist. The driver on a deleted node can't remove its own entry.
+func (r *SecretProviderClassStatusReconciler) pruneStaleNodeStatus(ctx context.Context, status *secretsstorev1.SecretProviderClassStatus) {
+	byNode := status.ByNode[:0]
+	for _, nodeStatus := range status.ByNode {
+		if nodeStatus.NodeName != r.nodeID && time.Since(nodeStatus.LastUpdateTime.Time) > staleNodeStatusThreshold {
+			err := r.apiReader.Get(ctx, client.ObjectKey{Name: nodeStatus.NodeName}, &corev1.Node{})
+			if apierrors.IsNotFound(err) {
+				klog.InfoS("removing secret provider class status for deleted node", "node", nodeStatus.NodeName)
+				continue
+			}
+		}
+		byNode = append(byNode, nodeStatus)
+	}
+	status.ByNode = byNode
+}
+
+// isFailedSecretProviderClassPodStatus returns true and the error reason if the last mount
+// or rotation request for the pod failed
+func isFailedSecretProviderClassPodStatus(spcPodStatus *secretsstorev1.SecretProviderClassPodStatus) (bool, string) {
+	if c := meta.FindStatusCondition(spcPodStatus.Status.Conditions, secretsstorev1.ConditionTypeContentUpToDate); c != nil && c.Status == metav1.ConditionFalse {
+		return true, c.Reason
+	}
+	if !spcPodStatus.Status.Mounted {
+		if c := meta.FindStatusCondition(spcPodStatus.Status.Conditions, secretsstorev1.ConditionTypeMounted); c != nil && c.Reason != "" {
+			return true, c.Reason
+		}
+		return true, unknownErrorReason
+	}
+	return false, ""
+}
+
+// buildNodeStatus builds the node entry for the secret provider class status from the
+// secret provider class pod statuses on the node
+func buildNodeStatus(nodeName string, spcPodStatuses []secretsstorev1.SecretProviderClassPodStatus) secretsstorev1.ByNodeStatus {
+	nodeStatus := secretsstorev1.ByNodeStatus{NodeName: nodeName}
+	reasons := make(map[string]int32)
+	var objects []secretsstorev1.SecretProviderClassObject
+	for i := range spcPodStatuses {
+		nodeStatus.PodCount++
+		if failed, reason := isFailedSecretProviderClassPodStatus(&spcPodStatuses[i]); failed {
+			nodeStatus.FailedPodCount++
+			reasons[reason]++
+		}
+		objects = append(objects, spcPodStatuses[i].Status.Objects...)
+	}
+	nodeStatus.ErrorReasons = errorReasonCounts(reasons)
+	nodeStatus.Objects = uniqueObjects(objects)
+	return nodeStatus
+}
+
+// setNodeStatus sets the node entry in the status. The entry is removed if there are no
+// pods on the node. The last update time is only changed if the entry changed.
+func setNodeStatus(status *secretsstorev1.SecretProviderClassStatus, nodeStatus secretsstorev1.ByNodeStatus, now time.Time) {
+	for i := range status.ByNode {
+		if status.ByNode[i].NodeName != nodeStatus.NodeName {
+			continue
+		}
+		if nodeStatus.PodCount == 0 {
+			status.ByNode = append(status.ByNode[:i], status.ByNode[i+1:]...)
+			return
+		}
+		nodeStatus.LastUpdateTime = status.ByNode[i].LastUpdateTime
+		if !reflect.DeepEqual(status.ByNode[i], nodeStatus) {
+			nodeStatus.LastUpdateTime = metav1.NewTime(now)
+			status.ByNode[i] = nodeStatus
+		}
+		return
+	}
+	if nodeStatus.PodCount == 0 {
+		return
+	}
+	nodeStatus.LastUpdateTime = metav1.NewTime(now)
+	status.ByNode = append(status.ByNode, nodeStatus)
+	sort.Slice(status.ByNode, func(i, j int) bool {
+		return status.ByNode[i].NodeName < status.ByNode[j].NodeName
+	})
+}
+
+// aggregateNodeStatus computes the totals in the status from all the node entries
+func aggregateNodeStatus(status *secretsstorev1.SecretProviderClassStatus) {
+	status.PodCount, status.NodeCount, status.FailedPodCount = 0, 0, 0
+	reasons := make(map[string]int32)
+	var objects []secretsstorev1.SecretProviderClassObject
+	for _, nodeStatus := range status.ByNode {
+		status.PodCount += nodeStatus.PodCount
+		status.FailedPodCount += nodeStatus.FailedPodCount
+		if nodeStatus.PodCount > 0 {
+			status.NodeCount++
+		}
+		for _, reason := range nodeStatus.ErrorReasons {
+			reasons[reason.Reason] += reason.Count
+		}
+		objects = append(objects, nodeStatus.Objects...)
+	}
+	status.ErrorReasons = errorReasonCounts(reasons)
+	status.Objects = uniqueObjects(objects)
+}
+
+// errorReasonCounts converts the reasons map to a list sorted by reason
+func errorReasonCounts(reasons map[string]int32) []secretsstorev1.ErrorReasonCount {
+	if len(reasons) == 0 {
+		return nil
+	}
+	counts := make([]secretsstorev1.ErrorReasonCount, 0, len(reasons))
+	for reason, count := range reasons {
+		counts = append(counts, secretsstorev1.ErrorReasonCount{Reason: reason, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Reason < counts[j].Reason
+	})
+	return counts
+}
+
+// uniqueObjects returns the set of object versions sorted by ID and version
+func uniqueObjects(objects []secretsstorev1.SecretProviderClassObject) []secretsstorev1.SecretProviderClassObject {
+	if len(objects) == 0 {
+		return nil
+	}
+	seen := make(map[secretsstorev1.SecretProviderClassObject]struct{}, len(objects))
+	var unique []secretsstorev1.SecretProviderClassObject
+	for _, o := range objects {
+		if _, ok := seen[o]; ok {
+			continue
+		}
+		seen[o] = struct{}{}
+		unique = append(unique, o)
+	}
+	return spcpsutil.OrderSecretProviderClassObjectByIDAndVersion(unique)
+}
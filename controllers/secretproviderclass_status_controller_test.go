@@ -0,0 +1,198 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestSecretProviderClassStatusReconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spc := newSecretProviderClass("spc1", "default")
+	// entry reported by another node that must be preserved
+	spc.Status.ByNode = []secretsstorev1.ByNodeStatus{
+		{
+			NodeName:       "node2",
+			PodCount:       2,
+			Objects:        []secretsstorev1.SecretProviderClassObject{{ID: "secret/object1", Version: "v1"}},
+			LastUpdateTime: metav1.Now(),
+		},
+	}
+
+	mounted := newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1")
+	mounted.Status.Objects = []secretsstorev1.SecretProviderClassObject{{ID: "secret/object1", Version: "v2"}}
+
+	failed := newSecretProviderClassPodStatus("pod2-default-spc1", "default", "node1")
+	failed.Status.PodName = "pod2"
+	failed.Status.Mounted = false
+	failed.Status.Conditions = []metav1.Condition{
+		{Type: secretsstorev1.ConditionTypeMounted, Status: metav1.ConditionFalse, Reason: "GRPCProviderError"},
+		{Type: secretsstorev1.ConditionTypeContentUpToDate, Status: metav1.ConditionFalse, Reason: "GRPCProviderError"},
+	}
+
+	otherSPC := newSecretProviderClassPodStatus("pod3-default-spc2", "default", "node1")
+	otherSPC.Status.SecretProviderClassName = "spc2"
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(spc, mounted, failed, otherSPC).
+		WithStatusSubresource(&secretsstorev1.SecretProviderClass{}).
+		Build()
+	r := &SecretProviderClassStatusReconciler{
+		Client:    c,
+		reader:    c,
+		apiReader: c,
+		nodeID:    "node1",
+	}
+
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "spc1"}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got := &secretsstorev1.SecretProviderClass{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "spc1"}, got)).To(Succeed())
+	g.Expect(got.Status.PodCount).To(Equal(int32(4)))
+	g.Expect(got.Status.NodeCount).To(Equal(int32(2)))
+	g.Expect(got.Status.FailedPodCount).To(Equal(int32(1)))
+	g.Expect(got.Status.ErrorReasons).To(Equal([]secretsstorev1.ErrorReasonCount{{Reason: "GRPCProviderError", Count: 1}}))
+	g.Expect(got.Status.Objects).To(Equal([]secretsstorev1.SecretProviderClassObject{
+		{ID: "secret/object1", Version: "v1"},
+		{ID: "secret/object1", Version: "v2"},
+	}))
+	g.Expect(got.Status.ByNode).To(HaveLen(2))
+	g.Expect(got.Status.ByNode[0].NodeName).To(Equal("node1"))
+	g.Expect(got.Status.ByNode[0].PodCount).To(Equal(int32(2)))
+
+	// once the pods are deleted from the node, the node entry is removed
+	g.Expect(c.Delete(context.TODO(), mounted)).To(Succeed())
+	g.Expect(c.Delete(context.TODO(), failed)).To(Succeed())
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "spc1"}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "spc1"}, got)).To(Succeed())
+	g.Expect(got.Status.PodCount).To(Equal(int32(2)))
+	g.Expect(got.Status.NodeCount).To(Equal(int32(1)))
+	g.Expect(got.Status.FailedPodCount).To(BeZero())
+	g.Expect(got.Status.ErrorReasons).To(BeEmpty())
+	g.Expect(got.Status.ByNode).To(HaveLen(1))
+	g.Expect(got.Status.ByNode[0].NodeName).To(Equal("node2"))
+}
+
+func TestSecretProviderClassStatusReconcile_SkipsUnchangedNodeStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spc := newSecretProviderClass("spc1", "default")
+	mounted := newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1")
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(spc, mounted).
+		WithStatusSubresource(&secretsstorev1.SecretProviderClass{}).
+		Build()
+
+	var apiReads, updates int
+	apiReader := interceptor.NewClient(c, interceptor.Funcs{
+		Get: func(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			apiReads++
+			return client.Get(ctx, key, obj, opts...)
+		},
+	})
+	writer := interceptor.NewClient(c, interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, client client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			updates++
+			return client.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+	})
+	r := &SecretProviderClassStatusReconciler{
+		Client:    writer,
+		reader:    c,
+		apiReader: apiReader,
+		nodeID:    "node1",
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "spc1"}}
+
+	_, err = r.Reconcile(context.TODO(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apiReads).To(Equal(1))
+	g.Expect(updates).To(Equal(1))
+
+	// the entry of the node didn't change, the status isn't read from the API server or updated
+	_, err = r.Reconcile(context.TODO(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apiReads).To(Equal(1))
+	g.Expect(updates).To(Equal(1))
+
+	// the entry of the node changed
+	mounted.Status.Objects = []secretsstorev1.SecretProviderClassObject{{ID: "secret/object1", Version: "v2"}}
+	g.Expect(c.Update(context.TODO(), mounted)).To(Succeed())
+	_, err = r.Reconcile(context.TODO(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(apiReads).To(Equal(2))
+	g.Expect(updates).To(Equal(2))
+
+	// the entry of the node is written again if it was changed by others
+	got := &secretsstorev1.SecretProviderClass{}
+	g.Expect(c.Get(context.TODO(), req.NamespacedName, got)).To(Succeed())
+	got.Status.ByNode = nil
+	g.Expect(c.Status().Update(context.TODO(), got)).To(Succeed())
+	_, err = r.Reconcile(context.TODO(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(updates).To(Equal(3))
+	g.Expect(c.Get(context.TODO(), req.NamespacedName, got)).To(Succeed())
+	g.Expect(got.Status.ByNode).To(HaveLen(1))
+}
+
+func TestSetNodeStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := &secretsstorev1.SecretProviderClassStatus{}
+	nodeStatus := secretsstorev1.ByNodeStatus{NodeName: "node1", PodCount: 1}
+
+	setNodeStatus(status, nodeStatus, created)
+	g.Expect(status.ByNode).To(HaveLen(1))
+	g.Expect(status.ByNode[0].LastUpdateTime.Time).To(Equal(created))
+
+	// the last update time doesn't change if the entry is the same
+	setNodeStatus(status, nodeStatus, created.Add(time.Hour))
+	g.Expect(status.ByNode[0].LastUpdateTime.Time).To(Equal(created))
+
+	nodeStatus.PodCount = 2
+	setNodeStatus(status, nodeStatus, created.Add(time.Hour))
+	g.Expect(status.ByNode[0].PodCount).To(Equal(int32(2)))
+	g.Expect(status.ByNode[0].LastUpdateTime.Time).To(Equal(created.Add(time.Hour)))
+
+	setNodeStatus(status, secretsstorev1.ByNodeStatus{NodeName: "node1"}, created)
+	g.Expect(status.ByNode).To(BeEmpty())
+}
@@ -18,12 +18,16 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 
 	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/certmonitor"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/client/clientset/versioned/scheme"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/k8sutil"
@@ -31,30 +35,78 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/csaupgrade"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
 	SecretManagedLabel         = "secrets-store.csi.k8s.io/managed"
 	SecretUsedLabel            = "secrets-store.csi.k8s.io/used"
 	secretCreationFailedReason = "FailedToCreateSecret"
+	secretPrunedReason         = "SecretPruned"
+	secretPruneFailedReason    = "FailedToPruneSecret"
+	secretApplyConflictReason  = "SecretApplyConflict"
+	secretNotManagedReason     = "SecretNotManaged"
+	secretConflictReason       = "SecretConflict"
+	certificatesDroppedReason  = "CertificatesDropped"
+
+	// secretFieldManager is the field manager used to apply the synced secrets
+	secretFieldManager = "secrets-store-csi-driver"
+	// secretUpdateFieldManager is the field manager of the secrets written with update by
+	// previous versions of the driver, derived from the user agent
+	secretUpdateFieldManager = "csi-secrets-store"
+	// secretOwnerRefsFieldManager is the field manager used to patch the owner references of
+	// the synced secrets. It's separate from the apply field manager so the apply doesn't
+	// remove the owner references it doesn't declare.
+	secretOwnerRefsFieldManager = "secrets-store-csi-driver-owner-refs"
+
+	// SecretProviderClassAnnotation is set on the synced secrets to the name of the
+	// secret provider class that declares the secret
+	SecretProviderClassAnnotation = "secrets-store.csi.k8s.io/secret-provider-class"
+	// SecretManagedKeysAnnotation is set on the synced secrets to the comma separated
+	// list of data keys synced by the driver. Keys in the list that are no longer
+	// declared in the secret provider class are removed from the secret.
+	SecretManagedKeysAnnotation = "secrets-store.csi.k8s.io/managed-keys"
+	// SecretContentHashAnnotation is set on the synced secrets to the hash of the data
+	// synced by the driver. It's used to detect changes to the secret without comparing
+	// the data.
+	SecretContentHashAnnotation = "secrets-store.csi.k8s.io/content-hash"
+	// SecretRetainAnnotation set to "true" on a synced secret prevents the driver from
+	// deleting the secret or removing keys from it when they are no longer declared
+	SecretRetainAnnotation = "secrets-store.csi.k8s.io/retain"
+	// SecretAdoptedAnnotation is set to "true" on the secrets that existed before they were
+	// adopted with adoptExisting. They are not deleted when they are no longer declared.
+	SecretAdoptedAnnotation = "secrets-store.csi.k8s.io/adopted"
 
 	SyncSecretForbiddenWarning = "The secret operation failed with forbidden error. If you installed the CSI driver using helm, ensure syncSecret.enabled=true is set."
 )
 
+var (
+	// errSecretNotManaged is returned when the secret to sync exists and was not created by the driver
+	errSecretNotManaged = errors.New("secret exists and is not managed by the driver")
+	// errSecretConflict is returned when the secret to sync is synced from another secret provider class
+	errSecretConflict = errors.New("secret is synced from another secret provider class")
+)
+
 // SecretProviderClassPodStatusReconciler reconciles a SecretProviderClassPodStatus object
 type SecretProviderClassPodStatusReconciler struct {
 	client.Client
@@ -62,13 +114,17 @@ type SecretProviderClassPodStatusReconciler struct {
 	scheme        *apiruntime.Scheme
 	nodeID        string
 	reader        client.Reader
+	apiReader     client.Reader
 	writer        client.Writer
 	eventRecorder record.EventRecorder
 	driverName    string
+	// certMonitor tracks the expiry of the certificates in the synced kubernetes.io/tls
+	// secrets. It's nil if the certificate expiry monitor is disabled.
+	certMonitor *certmonitor.Monitor
 }
 
 // New creates a new SecretProviderClassPodStatusReconciler
-func New(driverName string, mgr manager.Manager, nodeID string) (*SecretProviderClassPodStatusReconciler, error) {
+func New(driverName string, mgr manager.Manager, nodeID string, certMonitor *certmonitor.Monitor) (*SecretProviderClassPodStatusReconciler, error) {
 	eventBroadcaster := record.NewBroadcaster()
 	kubeClient := kubernetes.NewForConfigOrDie(mgr.GetConfig())
 	eventBroadcaster.StartRecordingToSink(&clientcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
@@ -80,9 +136,11 @@ func New(driverName string, mgr manager.Manager, nodeID string) (*SecretProvider
 		scheme:        mgr.GetScheme(),
 		nodeID:        nodeID,
 		reader:        mgr.GetCache(),
+		apiReader:     mgr.GetAPIReader(),
 		writer:        mgr.GetClient(),
 		eventRecorder: recorder,
 		driverName:    driverName,
+		certMonitor:   certMonitor,
 	}, nil
 }
 
@@ -118,6 +176,10 @@ func (r *SecretProviderClassPodStatusReconciler) Patcher(ctx context.Context) er
 
 	spcPodStatuses := spcPodStatusList.Items
 	for i := range spcPodStatuses {
+		// secret provider class pod status for failed mounts don't have synced secrets
+		if !spcPodStatuses[i].Status.Mounted {
+			continue
+		}
 		spcName := spcPodStatuses[i].Status.SecretProviderClassName
 		spc := &secretsstorev1.SecretProviderClass{}
 		namespace := spcPodStatuses[i].Namespace
@@ -136,31 +198,9 @@ func (r *SecretProviderClassPodStatusReconciler) Patcher(ctx context.Context) er
 		if err != nil {
 			return fmt.Errorf("failed to fetch pod during patching, err: %w", err)
 		}
-		var ownerRefs []metav1.OwnerReference
-		for _, ownerRef := range pod.GetOwnerReferences() {
-			ownerRefs = append(ownerRefs, metav1.OwnerReference{
-				APIVersion: ownerRef.APIVersion,
-				Kind:       ownerRef.Kind,
-				UID:        ownerRef.UID,
-				Name:       ownerRef.Name,
-			})
-		}
-		// If a pod has no owner references, then it's a static pod and
-		// doesn't belong to a replicaset. In this case, use the spcps as
-		// owner reference just like we do it today
-		if len(ownerRefs) == 0 {
-			// Create a new owner ref.
-			gvk, err := apiutil.GVKForObject(&spcPodStatuses[i], r.scheme)
-			if err != nil {
-				return err
-			}
-			ref := metav1.OwnerReference{
-				APIVersion: gvk.GroupVersion().String(),
-				Kind:       gvk.Kind,
-				UID:        spcPodStatuses[i].GetUID(),
-				Name:       spcPodStatuses[i].GetName(),
-			}
-			ownerRefs = append(ownerRefs, ref)
+		ownerRefs, err := r.secretOwnerRefs(pod, &spcPodStatuses[i])
+		if err != nil {
+			return err
 		}
 
 		for _, secret := range spc.Spec.SecretObjects {
@@ -204,6 +244,37 @@ func (r *SecretProviderClassPodStatusReconciler) Patcher(ctx context.Context) er
 }
 
 // ListOptionsLabelSelector returns a ListOptions with a label selector for node name.
+// secretOwnerRefs returns the owner references set on the secrets synced for the pod
+func (r *SecretProviderClassPodStatusReconciler) secretOwnerRefs(pod *corev1.Pod, spcPodStatus *secretsstorev1.SecretProviderClassPodStatus) ([]metav1.OwnerReference, error) {
+	var ownerRefs []metav1.OwnerReference
+	for _, ownerRef := range pod.GetOwnerReferences() {
+		ownerRefs = append(ownerRefs, metav1.OwnerReference{
+			APIVersion: ownerRef.APIVersion,
+			Kind:       ownerRef.Kind,
+			UID:        ownerRef.UID,
+			Name:       ownerRef.Name,
+		})
+	}
+	// If a pod has no owner references, then it's a static pod and
+	// doesn't belong to a replicaset. In this case, use the spcps as
+	// owner reference just like we do it today
+	if len(ownerRefs) == 0 {
+		// Create a new owner ref.
+		gvk, err := apiutil.GVKForObject(spcPodStatus, r.scheme)
+		if err != nil {
+			return nil, err
+		}
+		ref := metav1.OwnerReference{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			UID:        spcPodStatus.GetUID(),
+			Name:       spcPodStatus.GetName(),
+		}
+		ownerRefs = append(ownerRefs, ref)
+	}
+	return ownerRefs, nil
+}
+
 func (r *SecretProviderClassPodStatusReconciler) ListOptionsLabelSelector() client.ListOption {
 	return client.MatchingLabels(map[string]string{
 		secretsstorev1.InternalNodeLabel: r.nodeID,
@@ -226,6 +297,8 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 	spcPodStatus := &secretsstorev1.SecretProviderClassPodStatus{}
 	if err := r.reader.Get(ctx, req.NamespacedName, spcPodStatus); err != nil {
 		if apierrors.IsNotFound(err) {
+			// the pod is gone, the secrets synced for it are no longer monitored
+			r.certMonitor.UntrackOwner(req.NamespacedName)
 			klog.InfoS("reconcile complete", "spcps", req.NamespacedName.String())
 			return ctrl.Result{}, nil
 		}
@@ -233,6 +306,13 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
+	// the secret provider class pod status is also created for failed mounts. There
+	// is no mounted content to sync until the mount succeeds.
+	if !spcPodStatus.Status.Mounted {
+		klog.V(5).InfoS("secrets store content not mounted, skipping reconcile", "spcps", klog.KObj(spcPodStatus))
+		return ctrl.Result{}, nil
+	}
+
 	// Obtain the full pod metadata. An object reference is needed for sending
 	// events and the UID is helpful for validating the SPCPS TargetPath.
 	pod := &corev1.Pod{}
@@ -261,6 +341,12 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
+	// delete the secrets synced for the secret provider class that are no longer declared
+	if err := r.pruneOrphanedSecrets(ctx, spc, spcPodStatus, pod); err != nil {
+		klog.ErrorS(err, "failed to prune orphaned secrets", "spc", klog.KObj(spc), "spcps", klog.KObj(spcPodStatus))
+		r.generateEvent(pod, corev1.EventTypeWarning, secretPruneFailedReason, err.Error())
+	}
+
 	if len(spc.Spec.SecretObjects) == 0 {
 		klog.InfoS("no secret objects defined for spc, nothing to reconcile", "spc", klog.KObj(spc), "spcps", klog.KObj(spcPodStatus))
 		return ctrl.Result{}, nil
@@ -286,6 +372,16 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 		klog.ErrorS(err, "failed to get mounted files", "spc", klog.KObj(spc), "pod", klog.KObj(pod), "spcps", klog.KObj(spcPodStatus))
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
 	}
+	// the secrets declared in other secret provider classes in the namespace are only synced
+	// from the secret provider class that synced them first
+	conflicts, err := r.conflictingSecretProviderClasses(ctx, spc)
+	if err != nil {
+		klog.ErrorS(err, "failed to list secret provider classes", "spc", klog.KObj(spc), "spcps", klog.KObj(spcPodStatus))
+		return ctrl.Result{}, err
+	}
+
+	r.updateSecretConflictCondition(ctx, spc, spcPodStatus, pod, conflicts)
+
 	errs := make([]error, 0)
 	for _, secretObj := range spc.Spec.SecretObjects {
 		secretName := strings.TrimSpace(secretObj.SecretName)
@@ -299,8 +395,19 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 		var funcs []func() (bool, error)
 		secretType := secretutil.GetSecretType(strings.TrimSpace(secretObj.Type))
 
+		var nodePublishSecrets map[string][]byte
+		if secretObj.PKCS12Password != nil && len(secretObj.PKCS12Password.NodePublishSecretRefKey) > 0 {
+			if nodePublishSecrets, err = r.getNodePublishSecretData(ctx, req.Namespace, podVol); err != nil {
+				r.generateEvent(pod, corev1.EventTypeWarning, secretCreationFailedReason, fmt.Sprintf("failed to get nodePublishSecretRef for secret %s, err: %+v", secretName, err))
+				klog.ErrorS(err, "failed to get nodePublishSecretRef", "spc", klog.KObj(spc), "pod", klog.KObj(pod), "secret", klog.ObjectRef{Namespace: req.Namespace, Name: secretName}, "spcps", klog.KObj(spcPodStatus))
+				errs = append(errs, fmt.Errorf("failed to get nodePublishSecretRef for secret %s, err: %w", secretName, err))
+				continue
+			}
+		}
+
 		var datamap map[string][]byte
-		if datamap, err = secretutil.GetSecretData(secretObj.Data, secretType, files); err != nil {
+		var dropped []string
+		if datamap, dropped, err = secretutil.GetSecretData(*secretObj, secretType, files, nodePublishSecrets); err != nil {
 			r.generateEvent(pod, corev1.EventTypeWarning, secretCreationFailedReason, fmt.Sprintf("failed to get data in spc %s/%s for secret %s, err: %+v", req.Namespace, spcName, secretName, err))
 			klog.ErrorS(err, "failed to get data in spc for secret", "spc", klog.KObj(spc), "pod", klog.KObj(pod), "secret", klog.ObjectRef{Namespace: req.Namespace, Name: secretName}, "spcps", klog.KObj(spcPodStatus))
 			errs = append(errs, fmt.Errorf("failed to get data in spc %s/%s for secret %s, err: %w", req.Namespace, spcName, secretName, err))
@@ -321,15 +428,31 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 		labelsMap[SecretManagedLabel] = "true"
 
 		createFn := func() (bool, error) {
-			if err := r.createOrUpdateK8sSecret(ctx, secretName, req.Namespace, datamap, labelsMap, annotationsMap, secretType); err != nil {
+			applied, err := r.createOrUpdateK8sSecret(ctx, secretName, req.Namespace, spc.Name, datamap, labelsMap, annotationsMap, secretType, secretObj.AdoptExisting)
+			if err != nil {
 				klog.ErrorS(err, "failed to create Kubernetes secret", "spc", klog.KObj(spc), "pod", klog.KObj(pod), "secret", klog.ObjectRef{Namespace: req.Namespace, Name: secretName}, "spcps", klog.KObj(spcPodStatus))
 				// syncSecret.enabled is set to false by default in the helm chart for installing the driver in v0.0.23+
 				// that would result in a forbidden error, so generate a warning that can be helpful for debugging
 				if apierrors.IsForbidden(err) {
 					klog.Warning(SyncSecretForbiddenWarning)
 				}
+				// the fields are owned by another field manager or the secret is owned by someone
+				// else, retrying won't resolve the conflict
+				if apierrors.IsConflict(err) || errors.Is(err, errSecretNotManaged) || errors.Is(err, errSecretConflict) {
+					return false, err
+				}
 				return false, nil
 			}
+			// the certificates of the objects that are not part of the chain are not synced,
+			// report them once when the secret is written
+			if applied && len(dropped) > 0 {
+				r.generateEvent(pod, corev1.EventTypeWarning, certificatesDroppedReason, fmt.Sprintf("certificates %s are not part of the certificate chain and were not synced to secret %s", strings.Join(dropped, ", "), secretName))
+			}
+			if secretType == corev1.SecretTypeTLS {
+				r.certMonitor.TrackSecret(req.NamespacedName, secretName, pod, spc, datamap)
+			} else {
+				r.certMonitor.UntrackSecret(req.Namespace, secretName)
+			}
 			return true, nil
 		}
 		funcs = append(funcs, createFn)
@@ -341,15 +464,27 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 				Factor:   1.0,
 				Jitter:   0.1,
 			}, f); err != nil {
-				r.generateEvent(pod, corev1.EventTypeWarning, secretCreationFailedReason, err.Error())
+				reason := secretCreationFailedReason
+				switch {
+				case apierrors.IsConflict(err):
+					reason = secretApplyConflictReason
+				case errors.Is(err, errSecretNotManaged):
+					reason = secretNotManagedReason
+				case errors.Is(err, errSecretConflict):
+					reason = secretConflictReason
+				}
+				r.generateEvent(pod, corev1.EventTypeWarning, reason, err.Error())
+				r.updateSecretSyncedCondition(ctx, spcPodStatus, metav1.ConditionFalse, reason, fmt.Sprintf("failed to create or update secret %s, err: %v", secretName, err))
 				return ctrl.Result{RequeueAfter: 5 * time.Second}, err
 			}
 		}
 	}
 
 	if len(errs) > 0 {
+		r.updateSecretSyncedCondition(ctx, spcPodStatus, metav1.ConditionFalse, secretCreationFailedReason, utilerrors.NewAggregate(errs).Error())
 		return ctrl.Result{Requeue: true}, nil
 	}
+	r.updateSecretSyncedCondition(ctx, spcPodStatus, metav1.ConditionTrue, secretsstorev1.ReasonSecretSynced, fmt.Sprintf("%d secrets synced", len(spc.Spec.SecretObjects)))
 
 	klog.InfoS("reconcile complete", "spc", klog.KObj(spc), "pod", klog.KObj(pod), "spcps", klog.KObj(spcPodStatus))
 	// requeue the spc pod status again after 5mins to check if secret and ownerRef exists
@@ -360,11 +495,98 @@ func (r *SecretProviderClassPodStatusReconciler) Reconcile(ctx context.Context,
 
 func (r *SecretProviderClassPodStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsstorev1.SecretProviderClassPodStatus{}).
-		WithEventFilter(r.belongsToNodePredicate()).
+		For(&secretsstorev1.SecretProviderClassPodStatus{}, builder.WithPredicates(r.belongsToNodePredicate())).
+		Watches(&secretsstorev1.SecretProviderClass{}, handler.EnqueueRequestsFromMapFunc(r.mapSecretProviderClassToPodStatuses), builder.WithPredicates(secretProviderClassChangedPredicate())).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToPodStatuses), builder.WithPredicates(secretChangedPredicate())).
 		Complete(r)
 }
 
+// mapSecretProviderClassToPodStatuses maps the secret provider class to the secret provider
+// class pod statuses on the node that reference it, so changes to the secret objects are
+// synced without waiting for the periodic reconcile.
+func (r *SecretProviderClassPodStatusReconciler) mapSecretProviderClassToPodStatuses(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := r.podStatusesForSecretProviderClass(ctx, obj.GetNamespace(), obj.GetName())
+	klog.V(5).InfoS("secret provider class changed, reconciling secret provider class pod statuses", "spc", klog.KObj(obj), "count", len(requests))
+	return requests
+}
+
+// mapSecretToPodStatuses maps the synced secret to the secret provider class pod statuses
+// on the node that reference the secret provider class it was synced from, so a secret
+// that was edited or deleted is repaired without waiting for the periodic reconcile.
+func (r *SecretProviderClassPodStatusReconciler) mapSecretToPodStatuses(ctx context.Context, obj client.Object) []reconcile.Request {
+	spcName := obj.GetAnnotations()[SecretProviderClassAnnotation]
+	if spcName == "" {
+		return nil
+	}
+	requests := r.podStatusesForSecretProviderClass(ctx, obj.GetNamespace(), spcName)
+	klog.V(5).InfoS("synced secret changed, reconciling secret provider class pod statuses", "secret", klog.KObj(obj), "spc", spcName, "count", len(requests))
+	return requests
+}
+
+// podStatusesForSecretProviderClass returns the requests for the secret provider class pod
+// statuses on the node that reference the secret provider class.
+func (r *SecretProviderClassPodStatusReconciler) podStatusesForSecretProviderClass(ctx context.Context, namespace, spcName string) []reconcile.Request {
+	spcPodStatusList := &secretsstorev1.SecretProviderClassPodStatusList{}
+	if err := r.reader.List(ctx, spcPodStatusList, client.InNamespace(namespace), r.ListOptionsLabelSelector()); err != nil {
+		klog.ErrorS(err, "failed to list secret provider class pod statuses", "spc", klog.ObjectRef{Namespace: namespace, Name: spcName})
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range spcPodStatusList.Items {
+		if spcPodStatusList.Items[i].Status.SecretProviderClassName == spcName {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&spcPodStatusList.Items[i])})
+		}
+	}
+	return requests
+}
+
+// secretProviderClassChangedPredicate filters the secret provider class events to the
+// creations and spec changes. The pod statuses of a deleted secret provider class can't
+// be reconciled.
+func secretProviderClassChangedPredicate() predicate.Predicate {
+	return predicate.And(
+		predicate.GenerationChangedPredicate{},
+		predicate.Funcs{
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+		},
+	)
+}
+
+// secretChangedPredicate filters the synced secret events to the updates and deletions
+// of the secrets managed by the driver. Updates that don't change the secret content
+// (eg. status or managed fields only) are skipped.
+func secretChangedPredicate() predicate.Predicate {
+	return predicate.And(
+		predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetLabels()[SecretManagedLabel] == "true"
+		}),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+				if !ok {
+					return false
+				}
+				newSecret, ok := e.ObjectNew.(*corev1.Secret)
+				if !ok {
+					return false
+				}
+				return oldSecret.Type != newSecret.Type ||
+					!reflect.DeepEqual(oldSecret.Data, newSecret.Data) ||
+					!reflect.DeepEqual(oldSecret.Labels, newSecret.Labels) ||
+					!reflect.DeepEqual(oldSecret.Annotations, newSecret.Annotations)
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
+
 // belongsToNodePredicate defines predicates for handlers
 func (r *SecretProviderClassPodStatusReconciler) belongsToNodePredicate() predicate.Funcs {
 	return predicate.Funcs{
@@ -398,36 +620,472 @@ func (r *SecretProviderClassPodStatusReconciler) processIfBelongsToNode(objMeta
 
 // createOrUpdateK8sSecret creates K8s secret with data from mounted files
 // If a secret with the same name already exists in the namespace of the pod, it will update that existing secret.
-func (r *SecretProviderClassPodStatusReconciler) createOrUpdateK8sSecret(ctx context.Context, name, namespace string, datamap map[string][]byte, labelsmap map[string]string, annotationsmap map[string]string, secretType corev1.SecretType) error {
-	secret := &corev1.Secret{
+// The secret is written with server-side apply, the driver only owns the data keys, labels, annotations and type it
+// syncs. Keys that were synced before but are not in datamap anymore are removed from the existing secret and the
+// fields set by others are kept. The secret is not updated if it's already in sync, which is detected with the
+// content hash annotation.
+// An existing secret that was not created by the driver is only taken over if adoptExisting is set, and a secret
+// synced from another secret provider class that still declares it is not updated.
+// It returns true if the secret was created or updated.
+func (r *SecretProviderClassPodStatusReconciler) createOrUpdateK8sSecret(ctx context.Context, name, namespace, spcName string, datamap map[string][]byte, labelsmap map[string]string, annotationsmap map[string]string, secretType corev1.SecretType, adoptExisting bool) (bool, error) {
+	// the cache only contains the secrets managed by the driver
+	existing := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, existing)
+	if apierrors.IsNotFound(err) {
+		err = r.apiReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, existing)
+	}
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		existing = nil
+	}
+
+	adopt := false
+	if existing != nil {
+		if existing.Labels[SecretManagedLabel] != "true" {
+			if !adoptExisting {
+				return false, fmt.Errorf("%w: %s/%s, set adoptExisting in the secret object to take it over", errSecretNotManaged, namespace, name)
+			}
+			klog.InfoS("adopting existing Kubernetes secret", "secret", klog.ObjectRef{Namespace: namespace, Name: name}, "spc", klog.ObjectRef{Namespace: namespace, Name: spcName})
+			adopt = true
+		} else if err := r.checkSecretOwner(ctx, existing, spcName); err != nil {
+			return false, err
+		}
+	}
+
+	secret, err := newSyncedSecret(name, namespace, spcName, datamap, labelsmap, annotationsmap, secretType, existing)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if secretInSync(existing, secret) {
+			klog.V(5).InfoS("Kubernetes secret is in sync", "secret", klog.ObjectRef{Namespace: namespace, Name: name})
+			return false, nil
+		}
+		// secrets written by previous versions of the driver with update are owned by the
+		// update field manager. Move the ownership to the apply field manager, otherwise the
+		// fields that are not synced anymore are not removed and changed fields conflict.
+		patch, err := upgradeManagedFieldsPatch(existing)
+		if err != nil {
+			return false, fmt.Errorf("failed to upgrade managed fields of secret %s/%s, err: %w", namespace, name, err)
+		}
+		if patch != nil {
+			if err := r.writer.Patch(ctx, existing, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+				return false, fmt.Errorf("failed to upgrade managed fields of secret %s/%s, err: %w", namespace, name, err)
+			}
+		}
+	}
+
+	opts := []client.PatchOption{client.FieldOwner(secretFieldManager)}
+	if adopt {
+		// take over the fields set by the previous owner of the secret
+		opts = append(opts, client.ForceOwnership)
+	}
+	// fields synced by the driver that were changed by another field manager are not taken
+	// back, the apply fails with a conflict that's reported on the pod and the spc pod status
+	if err := r.writer.Patch(ctx, secret, client.Apply, opts...); err != nil {
+		return false, err
+	}
+	klog.InfoS("successfully applied Kubernetes secret", "secret", klog.ObjectRef{Namespace: namespace, Name: name})
+	return true, nil
+}
+
+// getNodePublishSecretData returns the data of the nodePublishSecretRef secret of the
+// pod volume. The secret is read from the API server as it isn't managed by the driver.
+func (r *SecretProviderClassPodStatusReconciler) getNodePublishSecretData(ctx context.Context, namespace string, podVol *corev1.Volume) (map[string][]byte, error) {
+	if podVol.CSI == nil || podVol.CSI.NodePublishSecretRef == nil || len(podVol.CSI.NodePublishSecretRef.Name) == 0 {
+		return nil, fmt.Errorf("nodePublishSecretRef is not set in volume %s", podVol.Name)
+	}
+	secret := &corev1.Secret{}
+	if err := r.apiReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: podVol.CSI.NodePublishSecretRef.Name}, secret); err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// checkSecretOwner returns an error if the secret was synced from another secret provider class
+// that still declares it. The secret is taken over if the secret provider class doesn't declare
+// it anymore, e.g. when the secret object was moved to another secret provider class.
+func (r *SecretProviderClassPodStatusReconciler) checkSecretOwner(ctx context.Context, secret *corev1.Secret, spcName string) error {
+	owner := secret.Annotations[SecretProviderClassAnnotation]
+	if owner == "" || owner == spcName {
+		return nil
+	}
+	ownerSPC := &secretsstorev1.SecretProviderClass{}
+	if err := r.reader.Get(ctx, client.ObjectKey{Namespace: secret.Namespace, Name: owner}, ownerSPC); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if declaredSecrets(ownerSPC).Has(secret.Name) {
+		return fmt.Errorf("%w: %s/%s is synced from secret provider class %s", errSecretConflict, secret.Namespace, secret.Name, owner)
+	}
+	return nil
+}
+
+// conflictingSecretProviderClasses returns the names of the other secret provider classes in
+// the namespace that declare the secrets of the secret provider class, by secret name.
+func (r *SecretProviderClassPodStatusReconciler) conflictingSecretProviderClasses(ctx context.Context, spc *secretsstorev1.SecretProviderClass) (map[string][]string, error) {
+	spcList := &secretsstorev1.SecretProviderClassList{}
+	if err := r.reader.List(ctx, spcList, client.InNamespace(spc.Namespace)); err != nil {
+		return nil, err
+	}
+	declared := declaredSecrets(spc)
+	conflicts := make(map[string][]string)
+	for i := range spcList.Items {
+		if spcList.Items[i].Name == spc.Name {
+			continue
+		}
+		for _, name := range sets.List(declaredSecrets(&spcList.Items[i]).Intersection(declared)) {
+			conflicts[name] = append(conflicts[name], spcList.Items[i].Name)
+		}
+	}
+	return conflicts, nil
+}
+
+// newSyncedSecret returns the secret to apply with the data, labels, annotations and type
+// synced by the driver. The keys in the managed keys annotation of the existing secret that
+// are not in datamap are kept if the secret is retained, otherwise they are removed by the
+// apply as they are not owned by the driver anymore.
+func newSyncedSecret(name, namespace, spcName string, datamap map[string][]byte, labelsmap map[string]string, annotationsmap map[string]string, secretType corev1.SecretType, existing *corev1.Secret) (*corev1.Secret, error) {
+	data := make(map[string][]byte, len(datamap))
+	for k, v := range datamap {
+		data[k] = v
+	}
+	retain := annotationsmap[SecretRetainAnnotation] == "true"
+	if existing != nil {
+		retain = retain || existing.Annotations[SecretRetainAnnotation] == "true"
+		for _, key := range managedKeys(existing) {
+			if _, ok := data[key]; ok {
+				continue
+			}
+			value, ok := existing.Data[key]
+			if !ok {
+				continue
+			}
+			if retain {
+				data[key] = value
+				continue
+			}
+			klog.InfoS("removing key no longer declared in secret provider class from secret", "secret", klog.KObj(existing), "key", key)
+		}
+	}
+
+	contentHash, err := secretutil.GetSHAFromSecret(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute content hash for secret %s/%s, err: %w", namespace, name, err)
+	}
+	annotations := make(map[string]string, len(annotationsmap)+3)
+	for k, v := range annotationsmap {
+		annotations[k] = v
+	}
+	annotations[SecretProviderClassAnnotation] = spcName
+	annotations[SecretManagedKeysAnnotation] = strings.Join(sets.List(sets.KeySet(data)), ",")
+	annotations[SecretContentHashAnnotation] = contentHash
+	if existing != nil && (existing.Labels[SecretManagedLabel] != "true" || existing.Annotations[SecretAdoptedAnnotation] == "true") {
+		annotations[SecretAdoptedAnnotation] = "true"
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:   namespace,
 			Name:        name,
 			Labels:      labelsmap,
-			Annotations: annotationsmap,
+			Annotations: annotations,
 		},
 		Type: secretType,
-		Data: datamap,
+		Data: data,
+	}, nil
+}
+
+// secretInSync returns true if the existing secret has the data, labels, annotations
+// and type of the desired secret and no key that should be removed. The content hash
+// annotation of the existing secret must match the hash of its data so a secret that
+// was edited is updated even if the annotation was copied.
+func secretInSync(existing, desired *corev1.Secret) bool {
+	if existing.Type != desired.Type {
+		return false
+	}
+	for k, v := range desired.Labels {
+		if value, ok := existing.Labels[k]; !ok || value != v {
+			return false
+		}
 	}
+	for k, v := range desired.Annotations {
+		if value, ok := existing.Annotations[k]; !ok || value != v {
+			return false
+		}
+	}
+	data := make(map[string][]byte, len(desired.Data))
+	for k := range desired.Data {
+		if _, ok := existing.Data[k]; !ok {
+			return false
+		}
+		data[k] = existing.Data[k]
+	}
+	hash, err := secretutil.GetSHAFromSecret(data)
+	return err == nil && hash == desired.Annotations[SecretContentHashAnnotation]
+}
 
-	err := r.writer.Create(ctx, secret)
-	if err == nil {
-		klog.InfoS("successfully created Kubernetes secret", "secret", klog.ObjectRef{Namespace: namespace, Name: name})
+// upgradeManagedFieldsPatch returns the JSON patch that moves the ownership of the data,
+// type, labels and annotations of a secret written with update by previous versions of the
+// driver to the apply field manager, or nil if there is nothing to move. The other fields
+// of the update field manager, e.g. the owner references set by previous versions of the
+// patcher, stay with it so the apply doesn't remove them. The patch is only needed once,
+// the update field manager doesn't own synced fields anymore after it.
+func upgradeManagedFieldsPatch(secret *corev1.Secret) ([]byte, error) {
+	upgraded := secret.DeepCopy()
+	var remaining []metav1.ManagedFieldsEntry
+	for i := range upgraded.ManagedFields {
+		entry := &upgraded.ManagedFields[i]
+		if entry.Manager != secretUpdateFieldManager || entry.Operation != metav1.ManagedFieldsOperationUpdate || entry.Subresource != "" {
+			continue
+		}
+		synced, other, err := splitSyncedFields(entry.FieldsV1)
+		if err != nil {
+			return nil, err
+		}
+		if synced == nil {
+			return nil, nil
+		}
+		entry.FieldsV1 = synced
+		if other != nil {
+			otherEntry := *entry.DeepCopy()
+			otherEntry.FieldsV1 = other
+			remaining = append(remaining, otherEntry)
+		}
+		break
+	}
+	if err := csaupgrade.UpgradeManagedFields(upgraded, sets.New(secretUpdateFieldManager), secretFieldManager); err != nil {
+		return nil, err
+	}
+	upgraded.ManagedFields = append(upgraded.ManagedFields, remaining...)
+	if reflect.DeepEqual(upgraded.ManagedFields, secret.ManagedFields) {
+		return nil, nil
+	}
+	// replace the resource version to fail with a conflict if the secret changed
+	return json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/metadata/managedFields", "value": upgraded.ManagedFields},
+		{"op": "replace", "path": "/metadata/resourceVersion", "value": secret.ResourceVersion},
+	})
+}
+
+// splitSyncedFields splits the fields of a managed fields entry into the fields synced by
+// the driver, the data, type, labels and annotations, and the other fields. Either is nil
+// if it's empty.
+func splitSyncedFields(fields *metav1.FieldsV1) (synced, other *metav1.FieldsV1, err error) {
+	if fields == nil {
+		return nil, nil, nil
+	}
+	all := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(fields.Raw, &all); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode managed fields, err: %w", err)
+	}
+	metadata := make(map[string]json.RawMessage)
+	if raw, ok := all["f:metadata"]; ok {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode managed fields, err: %w", err)
+		}
+		delete(all, "f:metadata")
+	}
+
+	syncedFields := make(map[string]json.RawMessage)
+	for _, field := range []string{"f:data", "f:type"} {
+		if raw, ok := all[field]; ok {
+			syncedFields[field] = raw
+			delete(all, field)
+		}
+	}
+	syncedMetadata := make(map[string]json.RawMessage)
+	for _, field := range []string{"f:labels", "f:annotations"} {
+		if raw, ok := metadata[field]; ok {
+			syncedMetadata[field] = raw
+			delete(metadata, field)
+		}
+	}
+	if len(syncedMetadata) > 0 {
+		if syncedFields["f:metadata"], err = json.Marshal(syncedMetadata); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(metadata) > 0 {
+		if all["f:metadata"], err = json.Marshal(metadata); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if synced, err = encodeFieldsV1(syncedFields); err != nil {
+		return nil, nil, err
+	}
+	if other, err = encodeFieldsV1(all); err != nil {
+		return nil, nil, err
+	}
+	return synced, other, nil
+}
+
+// encodeFieldsV1 returns the encoded fields, or nil if there are no fields
+func encodeFieldsV1(fields map[string]json.RawMessage) (*metav1.FieldsV1, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.FieldsV1{Raw: raw}, nil
+}
+
+// managedKeys returns the data keys synced by the driver in the secret
+func managedKeys(secret *corev1.Secret) []string {
+	value := secret.Annotations[SecretManagedKeysAnnotation]
+	if value == "" {
 		return nil
 	}
-	if !apierrors.IsAlreadyExists(err) {
+	return strings.Split(value, ",")
+}
+
+// pruneOrphanedSecrets deletes the secrets synced for the secret provider class that
+// are no longer declared in its secret objects, unless another secret provider class
+// in the namespace declares them, they are retained with the retain annotation or they
+// were adopted. The secrets synced by previous versions of the driver don't have the
+// secret provider class annotation, they are matched with the owner references set for
+// the pod and the annotation is set before they are checked.
+func (r *SecretProviderClassPodStatusReconciler) pruneOrphanedSecrets(ctx context.Context, spc *secretsstorev1.SecretProviderClass, spcPodStatus *secretsstorev1.SecretProviderClassPodStatus, pod *corev1.Pod) error {
+	secretList := &corev1.SecretList{}
+	if err := r.reader.List(ctx, secretList, client.InNamespace(spc.Namespace), client.MatchingLabels{SecretManagedLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list secrets, err: %w", err)
+	}
+	ownerRefs, err := r.secretOwnerRefs(pod, spcPodStatus)
+	if err != nil {
 		return err
 	}
+	owners := sets.New[types.UID]()
+	for _, ref := range ownerRefs {
+		owners.Insert(ref.UID)
+	}
 
-	klog.V(5).InfoS("Kubernetes secret is already created", "secret", klog.ObjectRef{Namespace: namespace, Name: name})
-	err = r.writer.Update(ctx, secret)
-	if err != nil {
+	declared := declaredSecrets(spc)
+	var legacy, orphans []*corev1.Secret
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if _, ok := secret.Annotations[SecretProviderClassAnnotation]; !ok {
+			if isOwnedBy(secret, owners) {
+				legacy = append(legacy, secret)
+			}
+			continue
+		}
+		if secret.Annotations[SecretProviderClassAnnotation] != spc.Name || declared.Has(secret.Name) || isRetained(secret, spc) {
+			continue
+		}
+		orphans = append(orphans, secret)
+	}
+	if len(legacy) == 0 && len(orphans) == 0 {
+		return nil
+	}
+
+	// the secret could have been moved to another secret provider class, it's updated
+	// when the secret provider class is reconciled
+	spcList := &secretsstorev1.SecretProviderClassList{}
+	if err := r.reader.List(ctx, spcList, client.InNamespace(spc.Namespace)); err != nil {
+		return fmt.Errorf("failed to list secret provider classes, err: %w", err)
+	}
+	declaredByOthers := sets.New[string]()
+	for i := range spcList.Items {
+		if spcList.Items[i].Name != spc.Name {
+			declaredByOthers = declaredByOthers.Union(declaredSecrets(&spcList.Items[i]))
+		}
+	}
+
+	var errs []error
+	for _, secret := range legacy {
+		// the owners of the pod are shared by all the secret provider classes mounted by
+		// the pod, a secret declared by another one is left to it
+		if !declared.Has(secret.Name) && declaredByOthers.Has(secret.Name) {
+			continue
+		}
+		if err := r.backfillSecretProviderClassAnnotation(ctx, secret, spc.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set secret provider class annotation on secret %s/%s, err: %w", secret.Namespace, secret.Name, err))
+			continue
+		}
+		if !declared.Has(secret.Name) && !isRetained(secret, spc) {
+			orphans = append(orphans, secret)
+		}
+	}
+
+	for _, secret := range orphans {
+		if declaredByOthers.Has(secret.Name) {
+			continue
+		}
+		err := r.writer.Delete(ctx, secret, client.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete secret %s/%s, err: %w", secret.Namespace, secret.Name, err))
+			continue
+		}
+		klog.InfoS("deleted secret no longer declared in secret provider class", "secret", klog.KObj(secret), "spc", klog.KObj(spc))
+		r.certMonitor.UntrackSecret(secret.Namespace, secret.Name)
+		r.generateEvent(pod, corev1.EventTypeNormal, secretPrunedReason, fmt.Sprintf("deleted secret %s no longer declared in secret provider class %s", secret.Name, spc.Name))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// isRetained returns true if the secret that's no longer declared is kept with the retain
+// annotation or because it was adopted
+func isRetained(secret *corev1.Secret, spc *secretsstorev1.SecretProviderClass) bool {
+	if secret.Annotations[SecretRetainAnnotation] == "true" {
+		klog.V(5).InfoS("secret is no longer declared but retained", "secret", klog.KObj(secret), "spc", klog.KObj(spc))
+		return true
+	}
+	if secret.Annotations[SecretAdoptedAnnotation] == "true" {
+		klog.V(5).InfoS("secret is no longer declared but was adopted", "secret", klog.KObj(secret), "spc", klog.KObj(spc))
+		return true
+	}
+	return false
+}
+
+// backfillSecretProviderClassAnnotation sets the secret provider class annotation on a secret
+// synced by a previous version of the driver. The secret is patched with the update field
+// manager of the previous versions, so the annotation is taken over by the apply field manager
+// with the other synced fields on the next sync.
+func (r *SecretProviderClassPodStatusReconciler) backfillSecretProviderClassAnnotation(ctx context.Context, secret *corev1.Secret, spcName string) error {
+	patch := client.MergeFromWithOptions(secret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[SecretProviderClassAnnotation] = spcName
+	if err := r.writer.Patch(ctx, secret, patch); err != nil {
 		return err
 	}
-	klog.V(5).InfoS("successfully updated Kubernetes secret", "secret", klog.ObjectRef{Namespace: namespace, Name: name})
+	klog.InfoS("set secret provider class annotation on secret synced by a previous version", "secret", klog.KObj(secret), "spc", klog.ObjectRef{Namespace: secret.Namespace, Name: spcName})
 	return nil
 }
 
+// isOwnedBy returns true if the object has an owner reference with one of the uids
+func isOwnedBy(obj metav1.Object, uids sets.Set[types.UID]) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if uids.Has(ref.UID) {
+			return true
+		}
+	}
+	return false
+}
+
+// declaredSecrets returns the names of the secrets declared in the secret provider class
+func declaredSecrets(spc *secretsstorev1.SecretProviderClass) sets.Set[string] {
+	names := sets.New[string]()
+	for _, secretObj := range spc.Spec.SecretObjects {
+		if secretObj != nil {
+			names.Insert(strings.TrimSpace(secretObj.SecretName))
+		}
+	}
+	return names
+}
+
 // patchSecretWithOwnerRef patches the secret owner reference with the spc pod status
 func (r *SecretProviderClassPodStatusReconciler) patchSecretWithOwnerRef(ctx context.Context, name, namespace string, ownerRefs ...metav1.OwnerReference) error {
 	secret := &corev1.Secret{}
@@ -465,11 +1123,67 @@ func (r *SecretProviderClassPodStatusReconciler) patchSecretWithOwnerRef(ctx con
 
 	if needsPatch {
 		secret.SetOwnerReferences(secretOwnerRefs)
-		return r.writer.Patch(ctx, secret, patch)
+		return r.writer.Patch(ctx, secret, patch, client.FieldOwner(secretOwnerRefsFieldManager))
 	}
 	return nil
 }
 
+// updateSecretSyncedCondition sets the SecretSynced condition in the secret provider class pod status.
+// The status is only updated if the condition changed to avoid triggering needless reconciles.
+func (r *SecretProviderClassPodStatusReconciler) updateSecretSyncedCondition(ctx context.Context, spcPodStatus *secretsstorev1.SecretProviderClassPodStatus, status metav1.ConditionStatus, reason, message string) {
+	condition := metav1.Condition{
+		Type:    secretsstorev1.ConditionTypeSecretSynced,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if !meta.SetStatusCondition(&spcPodStatus.Status.Conditions, condition) {
+		return
+	}
+	if err := r.writer.Update(ctx, spcPodStatus); err != nil {
+		// the condition will be updated in the next reconcile
+		klog.ErrorS(err, "failed to update secret synced condition", "spcps", klog.KObj(spcPodStatus))
+	}
+}
+
+// updateSecretConflictCondition sets the SecretConflict condition in the secret provider class pod
+// status if secrets of the secret provider class are declared in other secret provider classes, or
+// removes it otherwise. The warning event is only generated when the conflicts change, not on every
+// reconcile.
+func (r *SecretProviderClassPodStatusReconciler) updateSecretConflictCondition(ctx context.Context, spc *secretsstorev1.SecretProviderClass, spcPodStatus *secretsstorev1.SecretProviderClassPodStatus, pod *corev1.Pod, conflicts map[string][]string) {
+	var messages []string
+	for _, secretObj := range spc.Spec.SecretObjects {
+		secretName := strings.TrimSpace(secretObj.SecretName)
+		if others := conflicts[secretName]; len(others) > 0 {
+			messages = append(messages, fmt.Sprintf("secret %s is also declared in secret provider classes %s", secretName, strings.Join(others, ", ")))
+		}
+	}
+
+	var changed bool
+	message := strings.Join(messages, "; ")
+	if len(messages) == 0 {
+		changed = meta.RemoveStatusCondition(&spcPodStatus.Status.Conditions, secretsstorev1.ConditionTypeSecretConflict)
+	} else {
+		changed = meta.SetStatusCondition(&spcPodStatus.Status.Conditions, metav1.Condition{
+			Type:    secretsstorev1.ConditionTypeSecretConflict,
+			Status:  metav1.ConditionTrue,
+			Reason:  secretConflictReason,
+			Message: message,
+		})
+	}
+	if !changed {
+		return
+	}
+	if err := r.writer.Update(ctx, spcPodStatus); err != nil {
+		// the condition will be updated, and the event generated, in the next reconcile
+		klog.ErrorS(err, "failed to update secret conflict condition", "spcps", klog.KObj(spcPodStatus))
+		return
+	}
+	if len(messages) > 0 {
+		r.generateEvent(pod, corev1.EventTypeWarning, secretConflictReason, message)
+	}
+}
+
 // generateEvent generates an event
 func (r *SecretProviderClassPodStatusReconciler) generateEvent(obj apiruntime.Object, eventType, reason, message string) {
 	if obj != nil {
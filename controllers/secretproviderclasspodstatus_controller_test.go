@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 
@@ -25,18 +26,28 @@ import (
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/apimachinery/pkg/util/managedfields/managedfieldstest"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var (
 	fakeRecorder = record.NewFakeRecorder(10)
+	// kubectlFieldOwner is the field owner of the changes made by users
+	kubectlFieldOwner = client.FieldOwner("kubectl")
 )
 
 func setupScheme() (*runtime.Scheme, error) {
@@ -108,10 +119,171 @@ func newPod(name, namespace string, owners []metav1.OwnerReference) *corev1.Pod
 	}
 }
 
+// newFieldManagedClient returns a fake client that tracks the managed fields of the secrets
+// like the API server, which the fake client doesn't support. Server-side apply merges the
+// fields of the field managers and fails with a conflict if a field is owned by another field
+// manager with a different value, unless forced. Writes without a field owner are recorded
+// for the field manager derived from the user agent of the driver. The secrets in initObjects
+// without managed fields are applied by the driver if they have the managed label, otherwise
+// they're created by kubectl.
+func newFieldManagedClient(scheme *runtime.Scheme, initObjects ...client.Object) client.Client {
+	secretGVK := corev1.SchemeGroupVersion.WithKind("Secret")
+	fieldManager := managedfieldstest.NewFakeFieldManager(managedfields.NewDeducedTypeConverter(), secretGVK)
+
+	var objects []client.Object
+	var secrets []*corev1.Secret
+	for _, obj := range initObjects {
+		if secret, ok := obj.(*corev1.Secret); ok && len(secret.ManagedFields) == 0 {
+			secrets = append(secrets, secret.DeepCopy())
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	toUnstructured := func(secret *corev1.Secret) (*unstructured.Unstructured, error) {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+		if err != nil {
+			return nil, err
+		}
+		u := &unstructured.Unstructured{Object: object}
+		u.SetGroupVersionKind(secretGVK)
+		return u, nil
+	}
+	fromUnstructured := func(obj runtime.Object, secret *corev1.Secret) error {
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, secret)
+	}
+	fieldOwner := func(manager string) string {
+		if manager == "" {
+			return secretUpdateFieldManager
+		}
+		return manager
+	}
+	// update records the fields changed from live to secret for the field manager in secret
+	update := func(live, secret *corev1.Secret, manager string) error {
+		liveObj, err := toUnstructured(live)
+		if err != nil {
+			return err
+		}
+		newObj, err := toUnstructured(secret)
+		if err != nil {
+			return err
+		}
+		managed, err := fieldManager.Update(liveObj, newObj, fieldOwner(manager))
+		if err != nil {
+			return err
+		}
+		return fromUnstructured(managed, secret)
+	}
+
+	c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			secret, ok := obj.(*corev1.Secret)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			createOpts := &client.CreateOptions{}
+			createOpts.ApplyOptions(opts)
+			if err := update(&corev1.Secret{}, secret, createOpts.FieldManager); err != nil {
+				return err
+			}
+			return c.Create(ctx, secret, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			secret, ok := obj.(*corev1.Secret)
+			if !ok {
+				return c.Update(ctx, obj, opts...)
+			}
+			updateOpts := &client.UpdateOptions{}
+			updateOpts.ApplyOptions(opts)
+			live := &corev1.Secret{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(secret), live); err != nil {
+				return err
+			}
+			if err := update(live, secret, updateOpts.FieldManager); err != nil {
+				return err
+			}
+			return c.Update(ctx, secret, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			secret, ok := obj.(*corev1.Secret)
+			if !ok {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			patchOpts := &client.PatchOptions{}
+			patchOpts.ApplyOptions(opts)
+			live := &corev1.Secret{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(secret), live); err != nil {
+				if !apierrors.IsNotFound(err) || patch.Type() != types.ApplyPatchType {
+					return err
+				}
+				live = nil
+			}
+
+			if patch.Type() != types.ApplyPatchType {
+				if err := c.Patch(ctx, secret, patch, opts...); err != nil {
+					return err
+				}
+				if err := update(live, secret, patchOpts.FieldManager); err != nil {
+					return err
+				}
+				return c.Update(ctx, secret)
+			}
+
+			liveObj, err := toUnstructured(&corev1.Secret{})
+			if live != nil {
+				liveObj, err = toUnstructured(live)
+			}
+			if err != nil {
+				return err
+			}
+			appliedObj, err := toUnstructured(secret)
+			if err != nil {
+				return err
+			}
+			force := patchOpts.Force != nil && *patchOpts.Force
+			applied, err := fieldManager.Apply(liveObj, appliedObj, patchOpts.FieldManager, force)
+			if err != nil {
+				return err
+			}
+			result := &corev1.Secret{}
+			if err := fromUnstructured(applied, result); err != nil {
+				return err
+			}
+			if live == nil {
+				err = c.Create(ctx, result)
+			} else {
+				result.ResourceVersion = live.ResourceVersion
+				err = c.Update(ctx, result)
+			}
+			if err != nil {
+				return err
+			}
+			result.DeepCopyInto(secret)
+			return nil
+		},
+	})
+
+	for _, secret := range secrets {
+		secret.ResourceVersion = ""
+		var err error
+		if secret.Labels[SecretManagedLabel] == "true" {
+			secret.SetGroupVersionKind(secretGVK)
+			err = c.Patch(context.TODO(), secret, client.Apply, client.FieldOwner(secretFieldManager))
+		} else {
+			err = c.Create(context.TODO(), secret, client.FieldOwner("kubectl"))
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+	return c
+}
+
 func newReconciler(client client.Client, scheme *runtime.Scheme, nodeID string) *SecretProviderClassPodStatusReconciler {
 	return &SecretProviderClassPodStatusReconciler{
 		Client:        client,
 		reader:        client,
+		apiReader:     client,
 		writer:        client,
 		scheme:        scheme,
 		eventRecorder: fakeRecorder,
@@ -170,18 +342,37 @@ func TestCreateOrUpdateK8sSecret(t *testing.T) {
 	initObjects := []client.Object{
 		newSecret("my-secret", "default", labels, annotations),
 	}
-	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	client := newFieldManagedClient(scheme, initObjects...)
 	reconciler := newReconciler(client, scheme, "node1")
 
-	// secret already exists, just update it.
-	err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", nil, labels, annotations, corev1.SecretTypeOpaque)
-	g.Expect(err).NotTo(HaveOccurred())
+	// secret already exists and is not managed by the driver, it's not taken over.
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", nil, labels, annotations, corev1.SecretTypeOpaque, false)
+	g.Expect(err).To(MatchError(errSecretNotManaged))
 
-	err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret2", "default", nil, labels, annotations, corev1.SecretTypeOpaque)
+	// secret already exists and adoption is allowed, just update it.
+	managedLabels := map[string]string{"environment": "test", SecretManagedLabel: "true"}
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", nil, managedLabels, annotations, corev1.SecretTypeOpaque, true)
 	g.Expect(err).NotTo(HaveOccurred())
 	secret := &corev1.Secret{}
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Labels).To(HaveKeyWithValue(SecretManagedLabel, "true"))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretAdoptedAnnotation, "true"))
+
+	// the adopted secret is still marked once it's managed by the driver
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key": []byte("value")}, managedLabels, annotations, corev1.SecretTypeOpaque, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(HaveKey("key"))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretAdoptedAnnotation, "true"))
+
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret2", "default", "spc1", nil, labels, annotations, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	secret = &corev1.Secret{}
 	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret2", Namespace: "default"}, secret)
 	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Annotations).NotTo(HaveKey(SecretAdoptedAnnotation))
 
 	g.Expect(secret.Labels).To(Equal(labels))
 
@@ -280,3 +471,552 @@ func TestPatcherForPodWithOwner(t *testing.T) {
 	g.Expect(secret.OwnerReferences[0].Name).To(Equal("pod-6886c65f8f"))
 	g.Expect(secret.OwnerReferences[0].UID).To(Equal(types.UID("f39da13d-7246-4ef5-aed4-a6905f82cbcd")))
 }
+
+func TestCreateOrUpdateK8sSecret_PrunesKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	existing := newSecret("my-secret", "default", map[string]string{SecretManagedLabel: "true"}, map[string]string{
+		SecretManagedKeysAnnotation: "key1,key2",
+	})
+	existing.Data = map[string][]byte{"key1": []byte("old"), "key2": []byte("old")}
+	client := newFieldManagedClient(scheme, existing)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	// key and annotation added by another field manager
+	secret := &corev1.Secret{}
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)).To(Succeed())
+	secret.Data["foreign"] = []byte("kept")
+	secret.Annotations["kubed.appscode.com/sync"] = "app=test"
+	g.Expect(client.Update(context.TODO(), secret, kubectlFieldOwner)).To(Succeed())
+
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("new"), "key3": []byte("new")}, map[string]string{SecretManagedLabel: "true"}, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("new"), "key3": []byte("new"), "foreign": []byte("kept")}))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretManagedKeysAnnotation, "key1,key3"))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretProviderClassAnnotation, "spc1"))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue("kubed.appscode.com/sync", "app=test"))
+
+	// keys of a retained secret are not removed
+	secret.Annotations[SecretRetainAnnotation] = "true"
+	g.Expect(client.Update(context.TODO(), secret, kubectlFieldOwner)).To(Succeed())
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("new")}, map[string]string{SecretManagedLabel: "true"}, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(HaveKey("key3"))
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretManagedKeysAnnotation, "key1,key3"))
+}
+
+func TestPruneOrphanedSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	managed := map[string]string{SecretManagedLabel: "true"}
+	syncedBy := func(spcName string, extra ...string) map[string]string {
+		annotations := map[string]string{SecretProviderClassAnnotation: spcName}
+		for i := 0; i+1 < len(extra); i += 2 {
+			annotations[extra[i]] = extra[i+1]
+		}
+		return annotations
+	}
+
+	spc1 := newSecretProviderClass("spc1", "default")
+	spc1.Spec.SecretObjects = append(spc1.Spec.SecretObjects, &secretsstorev1.SecretObject{SecretName: "legacy-declared", Type: "Opaque"})
+	spc2 := newSecretProviderClass("spc2", "default")
+	spc2.Spec.SecretObjects = []*secretsstorev1.SecretObject{{SecretName: "moved", Type: "Opaque"}}
+	spcPodStatus := newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1")
+
+	// secrets synced by previous versions of the driver don't have the secret provider
+	// class annotation, they are owned by the secret provider class pod statuses
+	legacy := func(name string, owners ...types.UID) *corev1.Secret {
+		secret := newSecret(name, "default", managed, nil)
+		for _, uid := range owners {
+			secret.OwnerReferences = append(secret.OwnerReferences, metav1.OwnerReference{APIVersion: "secrets-store.csi.x-k8s.io/v1", Kind: "SecretProviderClassPodStatus", Name: spcPodStatus.Name, UID: uid})
+		}
+		return secret
+	}
+
+	initObjects := []client.Object{
+		spc1,
+		spc2,
+		newSecret("secret1", "default", managed, syncedBy("spc1")),
+		newSecret("orphan", "default", managed, syncedBy("spc1")),
+		newSecret("retained", "default", managed, syncedBy("spc1", SecretRetainAnnotation, "true")),
+		newSecret("adopted", "default", managed, syncedBy("spc1", SecretAdoptedAnnotation, "true")),
+		newSecret("moved", "default", managed, syncedBy("spc1")),
+		newSecret("other-spc", "default", managed, syncedBy("spc3")),
+		newSecret("unmanaged", "default", nil, syncedBy("spc1")),
+		newSecret("other-namespace", "other", managed, syncedBy("spc1")),
+		legacy("legacy-orphan", spcPodStatus.UID),
+		legacy("legacy-declared", spcPodStatus.UID),
+		legacy("legacy-other-pod", "c5a8f4b2-5a4f-4f3e-9c3c-7d1e3b1b6a9e"),
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	err = reconciler.pruneOrphanedSecrets(context.TODO(), spc1, spcPodStatus, newPod("pod1", "default", nil))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(client.List(context.TODO(), secrets)).To(Succeed())
+	var names []string
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Namespace+"/"+secret.Name)
+	}
+	g.Expect(names).To(ConsistOf("default/secret1", "default/retained", "default/adopted", "default/moved", "default/other-spc", "default/unmanaged", "other/other-namespace", "default/legacy-declared", "default/legacy-other-pod"))
+
+	secret := &corev1.Secret{}
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "legacy-declared", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretProviderClassAnnotation, "spc1"))
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "legacy-other-pod", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Annotations).NotTo(HaveKey(SecretProviderClassAnnotation))
+}
+
+func TestPruneOrphanedSecrets_PodWithOwner(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	managed := map[string]string{SecretManagedLabel: "true"}
+	replicaSet := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "pod-6886c65f8f", UID: "f39da13d-7246-4ef5-aed4-a6905f82cbcd"}
+
+	spc1 := newSecretProviderClass("spc1", "default")
+	spc1.Spec.SecretObjects = append(spc1.Spec.SecretObjects, &secretsstorev1.SecretObject{SecretName: "legacy-declared", Type: "Opaque"})
+	spc2 := newSecretProviderClass("spc2", "default")
+	spc2.Spec.SecretObjects = []*secretsstorev1.SecretObject{{SecretName: "legacy-other-spc", Type: "Opaque"}}
+	spcPodStatus := newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1")
+
+	// secrets synced by previous versions of the driver for a pod with owners are owned by
+	// the owners of the pod
+	legacy := func(name string, owner metav1.OwnerReference) *corev1.Secret {
+		secret := newSecret(name, "default", managed, nil)
+		secret.OwnerReferences = []metav1.OwnerReference{owner}
+		return secret
+	}
+
+	initObjects := []client.Object{
+		spc1,
+		spc2,
+		legacy("legacy-orphan", replicaSet),
+		legacy("legacy-declared", replicaSet),
+		legacy("legacy-other-spc", replicaSet),
+		legacy("legacy-other-owner", metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "other-6886c65f8f", UID: "c5a8f4b2-5a4f-4f3e-9c3c-7d1e3b1b6a9e"}),
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	err = reconciler.pruneOrphanedSecrets(context.TODO(), spc1, spcPodStatus, newPod("pod1", "default", []metav1.OwnerReference{replicaSet}))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(client.List(context.TODO(), secrets)).To(Succeed())
+	var names []string
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Name)
+	}
+	g.Expect(names).To(ConsistOf("legacy-declared", "legacy-other-spc", "legacy-other-owner"))
+
+	secret := &corev1.Secret{}
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "legacy-declared", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretProviderClassAnnotation, "spc1"))
+	// the secret declared by another secret provider class mounted by the pod is left to it
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "legacy-other-spc", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Annotations).NotTo(HaveKey(SecretProviderClassAnnotation))
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "legacy-other-owner", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Annotations).NotTo(HaveKey(SecretProviderClassAnnotation))
+}
+
+func TestMapSecretProviderClassToPodStatuses(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	otherSPC := newSecretProviderClassPodStatus("pod2-default-spc2", "default", "node1")
+	otherSPC.Status.SecretProviderClassName = "spc2"
+	initObjects := []client.Object{
+		newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1"),
+		newSecretProviderClassPodStatus("pod3-default-spc1", "default", "node2"),
+		newSecretProviderClassPodStatus("pod4-other-spc1", "other", "node1"),
+		otherSPC,
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	requests := reconciler.mapSecretProviderClassToPodStatuses(context.TODO(), newSecretProviderClass("spc1", "default"))
+	g.Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod1-default-spc1"}}))
+}
+
+func TestSecretProviderClassChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := secretProviderClassChangedPredicate()
+	oldSPC := newSecretProviderClass("spc1", "default")
+	oldSPC.Generation = 1
+	labelsChanged := oldSPC.DeepCopy()
+	labelsChanged.Labels = map[string]string{"foo": "bar"}
+	specChanged := oldSPC.DeepCopy()
+	specChanged.Generation = 2
+
+	g.Expect(p.Create(event.CreateEvent{Object: oldSPC})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSPC, ObjectNew: labelsChanged})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSPC, ObjectNew: specChanged})).To(BeTrue())
+	g.Expect(p.Delete(event.DeleteEvent{Object: oldSPC})).To(BeFalse())
+}
+
+func TestCreateOrUpdateK8sSecret_RepairsDrift(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	client := newFieldManagedClient(scheme)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	datamap := map[string][]byte{"key1": []byte("value1")}
+	labels := map[string]string{SecretManagedLabel: "true"}
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", datamap, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret := &corev1.Secret{}
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Annotations).To(HaveKey(SecretContentHashAnnotation))
+	resourceVersion := secret.ResourceVersion
+
+	// secret in sync is not updated
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", datamap, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.ResourceVersion).To(Equal(resourceVersion))
+
+	// edited secret is repaired, the keys added by others are kept
+	delete(secret.Data, "key1")
+	secret.Data["foreign"] = []byte("kept")
+	g.Expect(client.Update(context.TODO(), secret, kubectlFieldOwner)).To(Succeed())
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", datamap, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("value1"), "foreign": []byte("kept")}))
+
+	// deleted secret is created again
+	g.Expect(client.Delete(context.TODO(), secret)).To(Succeed())
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", datamap, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(datamap))
+}
+
+func TestCreateOrUpdateK8sSecret_ApplyConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	existing := newSecret("my-secret", "default", map[string]string{SecretManagedLabel: "true", "app": "test"}, map[string]string{SecretManagedKeysAnnotation: "key1,key2"})
+	existing.Data = map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}
+	client := newFieldManagedClient(scheme, existing)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	// another field manager changes a synced key and label
+	secret := &corev1.Secret{}
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)).To(Succeed())
+	secret.Data["key1"] = []byte("edited")
+	secret.Labels["app"] = "edited"
+	g.Expect(client.Update(context.TODO(), secret, kubectlFieldOwner)).To(Succeed())
+
+	// the changed fields are not taken back
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}, map[string]string{SecretManagedLabel: "true", "app": "test"}, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(apierrors.IsConflict(err)).To(BeTrue())
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)).To(Succeed())
+	g.Expect(secret.Data).To(HaveKeyWithValue("key1", []byte("edited")))
+	g.Expect(secret.Labels).To(HaveKeyWithValue("app", "edited"))
+}
+
+func TestMapSecretToPodStatuses(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	otherSPC := newSecretProviderClassPodStatus("pod2-default-spc2", "default", "node1")
+	otherSPC.Status.SecretProviderClassName = "spc2"
+	initObjects := []client.Object{
+		newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1"),
+		newSecretProviderClassPodStatus("pod3-default-spc1", "default", "node2"),
+		otherSPC,
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	managed := map[string]string{SecretManagedLabel: "true"}
+	requests := reconciler.mapSecretToPodStatuses(context.TODO(), newSecret("my-secret", "default", managed, map[string]string{SecretProviderClassAnnotation: "spc1"}))
+	g.Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod1-default-spc1"}}))
+
+	requests = reconciler.mapSecretToPodStatuses(context.TODO(), newSecret("my-secret", "default", managed, nil))
+	g.Expect(requests).To(BeEmpty())
+}
+
+func TestSecretChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := secretChangedPredicate()
+	oldSecret := newSecret("my-secret", "default", map[string]string{SecretManagedLabel: "true"}, nil)
+	oldSecret.Data = map[string][]byte{"key1": []byte("value1")}
+	dataChanged := oldSecret.DeepCopy()
+	dataChanged.Data["key1"] = []byte("edited")
+	resourceVersionChanged := oldSecret.DeepCopy()
+	resourceVersionChanged.ResourceVersion = "2"
+	unmanaged := newSecret("my-secret", "default", nil, nil)
+
+	g.Expect(p.Create(event.CreateEvent{Object: oldSecret})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSecret, ObjectNew: dataChanged})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSecret, ObjectNew: resourceVersionChanged})).To(BeFalse())
+	g.Expect(p.Delete(event.DeleteEvent{Object: oldSecret})).To(BeTrue())
+	g.Expect(p.Delete(event.DeleteEvent{Object: unmanaged})).To(BeFalse())
+}
+
+func TestCreateOrUpdateK8sSecret_UpgradesManagedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	existing := newSecret("my-secret", "default", map[string]string{SecretManagedLabel: "true"}, nil)
+	existing.Data = map[string][]byte{"key1": []byte("old"), "key2": []byte("old")}
+	existing.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs1", UID: "f39da13d-7246-4ef5-aed4-a6905f82cbcd"}}
+	existing.ManagedFields = []metav1.ManagedFieldsEntry{
+		{
+			Manager:    secretUpdateFieldManager,
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			APIVersion: "v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:key1":{},"f:key2":{}},"f:metadata":{"f:labels":{"f:secrets-store.csi.k8s.io/managed":{}},"f:ownerReferences":{}},"f:type":{}}`)},
+		},
+		{
+			Manager:    "kubectl",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			APIVersion: "v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:foo":{}}}}`)},
+		},
+	}
+	client := newFieldManagedClient(scheme, existing)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	managers := func(secret *corev1.Secret) []string {
+		var managers []string
+		for _, entry := range secret.ManagedFields {
+			managers = append(managers, fmt.Sprintf("%s/%s", entry.Manager, entry.Operation))
+		}
+		return managers
+	}
+
+	// the synced fields are moved to the apply field manager, the key that is not synced
+	// anymore is removed and the owner references are kept
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("new")}, map[string]string{SecretManagedLabel: "true"}, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret := &corev1.Secret{}
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("new")}))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(managers(secret)).To(ConsistOf(secretFieldManager+"/Apply", secretUpdateFieldManager+"/Update", "kubectl/Update"))
+
+	// the migration is done once, the owner references are kept by the next applies
+	patch, err := upgradeManagedFieldsPatch(secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(patch).To(BeNil())
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("newer")}, map[string]string{SecretManagedLabel: "true"}, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("newer")}))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+}
+
+func TestCreateOrUpdateK8sSecret_KeepsOwnerRefs(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	client := newFieldManagedClient(scheme)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	labels := map[string]string{SecretManagedLabel: "true"}
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("value1")}, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	ref := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs1", UID: "f39da13d-7246-4ef5-aed4-a6905f82cbcd"}
+	err = reconciler.patchSecretWithOwnerRef(context.TODO(), "my-secret", "default", ref)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the owner references patched by the patcher are not owned by the apply field manager
+	_, err = reconciler.createOrUpdateK8sSecret(context.TODO(), "my-secret", "default", "spc1", map[string][]byte{"key1": []byte("value2")}, labels, nil, corev1.SecretTypeOpaque, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret := &corev1.Secret{}
+	err = client.Get(context.TODO(), types.NamespacedName{Name: "my-secret", Namespace: "default"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("value2")}))
+	g.Expect(secret.OwnerReferences).To(ConsistOf(ref))
+	var managers []string
+	for _, entry := range secret.ManagedFields {
+		managers = append(managers, fmt.Sprintf("%s/%s", entry.Manager, entry.Operation))
+	}
+	g.Expect(managers).To(ConsistOf(secretFieldManager+"/Apply", secretOwnerRefsFieldManager+"/Update"))
+}
+
+func TestCreateOrUpdateK8sSecret_SecretOwner(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	managed := map[string]string{SecretManagedLabel: "true"}
+	spc1 := newSecretProviderClass("spc1", "default")
+	spc1.Spec.SecretObjects = []*secretsstorev1.SecretObject{{SecretName: "owned", Type: "Opaque"}}
+	initObjects := []client.Object{
+		spc1,
+		newSecret("owned", "default", managed, map[string]string{SecretProviderClassAnnotation: "spc1"}),
+		newSecret("moved", "default", managed, map[string]string{SecretProviderClassAnnotation: "spc1"}),
+		newSecret("deleted-spc", "default", managed, map[string]string{SecretProviderClassAnnotation: "spc3"}),
+	}
+	client := newFieldManagedClient(scheme, initObjects...)
+	reconciler := newReconciler(client, scheme, "node1")
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr error
+	}{
+		{name: "secret declared by the owner", secret: "owned", wantErr: errSecretConflict},
+		{name: "secret no longer declared by the owner", secret: "moved"},
+		{name: "owner deleted", secret: "deleted-spc"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			_, err := reconciler.createOrUpdateK8sSecret(context.TODO(), test.secret, "default", "spc2", map[string][]byte{"key1": []byte("value1")}, managed, nil, corev1.SecretTypeOpaque, false)
+			if test.wantErr != nil {
+				g.Expect(err).To(MatchError(test.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			secret := &corev1.Secret{}
+			g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: test.secret, Namespace: "default"}, secret)).To(Succeed())
+			g.Expect(secret.Annotations).To(HaveKeyWithValue(SecretProviderClassAnnotation, "spc2"))
+		})
+	}
+}
+
+func TestConflictingSecretProviderClasses(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spc1 := newSecretProviderClass("spc1", "default")
+	spc1.Spec.SecretObjects = append(spc1.Spec.SecretObjects, &secretsstorev1.SecretObject{SecretName: "secret2", Type: "Opaque"})
+	spc2 := newSecretProviderClass("spc2", "default")
+	spc3 := newSecretProviderClass("spc3", "default")
+	spc3.Spec.SecretObjects = []*secretsstorev1.SecretObject{{SecretName: "secret3", Type: "Opaque"}}
+	initObjects := []client.Object{
+		spc1,
+		spc2,
+		spc3,
+		newSecretProviderClass("spc4", "other"),
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	conflicts, err := reconciler.conflictingSecretProviderClasses(context.TODO(), spc1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(conflicts).To(Equal(map[string][]string{"secret1": {"spc2"}}))
+}
+
+func TestUpdateSecretConflictCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spc := newSecretProviderClass("spc1", "default")
+	spcPodStatus := newSecretProviderClassPodStatus("pod1-default-spc1", "default", "node1")
+	pod := newPod("pod1", "default", nil)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(spcPodStatus).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+	drainEvents := func() []string {
+		var events []string
+		for len(fakeRecorder.Events) > 0 {
+			events = append(events, <-fakeRecorder.Events)
+		}
+		return events
+	}
+	drainEvents()
+
+	conflicts := map[string][]string{"secret1": {"spc2"}}
+	reconciler.updateSecretConflictCondition(context.TODO(), spc, spcPodStatus, pod, conflicts)
+	reconciler.updateSecretConflictCondition(context.TODO(), spc, spcPodStatus, pod, conflicts)
+
+	// the event is only generated once for the same conflicts
+	g.Expect(drainEvents()).To(Equal([]string{"Warning SecretConflict secret secret1 is also declared in secret provider classes spc2"}))
+	got := &secretsstorev1.SecretProviderClassPodStatus{}
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: spcPodStatus.Name, Namespace: spcPodStatus.Namespace}, got)).To(Succeed())
+	condition := meta.FindStatusCondition(got.Status.Conditions, secretsstorev1.ConditionTypeSecretConflict)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(condition.Message).To(Equal("secret secret1 is also declared in secret provider classes spc2"))
+
+	conflicts["secret1"] = append(conflicts["secret1"], "spc3")
+	reconciler.updateSecretConflictCondition(context.TODO(), spc, spcPodStatus, pod, conflicts)
+	g.Expect(drainEvents()).To(Equal([]string{"Warning SecretConflict secret secret1 is also declared in secret provider classes spc2, spc3"}))
+
+	// the condition is removed once the conflict is resolved
+	reconciler.updateSecretConflictCondition(context.TODO(), spc, spcPodStatus, pod, nil)
+	g.Expect(drainEvents()).To(BeEmpty())
+	g.Expect(client.Get(context.TODO(), types.NamespacedName{Name: spcPodStatus.Name, Namespace: spcPodStatus.Namespace}, got)).To(Succeed())
+	g.Expect(meta.FindStatusCondition(got.Status.Conditions, secretsstorev1.ConditionTypeSecretConflict)).To(BeNil())
+}
+
+func TestGetNodePublishSecretData(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := setupScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret := newSecret("creds", "default", nil, nil)
+	secret.Data = map[string][]byte{"pfx-password": []byte("password")}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	reconciler := newReconciler(client, scheme, "node1")
+
+	vol := &corev1.Volume{
+		Name: "secrets-store-inline",
+		VolumeSource: corev1.VolumeSource{
+			CSI: &corev1.CSIVolumeSource{
+				Driver:               "secrets-store.csi.k8s.io",
+				NodePublishSecretRef: &corev1.LocalObjectReference{Name: "creds"},
+			},
+		},
+	}
+	data, err := reconciler.getNodePublishSecretData(context.TODO(), "default", vol)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(Equal(secret.Data))
+
+	vol.CSI.NodePublishSecretRef = nil
+	_, err = reconciler.getNodePublishSecretData(context.TODO(), "default", vol)
+	g.Expect(err).To(HaveOccurred())
+}
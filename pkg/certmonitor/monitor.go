@@ -0,0 +1,381 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certmonitor tracks the expiry of the X.509 certificates written by the
+// driver, in the mounted volumes and in the synced kubernetes.io/tls secrets.
+package certmonitor
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/secretutil"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	corev1 "k8s.io/api/core/v1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+const (
+	scope = "sigs.k8s.io/secrets-store-csi-driver"
+
+	// CertificateExpiringReason is the reason of the events for certificates that expire
+	// within the warning window
+	CertificateExpiringReason = "CertificateExpiring"
+	// CertificateExpiredReason is the reason of the events for expired certificates
+	CertificateExpiredReason = "CertificateExpired"
+
+	// checkInterval is the interval the tracked certificates are checked at
+	checkInterval = time.Hour
+)
+
+var (
+	osTypeKey    = "os_type"
+	namespaceKey = "namespace"
+	podKey       = "pod"
+	volumeKey    = "volume"
+	objectKey    = "object"
+	secretKey    = "secret"
+	keyKey       = "key"
+	// subjectKey tells apart the certificates of a chain in the same object. The series
+	// are only observed while the certificate is tracked, so the cardinality is bounded
+	// by the number of tracked certificates.
+	subjectKey = "subject"
+	runtimeOS  = runtime.GOOS
+)
+
+// certificate is a certificate of a tracked object
+type certificate struct {
+	// name is the object name in a volume or the data key in a secret
+	name     string
+	subject  string
+	notAfter time.Time
+	// reason is the reason of the last events emitted for the certificate, empty if
+	// it doesn't expire within the warning window
+	reason string
+}
+
+// certificateID identifies a certificate across the versions of a tracked object
+type certificateID struct {
+	name     string
+	subject  string
+	notAfter int64
+}
+
+func (c certificate) id() certificateID {
+	return certificateID{name: c.name, subject: c.subject, notAfter: c.notAfter.Unix()}
+}
+
+// entry is a tracked volume or secret
+type entry struct {
+	// description identifies the volume or secret in the event messages
+	description string
+	// attributes identify the volume or secret in the metrics
+	attributes []attribute.KeyValue
+	// nameKey is the metric attribute of the certificate name
+	nameKey string
+	// objects are the pod and secret provider class the events are emitted on
+	objects []apiruntime.Object
+	certs   []certificate
+}
+
+// secretID identifies a synced secret tracked for a secret provider class pod status.
+// A secret is synced for each pod that mounts the secret provider class.
+type secretID struct {
+	owner types.NamespacedName
+	name  string
+}
+
+// Monitor exports the expiry of the tracked certificates as metrics and emits warning
+// events on the pod and secret provider class for the certificates that expire within
+// the warning window or have expired. A nil Monitor is valid and tracks nothing.
+type Monitor struct {
+	mu       sync.Mutex
+	window   time.Duration
+	recorder record.EventRecorder
+	volumes  map[string]*entry
+	secrets  map[secretID]*entry
+	now      func() time.Time
+}
+
+// New creates a Monitor that warns about the certificates that expire within window
+func New(window time.Duration, recorder record.EventRecorder) (*Monitor, error) {
+	m := &Monitor{
+		window:   window,
+		recorder: recorder,
+		volumes:  make(map[string]*entry),
+		secrets:  make(map[secretID]*entry),
+		now:      time.Now,
+	}
+
+	meter := otel.Meter(scope)
+	volumeGauge, err := meter.Int64ObservableGauge("volume_certificate_not_after", metric.WithUnit("s"), metric.WithDescription("Expiry time of the certificates in the mounted volumes, in seconds since the epoch"))
+	if err != nil {
+		return nil, err
+	}
+	secretGauge, err := meter.Int64ObservableGauge("k8s_secret_certificate_not_after", metric.WithUnit("s"), metric.WithDescription("Expiry time of the certificates in the synced kubernetes.io/tls secrets, in seconds since the epoch"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		observe(o, volumeGauge, values(m.volumes))
+		observe(o, secretGauge, values(m.secrets))
+		return nil
+	}, volumeGauge, secretGauge); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Run checks the tracked certificates periodically until the context is done, so
+// the warnings are emitted for the certificates that weren't rewritten.
+func (m *Monitor) Run(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+// checkAll emits the warning events for all the tracked certificates that expire
+// within the warning window or have expired, even if they were already emitted.
+func (m *Monitor) checkAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.volumes {
+		m.check(e, nil)
+	}
+	for _, e := range m.secrets {
+		m.check(e, nil)
+	}
+}
+
+// TrackVolume tracks the certificates in the objects written to the target path, by
+// object name. Objects that aren't PEM encoded certificates are ignored.
+func (m *Monitor) TrackVolume(targetPath string, pod, spc apiruntime.Object, objects map[string][]byte) {
+	if m == nil {
+		return
+	}
+	podRef := objectRef(pod)
+	e := &entry{
+		description: fmt.Sprintf("volume %s of pod %s", fileutil.GetVolumeNameFromTargetPath(targetPath), podRef),
+		attributes: []attribute.KeyValue{
+			attribute.Key(namespaceKey).String(podRef.Namespace),
+			attribute.Key(podKey).String(podRef.Name),
+			attribute.Key(volumeKey).String(fileutil.GetVolumeNameFromTargetPath(targetPath)),
+		},
+		nameKey: objectKey,
+		objects: []apiruntime.Object{pod, spc},
+		certs:   parseCertificates(objects),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(e.certs) == 0 {
+		delete(m.volumes, targetPath)
+		return
+	}
+	m.check(e, m.volumes[targetPath])
+	m.volumes[targetPath] = e
+}
+
+// UntrackVolume stops tracking the certificates written to the target path
+func (m *Monitor) UntrackVolume(targetPath string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.volumes, targetPath)
+}
+
+// TrackSecret tracks the certificates in the data of the secret synced for the secret
+// provider class pod status owner, by data key.
+func (m *Monitor) TrackSecret(owner types.NamespacedName, name string, pod, spc apiruntime.Object, data map[string][]byte) {
+	if m == nil {
+		return
+	}
+	e := &entry{
+		description: fmt.Sprintf("secret %s/%s", owner.Namespace, name),
+		attributes: []attribute.KeyValue{
+			attribute.Key(namespaceKey).String(owner.Namespace),
+			attribute.Key(secretKey).String(name),
+		},
+		nameKey: keyKey,
+		objects: []apiruntime.Object{pod, spc},
+		certs:   parseCertificates(data),
+	}
+
+	id := secretID{owner: owner, name: name}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(e.certs) == 0 {
+		delete(m.secrets, id)
+		return
+	}
+	m.check(e, m.secrets[id])
+	m.secrets[id] = e
+}
+
+// UntrackSecret stops tracking the certificates of the secret for all the owners
+func (m *Monitor) UntrackSecret(namespace, name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.secrets {
+		if id.owner.Namespace == namespace && id.name == name {
+			delete(m.secrets, id)
+		}
+	}
+}
+
+// UntrackOwner stops tracking the certificates of the secrets synced for the secret
+// provider class pod status owner
+func (m *Monitor) UntrackOwner(owner types.NamespacedName) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.secrets {
+		if id.owner == owner {
+			delete(m.secrets, id)
+		}
+	}
+}
+
+// check emits the warning events for the certificates of the entry that expire within
+// the warning window or have expired. If the entry replaces a previous entry for the same
+// volume or secret, the events are only emitted for the certificates whose state changed,
+// so they aren't repeated on every mount and sync and are only repeated by the periodic
+// check. The caller must hold the lock.
+func (m *Monitor) check(e, previous *entry) {
+	reasons := make(map[certificateID]string)
+	if previous != nil {
+		for _, cert := range previous.certs {
+			reasons[cert.id()] = cert.reason
+		}
+	}
+	now := m.now()
+	for i := range e.certs {
+		cert := &e.certs[i]
+		var message string
+		switch {
+		case !now.Before(cert.notAfter):
+			cert.reason = CertificateExpiredReason
+			message = fmt.Sprintf("certificate %q in %s %s of %s expired at %s", cert.subject, e.nameKey, cert.name, e.description, cert.notAfter.UTC().Format(time.RFC3339))
+		case cert.notAfter.Sub(now) <= m.window:
+			cert.reason = CertificateExpiringReason
+			message = fmt.Sprintf("certificate %q in %s %s of %s expires at %s", cert.subject, e.nameKey, cert.name, e.description, cert.notAfter.UTC().Format(time.RFC3339))
+		default:
+			cert.reason = ""
+			continue
+		}
+		if previous != nil && reasons[cert.id()] == cert.reason {
+			continue
+		}
+		klog.InfoS("certificate expiring", "reason", cert.reason, "subject", cert.subject, e.nameKey, cert.name, "notAfter", cert.notAfter, "in", e.description)
+		if m.recorder == nil {
+			continue
+		}
+		for _, obj := range e.objects {
+			if obj != nil {
+				m.recorder.Event(obj, corev1.EventTypeWarning, cert.reason, message)
+			}
+		}
+	}
+}
+
+// parseCertificates returns the certificates of the PEM and unencrypted PKCS#12 objects,
+// sorted by object name
+func parseCertificates(objects map[string][]byte) []certificate {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var certs []certificate
+	for _, name := range names {
+		parsed, err := secretutil.ParseCertificates(objects[name], "")
+		if err != nil {
+			continue
+		}
+		for _, cert := range parsed {
+			certs = append(certs, certificate{name: name, subject: cert.Subject.String(), notAfter: cert.NotAfter})
+		}
+	}
+	return certs
+}
+
+// observe observes the expiry of the certificates of the entries. A certificate is
+// observed once if the same secret is tracked for several owners.
+func observe(o metric.Observer, gauge metric.Int64Observable, entries []*entry) {
+	observed := make(map[attribute.Distinct]bool)
+	for _, e := range entries {
+		for _, cert := range e.certs {
+			attrs := append([]attribute.KeyValue{
+				attribute.Key(osTypeKey).String(runtimeOS),
+				attribute.Key(e.nameKey).String(cert.name),
+				attribute.Key(subjectKey).String(cert.subject),
+			}, e.attributes...)
+			set := attribute.NewSet(attrs...)
+			if observed[set.Equivalent()] {
+				continue
+			}
+			observed[set.Equivalent()] = true
+			o.ObserveInt64(gauge, cert.notAfter.Unix(), metric.WithAttributeSet(set))
+		}
+	}
+}
+
+func values[K comparable](m map[K]*entry) []*entry {
+	entries := make([]*entry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// objectRef returns the namespace and name of the object
+func objectRef(obj apiruntime.Object) klog.ObjectRef {
+	if o, ok := obj.(klog.KMetadata); ok {
+		return klog.KObj(o)
+	}
+	return klog.ObjectRef{}
+}
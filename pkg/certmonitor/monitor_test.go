@@ -0,0 +1,279 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmonitor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+const testTargetPath = "/var/lib/kubelet/pods/7e7686a1-56c4-4c67-a6fd-4656ac484f0a/volumes/kubernetes.io~csi/secrets-store-inline/mount"
+
+var (
+	testNow  = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testPod  = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	testSPC  = &secretsstorev1.SecretProviderClass{ObjectMeta: metav1.ObjectMeta{Name: "spc1", Namespace: "default"}}
+	testPod2 = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "default"}}
+)
+
+// newTestCert returns a PEM encoded self-signed certificate that expires at notAfter
+func newTestCert(t *testing.T, cn string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestMonitor(recorder record.EventRecorder) *Monitor {
+	return &Monitor{
+		window:   24 * time.Hour,
+		recorder: recorder,
+		volumes:  make(map[string]*entry),
+		secrets:  make(map[secretID]*entry),
+		now:      func() time.Time { return testNow },
+	}
+}
+
+// events returns the events recorded by the fake recorder
+func events(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestTrackVolume(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         map[string][]byte
+		expectedTracked bool
+		expectedEvents  []string
+	}{
+		{
+			name:            "valid certificate",
+			objects:         map[string][]byte{"cert": newTestCert(t, "valid", testNow.Add(48*time.Hour))},
+			expectedTracked: true,
+		},
+		{
+			name:            "expiring certificate",
+			objects:         map[string][]byte{"cert": newTestCert(t, "expiring", testNow.Add(time.Hour))},
+			expectedTracked: true,
+			expectedEvents: []string{
+				`Warning CertificateExpiring certificate "CN=expiring" in object cert of volume secrets-store-inline of pod default/pod1 expires at 2026-01-01T01:00:00Z`,
+				`Warning CertificateExpiring certificate "CN=expiring" in object cert of volume secrets-store-inline of pod default/pod1 expires at 2026-01-01T01:00:00Z`,
+			},
+		},
+		{
+			name:            "expired certificate",
+			objects:         map[string][]byte{"cert": newTestCert(t, "expired", testNow.Add(-time.Hour)), "password": []byte("secret")},
+			expectedTracked: true,
+			expectedEvents: []string{
+				`Warning CertificateExpired certificate "CN=expired" in object cert of volume secrets-store-inline of pod default/pod1 expired at 2025-12-31T23:00:00Z`,
+				`Warning CertificateExpired certificate "CN=expired" in object cert of volume secrets-store-inline of pod default/pod1 expired at 2025-12-31T23:00:00Z`,
+			},
+		},
+		{
+			name:    "no certificate",
+			objects: map[string][]byte{"password": []byte("secret")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			m := newTestMonitor(recorder)
+
+			m.TrackVolume(testTargetPath, testPod, testSPC, test.objects)
+			if _, tracked := m.volumes[testTargetPath]; tracked != test.expectedTracked {
+				t.Fatalf("expected tracked to be %t, got %t", test.expectedTracked, tracked)
+			}
+			actual := events(recorder)
+			if strings.Join(actual, "\n") != strings.Join(test.expectedEvents, "\n") {
+				t.Fatalf("expected events %q, got %q", test.expectedEvents, actual)
+			}
+
+			m.UntrackVolume(testTargetPath)
+			if len(m.volumes) != 0 {
+				t.Fatalf("expected volume to be untracked, got %d volumes", len(m.volumes))
+			}
+		})
+	}
+}
+
+func TestTrackSecret(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	m := newTestMonitor(recorder)
+	owner1 := types.NamespacedName{Namespace: "default", Name: "pod1-default-spc1"}
+	owner2 := types.NamespacedName{Namespace: "default", Name: "pod2-default-spc1"}
+	data := map[string][]byte{"tls.crt": newTestCert(t, "expiring", testNow.Add(time.Hour)), "tls.key": []byte("key")}
+
+	m.TrackSecret(owner1, "secret1", testPod, testSPC, data)
+	m.TrackSecret(owner2, "secret1", testPod2, testSPC, data)
+	m.TrackSecret(owner1, "secret2", testPod, testSPC, data)
+	if len(m.secrets) != 3 {
+		t.Fatalf("expected 3 tracked secrets, got %d", len(m.secrets))
+	}
+	expected := `Warning CertificateExpiring certificate "CN=expiring" in key tls.crt of secret default/secret1 expires at 2026-01-01T01:00:00Z`
+	if actual := events(recorder); len(actual) != 6 || actual[0] != expected {
+		t.Fatalf("expected 6 events starting with %q, got %q", expected, actual)
+	}
+
+	m.UntrackSecret("default", "secret1")
+	if len(m.secrets) != 1 {
+		t.Fatalf("expected 1 tracked secret, got %d", len(m.secrets))
+	}
+	m.UntrackOwner(owner1)
+	if len(m.secrets) != 0 {
+		t.Fatalf("expected no tracked secret, got %d", len(m.secrets))
+	}
+}
+
+func TestTrackVolume_RepeatedEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	m := newTestMonitor(recorder)
+	objects := map[string][]byte{"cert": newTestCert(t, "expiring", testNow.Add(time.Hour))}
+	expiring := `Warning CertificateExpiring certificate "CN=expiring" in object cert of volume secrets-store-inline of pod default/pod1 expires at 2026-01-01T01:00:00Z`
+	expired := `Warning CertificateExpired certificate "CN=expiring" in object cert of volume secrets-store-inline of pod default/pod1 expired at 2026-01-01T01:00:00Z`
+
+	m.TrackVolume(testTargetPath, testPod, testSPC, objects)
+	if actual := events(recorder); len(actual) != 2 || actual[0] != expiring {
+		t.Fatalf("expected 2 events %q, got %q", expiring, actual)
+	}
+
+	// the events aren't repeated when the same certificate is written again
+	m.TrackVolume(testTargetPath, testPod, testSPC, objects)
+	if actual := events(recorder); len(actual) != 0 {
+		t.Fatalf("expected no events, got %q", actual)
+	}
+
+	// the periodic check repeats the events
+	m.checkAll()
+	if actual := events(recorder); len(actual) != 2 || actual[0] != expiring {
+		t.Fatalf("expected 2 events %q, got %q", expiring, actual)
+	}
+
+	// the events are emitted when the state of the certificate changes
+	m.now = func() time.Time { return testNow.Add(2 * time.Hour) }
+	m.TrackVolume(testTargetPath, testPod, testSPC, objects)
+	if actual := events(recorder); len(actual) != 2 || actual[0] != expired {
+		t.Fatalf("expected 2 events %q, got %q", expired, actual)
+	}
+
+	// a new certificate is checked when it's written
+	m.TrackVolume(testTargetPath, testPod, testSPC, map[string][]byte{"cert": newTestCert(t, "renewed", testNow.Add(3*time.Hour))})
+	if actual := events(recorder); len(actual) != 2 || !strings.Contains(actual[0], `"CN=renewed"`) {
+		t.Fatalf("expected 2 events for the renewed certificate, got %q", actual)
+	}
+}
+
+func TestNilMonitor(t *testing.T) {
+	var m *Monitor
+	m.TrackVolume(testTargetPath, testPod, testSPC, map[string][]byte{"cert": newTestCert(t, "valid", testNow)})
+	m.UntrackVolume(testTargetPath)
+	m.TrackSecret(types.NamespacedName{}, "secret1", testPod, testSPC, nil)
+	m.UntrackSecret("default", "secret1")
+	m.UntrackOwner(types.NamespacedName{})
+	m.Run(context.Background())
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	m, err := New(24*time.Hour, nil)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	notAfter := testNow.Add(48 * time.Hour)
+	cert := newTestCert(t, "valid", notAfter)
+	m.TrackVolume(testTargetPath, testPod, testSPC, map[string][]byte{"cert": cert})
+	// the same secret synced for two pods is observed once
+	m.TrackSecret(types.NamespacedName{Namespace: "default", Name: "pod1-default-spc1"}, "secret1", testPod, testSPC, map[string][]byte{"tls.crt": cert})
+	m.TrackSecret(types.NamespacedName{Namespace: "default", Name: "pod2-default-spc1"}, "secret1", testPod2, testSPC, map[string][]byte{"tls.crt": cert})
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	expected := map[string]attribute.KeyValue{
+		"volume_certificate_not_after":     attribute.String(volumeKey, "secrets-store-inline"),
+		"k8s_secret_certificate_not_after": attribute.String(secretKey, "secret1"),
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, metric := range sm.Metrics {
+			attr, ok := expected[metric.Name]
+			if !ok {
+				continue
+			}
+			delete(expected, metric.Name)
+			gauge, ok := metric.Data.(metricdata.Gauge[int64])
+			if !ok {
+				t.Fatalf("expected %s to be an int64 gauge, got %T", metric.Name, metric.Data)
+			}
+			if len(gauge.DataPoints) != 1 {
+				t.Fatalf("expected 1 data point for %s, got %d", metric.Name, len(gauge.DataPoints))
+			}
+			dp := gauge.DataPoints[0]
+			if dp.Value != notAfter.Unix() {
+				t.Fatalf("expected %s to be %d, got %d", metric.Name, notAfter.Unix(), dp.Value)
+			}
+			if value, ok := dp.Attributes.Value(attr.Key); !ok || value != attr.Value {
+				t.Fatalf("expected %s attribute %s=%s, got %v", metric.Name, attr.Key, attr.Value.Emit(), dp.Attributes)
+			}
+		}
+	}
+	if len(expected) != 0 {
+		t.Fatalf("expected metrics %v to be reported", expected)
+	}
+}
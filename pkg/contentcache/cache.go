@@ -0,0 +1,283 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package contentcache implements a node local cache of the last successful mount
+// response for a SecretProviderClass. The cached content is encrypted with a node key
+// and is only used when the provider can't be reached to mount the content for a new pod.
+package contentcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// keySize is the size of the AES-256 node key
+	keySize = 32
+	// entrySuffix is the file name suffix of the cache entries
+	entrySuffix = ".cache"
+)
+
+var (
+	// ErrNotFound is returned when there is no cache entry for the key
+	ErrNotFound = errors.New("cache entry not found")
+	// ErrStale is returned when the cache entry is older than the max staleness
+	ErrStale = errors.New("cache entry is stale")
+)
+
+// Entry is the content of the last successful mount response
+type Entry struct {
+	// ObjectVersions are the object versions returned by the provider
+	ObjectVersions map[string]string `json:"objectVersions,omitempty"`
+	// Files are the files returned by the provider
+	Files []File `json:"files,omitempty"`
+	// Timestamp is the time the mount response was received
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// File is a file returned by the provider
+type File struct {
+	Path     string `json:"path"`
+	Mode     int32  `json:"mode"`
+	Contents []byte `json:"contents"`
+}
+
+// Cache stores the entries as files encrypted with AES-GCM in a directory on the node
+type Cache struct {
+	dir          string
+	aead         cipher.AEAD
+	maxStaleness time.Duration
+	now          func() time.Time
+}
+
+// New creates a cache that stores the entries in dir. The node key is read from
+// keyFile and is generated if the file doesn't exist. The key file must not be in dir,
+// otherwise anyone who can read the cache can decrypt it. Entries older than maxStaleness
+// are never returned and are removed.
+func New(dir, keyFile string, maxStaleness time.Duration) (*Cache, error) {
+	if keyFile == "" {
+		return nil, errors.New("cache key file is required")
+	}
+	inDir, err := isInDir(keyFile, dir)
+	if err != nil {
+		return nil, err
+	}
+	if inDir {
+		return nil, fmt.Errorf("cache key %s must not be in the cache directory %s", keyFile, dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	key, err := loadOrGenerateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		dir:          dir,
+		aead:         aead,
+		maxStaleness: maxStaleness,
+		now:          time.Now,
+	}
+	c.prune()
+	return c, nil
+}
+
+// Key returns the cache key for the parts, e.g. the namespace, secret provider class
+// and service account of the pod.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Store stores the mount response files and object versions for the key
+func (c *Cache) Store(key string, files []*v1alpha1.File, objectVersions map[string]string) error {
+	entry := Entry{
+		ObjectVersions: objectVersions,
+		Timestamp:      c.now(),
+	}
+	for _, f := range files {
+		entry.Files = append(entry.Files, File{Path: f.GetPath(), Mode: f.GetMode(), Contents: f.GetContents()})
+	}
+	plaintext, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	// the key is used as additional data so an entry can't be used for another key
+	data := c.aead.Seal(nonce, nonce, plaintext, []byte(key))
+
+	// write to a temporary file and rename so a partially written entry is never read
+	tmp, err := os.CreateTemp(c.dir, key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Load returns the entry for the key. ErrNotFound is returned if there is no entry and
+// ErrStale if the entry is older than the max staleness.
+func (c *Cache) Load(key string) (*Entry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("cache entry %s is corrupted", key)
+	}
+	plaintext, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt cache entry %s: %w", key, err)
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(plaintext, entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+	}
+	if c.now().Sub(entry.Timestamp) > c.maxStaleness {
+		c.remove(key)
+		return nil, ErrStale
+	}
+	return entry, nil
+}
+
+// ProtoFiles returns the entry files as mount response files
+func (e *Entry) ProtoFiles() []*v1alpha1.File {
+	files := make([]*v1alpha1.File, 0, len(e.Files))
+	for _, f := range e.Files {
+		files = append(files, &v1alpha1.File{Path: f.Path, Mode: f.Mode, Contents: f.Contents})
+	}
+	return files
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+entrySuffix)
+}
+
+func (c *Cache) remove(key string) {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		klog.ErrorS(err, "failed to remove cache entry", "key", key)
+	}
+}
+
+// prune removes the entries that were last written before the max staleness. Entries
+// that are not used anymore, e.g. for a deleted secret provider class, are removed
+// when the driver restarts.
+func (c *Cache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		klog.ErrorS(err, "failed to list cache entries", "dir", c.dir)
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), entrySuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if c.now().Sub(info.ModTime()) > c.maxStaleness {
+			c.remove(strings.TrimSuffix(e.Name(), entrySuffix))
+		}
+	}
+}
+
+// isInDir returns true if path is dir or a path in dir
+func isInDir(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// loadOrGenerateKey reads the node key from the file or generates a new random key
+// and writes it to the file if it doesn't exist.
+func loadOrGenerateKey(keyFile string) ([]byte, error) {
+	key, err := os.ReadFile(keyFile)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("cache key %s must be %d bytes, got %d", keyFile, keySize, len(key))
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cache key %s: %w", keyFile, err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write cache key %s: %w", keyFile, err)
+	}
+	klog.InfoS("generated content cache key", "keyFile", keyFile)
+	return key, nil
+}
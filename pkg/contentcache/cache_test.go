@@ -0,0 +1,155 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contentcache
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestCache(t *testing.T) (*Cache, string) {
+	t.Helper()
+	dir := t.TempDir()
+	c, err := New(dir, filepath.Join(t.TempDir(), "key"), time.Hour)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	return c, dir
+}
+
+func TestStoreAndLoad(t *testing.T) {
+	c, dir := newTestCache(t)
+	key := Key("default", "spc1", "sa1")
+	files := []*v1alpha1.File{{Path: "foo", Mode: 0644, Contents: []byte("secret")}}
+	objectVersions := map[string]string{"secret/foo": "v1"}
+
+	if _, err := c.Load(key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got: %v", err)
+	}
+	if err := c.Store(key, files, objectVersions); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	// the contents are not stored in plain text
+	data, err := os.ReadFile(filepath.Join(dir, key+entrySuffix))
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Errorf("expected cache entry to be encrypted")
+	}
+
+	entry, err := c.Load(key)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	if diff := cmp.Diff(objectVersions, entry.ObjectVersions); diff != "" {
+		t.Errorf("object versions mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]File{{Path: "foo", Mode: 0644, Contents: []byte("secret")}}, entry.Files); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+
+	// an entry renamed to another key can't be decrypted
+	otherKey := Key("default", "spc1", "sa2")
+	if err := os.Rename(filepath.Join(dir, key+entrySuffix), filepath.Join(dir, otherKey+entrySuffix)); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	if _, err := c.Load(otherKey); err == nil {
+		t.Errorf("expected error loading entry for another key")
+	}
+}
+
+func TestLoadStale(t *testing.T) {
+	c, dir := newTestCache(t)
+	key := Key("default", "spc1", "sa1")
+	if err := c.Store(key, nil, map[string]string{"secret/foo": "v1"}); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := c.Load(key); !errors.Is(err, ErrStale) {
+		t.Fatalf("expected ErrStale, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, key+entrySuffix)); !os.IsNotExist(err) {
+		t.Errorf("expected stale entry to be removed, got: %v", err)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "key")
+
+	c1, err := New(dir, keyFile, time.Hour)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	key := Key("default", "spc1", "sa1")
+	if err := c1.Store(key, nil, map[string]string{"secret/foo": "v1"}); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	// the generated key is reused after a restart
+	c2, err := New(dir, keyFile, time.Hour)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	if _, err := c2.Load(key); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("short"), 0600); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	if _, err := New(dir, keyFile, time.Hour); err == nil {
+		t.Errorf("expected error for invalid key size")
+	}
+}
+
+func TestNewKeyFileInCacheDir(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		keyFile string
+	}{
+		{name: "no key file", keyFile: ""},
+		{name: "key file in cache dir", keyFile: filepath.Join(dir, "key")},
+		{name: "key file in cache subdir", keyFile: filepath.Join(dir, "keys", "key")},
+		{name: "key file is cache dir", keyFile: dir},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(dir, test.keyFile, time.Hour); err == nil {
+				t.Errorf("expected error for key file %q", test.keyFile)
+			}
+		})
+	}
+
+	// a sibling of the cache dir with the same prefix is not in the cache dir
+	if _, err := New(dir, dir+"-key", time.Hour); err != nil {
+		t.Errorf("expected error to be nil, got: %+v", err)
+	}
+}
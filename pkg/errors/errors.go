@@ -31,6 +31,9 @@ const (
 	FailedToLookupProviderGRPCClient = "FailedToLookupProviderGRPCClient"
 	// GRPCProviderError error
 	GRPCProviderError = "GRPCProviderError"
+	// ProviderPeerVerificationFailed error
+	// Indicates the provider process or socket file doesn't match the identity in the provider registry.
+	ProviderPeerVerificationFailed = "ProviderPeerVerificationFailed"
 	// FailedToRotate error
 	FailedToRotate = "FailedToRotate"
 	// PodNotFound error
@@ -45,4 +48,7 @@ const (
 	PodVolumeNotFound = "PodVolumeNotFound"
 	// FileWriteError error
 	FileWriteError = "FileWriteError"
+	// TemplateConfigMapNotFound error
+	// Indicates the template ConfigMap referenced by the SecretProviderClass couldn't be read.
+	TemplateConfigMapNotFound = "TemplateConfigMapNotFound"
 )
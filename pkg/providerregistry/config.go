@@ -0,0 +1,304 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package providerregistry loads the provider registry configuration file that
+// sets the endpoint and transport settings of each provider.
+package providerregistry
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/validation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the version of the provider registry configuration file
+	APIVersion = "secrets-store.csi.x-k8s.io/v1alpha1"
+	// Kind is the kind of the provider registry configuration file
+	Kind = "ProviderRegistry"
+)
+
+// Endpoint schemes supported in Provider.Endpoint
+const (
+	SchemeUnix  = "unix"
+	SchemeTCP   = "tcp"
+	SchemeVsock = "vsock"
+)
+
+// grpcStatusCodes are the gRPC status codes that can be retried
+var grpcStatusCodes = sets.New(
+	"CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED",
+)
+
+// Config is the provider registry configuration file
+type Config struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Providers are the providers the driver connects to
+	Providers []Provider `json:"providers"`
+}
+
+// Provider sets the endpoint and transport settings of a provider
+type Provider struct {
+	// Name is the provider name set in the SecretProviderClass
+	Name string `json:"name"`
+	// Endpoint is the address of the provider, e.g. unix:///etc/kubernetes/secrets-store-csi-providers/vault.sock,
+	// tcp://10.0.0.1:8443 or vsock://3:8443
+	Endpoint string `json:"endpoint"`
+	// TLS configures TLS for tcp and vsock endpoints. The connection is not encrypted if it's not set.
+	// +optional
+	TLS *TLS `json:"tls,omitempty"`
+	// Timeout is the timeout of the requests to the provider
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Retry is the retry policy of the requests to the provider
+	// +optional
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// MaxRecvMsgSize is the maximum size in bytes of a message received from the provider
+	// +optional
+	MaxRecvMsgSize int `json:"maxRecvMsgSize,omitempty"`
+	// MaxSendMsgSize is the maximum size in bytes of a message sent to the provider
+	// +optional
+	MaxSendMsgSize int `json:"maxSendMsgSize,omitempty"`
+	// MaxConcurrency is the maximum number of concurrent requests to the provider
+	// +optional
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// Peer is the expected identity of the provider process and socket file. It's
+	// verified before the driver trusts the provider socket. Only supported for unix
+	// endpoints on Linux, and can't be set together with TLS.
+	// +optional
+	Peer *Peer `json:"peer,omitempty"`
+}
+
+// TLS configures TLS for the connection to a provider
+type TLS struct {
+	// CAFile is the file with the CA certificates used to verify the provider certificate.
+	// The system CA certificates are used if it's not set.
+	// +optional
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile are the files with the client certificate and key
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+	// ServerName is the name used to verify the provider certificate. The host of
+	// the endpoint is used if it's not set.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// RetryPolicy is the gRPC retry policy of the requests to a provider
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the original request
+	MaxAttempts int `json:"maxAttempts"`
+	// InitialBackoff is the backoff before the first retry
+	InitialBackoff metav1.Duration `json:"initialBackoff"`
+	// MaxBackoff is the maximum backoff between retries
+	MaxBackoff metav1.Duration `json:"maxBackoff"`
+	// BackoffMultiplier is the multiplier of the backoff after each retry
+	BackoffMultiplier float64 `json:"backoffMultiplier"`
+	// RetryableStatusCodes are the gRPC status codes that are retried, e.g. UNAVAILABLE
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// Load reads and validates the provider registry configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider registry config %s: %w", path, err)
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to decode provider registry config %s: %w", path, err)
+	}
+	if errs := config.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid provider registry config %s: %w", path, errs.ToAggregate())
+	}
+	return config, nil
+}
+
+// Validate validates the provider registry configuration
+func (c *Config) Validate() field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if c.APIVersion != APIVersion {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{APIVersion}))
+	}
+	if c.Kind != Kind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{Kind}))
+	}
+
+	names := sets.New[string]()
+	for i := range c.Providers {
+		idxPath := field.NewPath("providers").Index(i)
+		provider := &c.Providers[i]
+		allErrs = append(allErrs, validateProvider(provider, idxPath)...)
+
+		if names.Has(provider.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), provider.Name))
+		}
+		names.Insert(provider.Name)
+	}
+
+	return allErrs
+}
+
+// Get returns the settings of the provider
+func (c *Config) Get(name string) (Provider, bool) {
+	if c == nil {
+		return Provider{}, false
+	}
+	for _, p := range c.Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Provider{}, false
+}
+
+func validateProvider(provider *Provider, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(provider.Name) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else if !validation.IsValidProviderName(provider.Name) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), provider.Name, "must be a valid provider name"))
+	}
+
+	scheme, address, err := ParseEndpoint(provider.Endpoint)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint"), provider.Endpoint, err.Error()))
+	}
+	if provider.TLS != nil {
+		tlsPath := fldPath.Child("tls")
+		if scheme == SchemeUnix {
+			allErrs = append(allErrs, field.Forbidden(tlsPath, "TLS is not supported for unix endpoints"))
+		}
+		if (provider.TLS.CertFile == "") != (provider.TLS.KeyFile == "") {
+			allErrs = append(allErrs, field.Invalid(tlsPath, "", "certFile and keyFile must be set together"))
+		}
+	}
+	if scheme == SchemeTCP && address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint"), provider.Endpoint, err.Error()))
+		}
+	}
+
+	if provider.Timeout != nil && provider.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), provider.Timeout.Duration.String(), "must be greater than 0"))
+	}
+	if provider.Retry != nil {
+		allErrs = append(allErrs, validateRetryPolicy(provider.Retry, fldPath.Child("retry"))...)
+	}
+	if provider.MaxRecvMsgSize < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxRecvMsgSize"), provider.MaxRecvMsgSize, "must be greater than or equal to 0"))
+	}
+	if provider.MaxSendMsgSize < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxSendMsgSize"), provider.MaxSendMsgSize, "must be greater than or equal to 0"))
+	}
+	if provider.MaxConcurrency < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxConcurrency"), provider.MaxConcurrency, "must be greater than or equal to 0"))
+	}
+	if provider.Peer != nil {
+		peerPath := fldPath.Child("peer")
+		if scheme != "" && scheme != SchemeUnix {
+			allErrs = append(allErrs, field.Forbidden(peerPath, "peer verification is only supported for unix endpoints"))
+		}
+		if provider.TLS != nil {
+			allErrs = append(allErrs, field.Forbidden(peerPath, "peer verification can't be set together with tls"))
+		}
+		allErrs = append(allErrs, validatePeer(provider.Peer, peerPath)...)
+	}
+
+	return allErrs
+}
+
+func validateRetryPolicy(retry *RetryPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// the gRPC service config requires at least two attempts and limits them to 5
+	if retry.MaxAttempts < 2 || retry.MaxAttempts > 5 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAttempts"), retry.MaxAttempts, "must be between 2 and 5"))
+	}
+	if retry.InitialBackoff.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("initialBackoff"), retry.InitialBackoff.Duration.String(), "must be greater than 0"))
+	}
+	if retry.MaxBackoff.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxBackoff"), retry.MaxBackoff.Duration.String(), "must be greater than 0"))
+	}
+	if retry.BackoffMultiplier <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("backoffMultiplier"), retry.BackoffMultiplier, "must be greater than 0"))
+	}
+	if len(retry.RetryableStatusCodes) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("retryableStatusCodes"), ""))
+	}
+	for i, code := range retry.RetryableStatusCodes {
+		if !grpcStatusCodes.Has(code) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("retryableStatusCodes").Index(i), code, sets.List(grpcStatusCodes)))
+		}
+	}
+
+	return allErrs
+}
+
+// ParseEndpoint returns the scheme and address of the endpoint. The address is
+// the socket path for unix endpoints, host:port for tcp endpoints and cid:port
+// for vsock endpoints.
+func ParseEndpoint(endpoint string) (string, string, error) {
+	scheme, address, ok := strings.Cut(endpoint, "://")
+	if !ok || address == "" {
+		return "", "", fmt.Errorf("must be <scheme>://<address> with scheme %s, %s or %s", SchemeUnix, SchemeTCP, SchemeVsock)
+	}
+	switch strings.ToLower(scheme) {
+	case SchemeUnix, SchemeTCP:
+	case SchemeVsock:
+		if _, _, err := ParseVsockAddress(address); err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported scheme %q, must be %s, %s or %s", scheme, SchemeUnix, SchemeTCP, SchemeVsock)
+	}
+	return strings.ToLower(scheme), address, nil
+}
+
+// ParseVsockAddress returns the context ID and port of a cid:port vsock address
+func ParseVsockAddress(address string) (uint32, uint32, error) {
+	cidStr, portStr, ok := strings.Cut(address, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("vsock address %q must be <cid>:<port>", address)
+	}
+	cid, err := strconv.ParseUint(cidStr, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vsock context ID %q: %w", cidStr, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vsock port %q: %w", portStr, err)
+	}
+	return uint32(cid), uint32(port), nil
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const validConfig = `
+apiVersion: secrets-store.csi.x-k8s.io/v1alpha1
+kind: ProviderRegistry
+providers:
+- name: vault
+  endpoint: unix:///etc/kubernetes/secrets-store-csi-providers/vault.sock
+  timeout: 30s
+  maxConcurrency: 10
+- name: remote
+  endpoint: tcp://10.0.0.1:8443
+  tls:
+    caFile: /etc/provider/ca.crt
+  retry:
+    maxAttempts: 4
+    initialBackoff: 500ms
+    maxBackoff: 5s
+    backoffMultiplier: 2
+    retryableStatusCodes: [UNAVAILABLE, RESOURCE_EXHAUSTED]
+  maxRecvMsgSize: 16777216
+`
+
+func validProviders() []Provider {
+	return []Provider{
+		{Name: "vault", Endpoint: "unix:///tmp/vault.sock"},
+		{Name: "remote", Endpoint: "tcp://10.0.0.1:8443", TLS: &TLS{CAFile: "/tmp/ca.crt"}},
+		{Name: "enclave", Endpoint: "vsock://3:8443"},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registry.yaml")
+	if err := os.WriteFile(path, []byte(validConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	expected := &Config{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Providers: []Provider{
+			{
+				Name:           "vault",
+				Endpoint:       "unix:///etc/kubernetes/secrets-store-csi-providers/vault.sock",
+				Timeout:        &metav1.Duration{Duration: 30 * time.Second},
+				MaxConcurrency: 10,
+			},
+			{
+				Name:     "remote",
+				Endpoint: "tcp://10.0.0.1:8443",
+				TLS:      &TLS{CAFile: "/etc/provider/ca.crt"},
+				Retry: &RetryPolicy{
+					MaxAttempts:          4,
+					InitialBackoff:       metav1.Duration{Duration: 500 * time.Millisecond},
+					MaxBackoff:           metav1.Duration{Duration: 5 * time.Second},
+					BackoffMultiplier:    2,
+					RetryableStatusCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+				},
+				MaxRecvMsgSize: 16777216,
+			},
+		},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Load() = %+v, expected %+v", config, expected)
+	}
+
+	if p, ok := config.Get("remote"); !ok || p.Endpoint != "tcp://10.0.0.1:8443" {
+		t.Errorf("Get(remote) = %+v, %v", p, ok)
+	}
+	if _, ok := config.Get("unknown"); ok {
+		t.Errorf("Get(unknown) found a provider")
+	}
+	if _, ok := (*Config)(nil).Get("vault"); ok {
+		t.Errorf("Get() on a nil config found a provider")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "unknown field",
+			content:       strings.Replace(validConfig, "maxConcurrency", "concurrency", 1),
+			expectedError: `unknown field "concurrency"`,
+		},
+		{
+			name:          "invalid config",
+			content:       strings.Replace(validConfig, "ProviderRegistry", "Registry", 1),
+			expectedError: `kind: Unsupported value: "Registry"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "registry.yaml")
+			if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Fatalf("Load() error = %v, expected %q", err, test.expectedError)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("Load() of a missing file succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validRetry := func() *RetryPolicy {
+		retry := DefaultRetryPolicy
+		return &retry
+	}
+
+	tests := []struct {
+		name           string
+		mutate         func(config *Config)
+		expectedErrors []string
+	}{
+		{
+			name:   "valid config",
+			mutate: func(config *Config) {},
+		},
+		{
+			name: "invalid apiVersion and kind",
+			mutate: func(config *Config) {
+				config.APIVersion = "v1"
+				config.Kind = ""
+			},
+			expectedErrors: []string{
+				`apiVersion: Unsupported value: "v1": supported values: "secrets-store.csi.x-k8s.io/v1alpha1"`,
+				`kind: Unsupported value: "": supported values: "ProviderRegistry"`,
+			},
+		},
+		{
+			name: "missing and invalid names",
+			mutate: func(config *Config) {
+				config.Providers[0].Name = ""
+				config.Providers[1].Name = "../remote"
+			},
+			expectedErrors: []string{
+				"providers[0].name: Required value",
+				`providers[1].name: Invalid value: "../remote": must be a valid provider name`,
+			},
+		},
+		{
+			name:           "duplicate names",
+			mutate:         func(config *Config) { config.Providers[2].Name = "vault" },
+			expectedErrors: []string{`providers[2].name: Duplicate value: "vault"`},
+		},
+		{
+			name: "invalid endpoints",
+			mutate: func(config *Config) {
+				config.Providers[0].Endpoint = "/tmp/vault.sock"
+				config.Providers[1].Endpoint = "tcp://10.0.0.1"
+				config.Providers[2].Endpoint = "vsock://3"
+			},
+			expectedErrors: []string{
+				`providers[0].endpoint: Invalid value: "/tmp/vault.sock": must be <scheme>://<address> with scheme unix, tcp or vsock`,
+				`providers[1].endpoint: Invalid value: "tcp://10.0.0.1": address 10.0.0.1: missing port in address`,
+				`providers[2].endpoint: Invalid value: "vsock://3": vsock address "3" must be <cid>:<port>`,
+			},
+		},
+		{
+			name: "invalid TLS",
+			mutate: func(config *Config) {
+				config.Providers[0].TLS = &TLS{}
+				config.Providers[1].TLS.CertFile = "/tmp/tls.crt"
+			},
+			expectedErrors: []string{
+				"providers[0].tls: Forbidden: TLS is not supported for unix endpoints",
+				`providers[1].tls: Invalid value: "": certFile and keyFile must be set together`,
+			},
+		},
+		{
+			name: "invalid timeout and limits",
+			mutate: func(config *Config) {
+				config.Providers[0].Timeout = &metav1.Duration{}
+				config.Providers[0].MaxRecvMsgSize = -1
+				config.Providers[0].MaxSendMsgSize = -1
+				config.Providers[0].MaxConcurrency = -1
+			},
+			expectedErrors: []string{
+				`providers[0].timeout: Invalid value: "0s": must be greater than 0`,
+				"providers[0].maxRecvMsgSize: Invalid value: -1: must be greater than or equal to 0",
+				"providers[0].maxSendMsgSize: Invalid value: -1: must be greater than or equal to 0",
+				"providers[0].maxConcurrency: Invalid value: -1: must be greater than or equal to 0",
+			},
+		},
+		{
+			name: "valid peer",
+			mutate: func(config *Config) {
+				uid := int64(0)
+				config.Providers[0].Peer = &Peer{UID: &uid, SocketMode: "0660", ExecutablePath: "/bin/provider", ExecutableSHA256: strings.Repeat("ab", 32)}
+			},
+		},
+		{
+			name: "invalid peer",
+			mutate: func(config *Config) {
+				uid := int64(-1)
+				config.Providers[0].Peer = &Peer{UID: &uid, SocketMode: "0999", ExecutablePath: "provider", ExecutableSHA256: "abc"}
+				config.Providers[1].Peer = &Peer{}
+			},
+			expectedErrors: []string{
+				"providers[0].peer.uid: Invalid value: -1: must be greater than or equal to 0",
+				`providers[0].peer.socketMode: Invalid value: "0999": must be an octal file mode`,
+				`providers[0].peer.executablePath: Invalid value: "provider": must be an absolute path`,
+				`providers[0].peer.executableSHA256: Invalid value: "abc": must be a hex encoded SHA-256 hash`,
+				"providers[1].peer: Forbidden: peer verification is only supported for unix endpoints",
+				"providers[1].peer: Forbidden: peer verification can't be set together with tls",
+			},
+		},
+		{
+			name: "peer with tls",
+			mutate: func(config *Config) {
+				config.Providers[0].TLS = &TLS{CAFile: "/etc/provider/ca.crt"}
+				config.Providers[0].Peer = &Peer{SocketMode: "0660"}
+			},
+			expectedErrors: []string{
+				"providers[0].tls: Forbidden: TLS is not supported for unix endpoints",
+				"providers[0].peer: Forbidden: peer verification can't be set together with tls",
+			},
+		},
+		{
+			name:   "valid retry policy",
+			mutate: func(config *Config) { config.Providers[0].Retry = validRetry() },
+		},
+		{
+			name: "invalid retry policy",
+			mutate: func(config *Config) {
+				config.Providers[0].Retry = &RetryPolicy{MaxAttempts: 1}
+				config.Providers[1].Retry = validRetry()
+				config.Providers[1].Retry.RetryableStatusCodes = []string{"Unavailable"}
+			},
+			expectedErrors: []string{
+				"providers[0].retry.maxAttempts: Invalid value: 1: must be between 2 and 5",
+				`providers[0].retry.initialBackoff: Invalid value: "0s": must be greater than 0`,
+				`providers[0].retry.maxBackoff: Invalid value: "0s": must be greater than 0`,
+				"providers[0].retry.backoffMultiplier: Invalid value: 0: must be greater than 0",
+				"providers[0].retry.retryableStatusCodes: Required value",
+				`providers[1].retry.retryableStatusCodes[0]: Unsupported value: "Unavailable"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &Config{APIVersion: APIVersion, Kind: Kind, Providers: validProviders()}
+			test.mutate(config)
+
+			var got []string
+			for _, err := range config.Validate() {
+				got = append(got, err.Error())
+			}
+			if len(got) != len(test.expectedErrors) {
+				t.Fatalf("Validate() = %q, expected %q", got, test.expectedErrors)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], test.expectedErrors[i]) {
+					t.Errorf("Validate()[%d] = %q, expected %q", i, got[i], test.expectedErrors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint        string
+		expectedScheme  string
+		expectedAddress string
+		expectedErr     bool
+	}{
+		{endpoint: "unix:///tmp/vault.sock", expectedScheme: SchemeUnix, expectedAddress: "/tmp/vault.sock"},
+		{endpoint: "TCP://provider.example.com:8443", expectedScheme: SchemeTCP, expectedAddress: "provider.example.com:8443"},
+		{endpoint: "vsock://3:8443", expectedScheme: SchemeVsock, expectedAddress: "3:8443"},
+		{endpoint: "vsock://host:8443", expectedErr: true},
+		{endpoint: "http://10.0.0.1:80", expectedErr: true},
+		{endpoint: "unix://", expectedErr: true},
+		{endpoint: "", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.endpoint, func(t *testing.T) {
+			scheme, address, err := ParseEndpoint(test.endpoint)
+			if test.expectedErr != (err != nil) {
+				t.Fatalf("ParseEndpoint() error = %v, expected error: %v", err, test.expectedErr)
+			}
+			if scheme != test.expectedScheme || address != test.expectedAddress {
+				t.Errorf("ParseEndpoint() = %q, %q, expected %q, %q", scheme, address, test.expectedScheme, test.expectedAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// serviceName is the gRPC service implemented by the providers
+const serviceName = "v1alpha1.CSIDriverProvider"
+
+// DefaultRetryPolicy is the retry policy used if the provider doesn't set one.
+// It retries the requests if the connection to the provider is not ready.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	InitialBackoff:       metav1.Duration{Duration: time.Second},
+	MaxBackoff:           metav1.Duration{Duration: 10 * time.Second},
+	BackoffMultiplier:    1.1,
+	RetryableStatusCodes: []string{"UNAVAILABLE"},
+}
+
+// DialOptions returns the gRPC target of the provider and the dial options for its
+// transport settings. The options can be appended to the driver's default dial
+// options so they take precedence.
+func (p *Provider) DialOptions() (string, []grpc.DialOption, error) {
+	scheme, address, err := ParseEndpoint(p.Endpoint)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var target string
+	var opts []grpc.DialOption
+	authority := "localhost"
+	switch scheme {
+	case SchemeUnix:
+		target = "unix:" + address
+	case SchemeTCP:
+		target = "passthrough:///" + address
+		if authority, _, err = net.SplitHostPort(address); err != nil {
+			return "", nil, err
+		}
+	case SchemeVsock:
+		target = "passthrough:///" + address
+		opts = append(opts, grpc.WithContextDialer(dialVsock))
+	}
+
+	if p.TLS != nil {
+		config, err := p.TLS.config()
+		if err != nil {
+			return "", nil, err
+		}
+		if config.ServerName == "" {
+			config.ServerName = authority
+		}
+		authority = config.ServerName
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	} else if p.Peer != nil {
+		opts = append(opts, grpc.WithTransportCredentials(&peerCredentials{peer: p.Peer, socketPath: address}))
+	} else {
+		if scheme != SchemeUnix {
+			klog.InfoS("connection to provider is not encrypted, configure TLS in the provider registry", "provider", p.Name, "endpoint", p.Endpoint)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	opts = append(opts, grpc.WithAuthority(authority))
+
+	serviceConfig, err := p.serviceConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
+
+	var callOpts []grpc.CallOption
+	if p.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(p.MaxRecvMsgSize))
+	}
+	if p.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(p.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if p.MaxConcurrency > 0 {
+		limiter := make(concurrencyLimiter, p.MaxConcurrency)
+		opts = append(opts, grpc.WithChainUnaryInterceptor(limiter.unary), grpc.WithChainStreamInterceptor(limiter.stream))
+	}
+
+	return target, opts, nil
+}
+
+// serviceConfig returns the gRPC service config with the retry policy and timeout
+// of the provider.
+func (p *Provider) serviceConfig() (string, error) {
+	retry := DefaultRetryPolicy
+	if p.Retry != nil {
+		retry = *p.Retry
+	}
+	methodConfig := map[string]any{
+		"name":         []map[string]string{{"service": serviceName}},
+		"waitForReady": true,
+		"retryPolicy": map[string]any{
+			"maxAttempts":          retry.MaxAttempts,
+			"initialBackoff":       durationString(retry.InitialBackoff.Duration),
+			"maxBackoff":           durationString(retry.MaxBackoff.Duration),
+			"backoffMultiplier":    retry.BackoffMultiplier,
+			"retryableStatusCodes": retry.RetryableStatusCodes,
+		},
+	}
+	if p.Timeout != nil {
+		methodConfig["timeout"] = durationString(p.Timeout.Duration)
+	}
+	data, err := json.Marshal(map[string]any{"methodConfig": []any{methodConfig}})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// config returns the TLS config with the CA and client certificates
+func (t *TLS) config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// durationString formats the duration in the gRPC service config format, e.g. 1.5s
+func durationString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// concurrencyLimiter limits the number of concurrent requests on a connection
+type concurrencyLimiter chan struct{}
+
+func (l concurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (l concurrencyLimiter) release() {
+	<-l
+}
+
+func (l concurrencyLimiter) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+	defer l.release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (l concurrencyLimiter) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	release := func() { once.Do(l.release) }
+
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// the stream ends when it's received completely or its context is canceled
+	stop := context.AfterFunc(ctx, release)
+	return &limitedStream{ClientStream: s, release: func() {
+		stop()
+		release()
+	}}, nil
+}
+
+// limitedStream releases the concurrency limiter when the stream ends
+type limitedStream struct {
+	grpc.ClientStream
+	release func()
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.release()
+	}
+	return err
+}
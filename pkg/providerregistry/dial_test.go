@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		expected string
+	}{
+		{
+			name:     "default retry policy",
+			provider: Provider{},
+			expected: `{"methodConfig":[{"name":[{"service":"v1alpha1.CSIDriverProvider"}],"retryPolicy":{"backoffMultiplier":1.1,"initialBackoff":"1s","maxAttempts":3,"maxBackoff":"10s","retryableStatusCodes":["UNAVAILABLE"]},"waitForReady":true}]}`,
+		},
+		{
+			name: "retry policy and timeout",
+			provider: Provider{
+				Timeout: &metav1.Duration{Duration: 1500 * time.Millisecond},
+				Retry: &RetryPolicy{
+					MaxAttempts:          5,
+					InitialBackoff:       metav1.Duration{Duration: 100 * time.Millisecond},
+					MaxBackoff:           metav1.Duration{Duration: time.Minute},
+					BackoffMultiplier:    2,
+					RetryableStatusCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+				},
+			},
+			expected: `{"methodConfig":[{"name":[{"service":"v1alpha1.CSIDriverProvider"}],"retryPolicy":{"backoffMultiplier":2,"initialBackoff":"0.1s","maxAttempts":5,"maxBackoff":"60s","retryableStatusCodes":["UNAVAILABLE","RESOURCE_EXHAUSTED"]},"timeout":"1.5s","waitForReady":true}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.provider.serviceConfig()
+			if err != nil {
+				t.Fatalf("serviceConfig() error = %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("serviceConfig() = %s, expected %s", got, test.expected)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("serviceConfig() is not valid JSON")
+			}
+		})
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		provider       Provider
+		expectedTarget string
+		expectedErr    bool
+	}{
+		{
+			name:           "unix endpoint",
+			provider:       Provider{Name: "vault", Endpoint: "unix:///tmp/vault.sock", MaxConcurrency: 2, MaxRecvMsgSize: 1024},
+			expectedTarget: "unix:/tmp/vault.sock",
+		},
+		{
+			name:           "tcp endpoint",
+			provider:       Provider{Name: "remote", Endpoint: "tcp://10.0.0.1:8443", TLS: &TLS{ServerName: "provider.example.com"}},
+			expectedTarget: "passthrough:///10.0.0.1:8443",
+		},
+		{
+			name:           "vsock endpoint",
+			provider:       Provider{Name: "enclave", Endpoint: "vsock://3:8443"},
+			expectedTarget: "passthrough:///3:8443",
+		},
+		{
+			name:        "missing CA file",
+			provider:    Provider{Name: "remote", Endpoint: "tcp://10.0.0.1:8443", TLS: &TLS{CAFile: "/does/not/exist"}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid endpoint",
+			provider:    Provider{Name: "remote", Endpoint: "10.0.0.1:8443"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, opts, err := test.provider.DialOptions()
+			if test.expectedErr != (err != nil) {
+				t.Fatalf("DialOptions() error = %v, expected error: %v", err, test.expectedErr)
+			}
+			if test.expectedErr {
+				return
+			}
+			if target != test.expectedTarget {
+				t.Errorf("DialOptions() target = %q, expected %q", target, test.expectedTarget)
+			}
+			conn, err := grpc.NewClient(target, opts...)
+			if err != nil {
+				t.Fatalf("grpc.NewClient() error = %v", err)
+			}
+			conn.Close()
+		})
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := make(concurrencyLimiter, 1)
+	invoked := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		invoked++
+		return nil
+	}
+
+	if err := limiter.unary(context.Background(), "/v1alpha1.CSIDriverProvider/Mount", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("unary() error = %v", err)
+	}
+	if len(limiter) != 0 {
+		t.Fatalf("expected the limiter to be released after the request")
+	}
+
+	// a stream holds the limiter until it's received completely
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{}, nil
+	}
+	s, err := limiter.stream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/v1alpha1.CSIDriverProvider/MountStream", streamer)
+	if err != nil {
+		t.Fatalf("stream() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = limiter.unary(ctx, "/v1alpha1.CSIDriverProvider/Mount", nil, nil, nil, invoker)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded while the limit is reached, got: %v", err)
+	}
+
+	if err := s.RecvMsg(nil); err == nil {
+		t.Fatalf("expected the fake stream to end")
+	}
+	if err := limiter.unary(context.Background(), "/v1alpha1.CSIDriverProvider/Mount", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("unary() error = %v", err)
+	}
+	if !reflect.DeepEqual(invoked, 2) {
+		t.Errorf("expected 2 invocations, got %d", invoked)
+	}
+}
+
+// fakeClientStream is a stream that ends on the first RecvMsg
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *fakeClientStream) RecvMsg(m any) error {
+	return status.Error(codes.Unavailable, "stream ended")
+}
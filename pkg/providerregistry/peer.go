@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"google.golang.org/grpc/credentials"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ErrPeerVerificationFailed is returned when the provider process or socket file
+// doesn't match the expected identity
+var ErrPeerVerificationFailed = errors.New("provider peer verification failed")
+
+// Peer is the expected identity of the provider. The fields that are not set are
+// not verified.
+type Peer struct {
+	// UID is the user ID of the provider process and the owner of the socket file
+	// +optional
+	UID *int64 `json:"uid,omitempty"`
+	// GID is the group ID of the provider process and the group of the socket file
+	// +optional
+	GID *int64 `json:"gid,omitempty"`
+	// SocketMode is the octal permission mask of the socket file, e.g. "0660". The
+	// socket file must not have permissions that are not in the mask.
+	// +optional
+	SocketMode string `json:"socketMode,omitempty"`
+	// ExecutablePath is the path of the provider executable, as seen from the mount
+	// namespace of the provider process
+	// +optional
+	ExecutablePath string `json:"executablePath,omitempty"`
+	// ExecutableSHA256 is the hex encoded SHA-256 hash of the provider executable
+	// +optional
+	ExecutableSHA256 string `json:"executableSHA256,omitempty"`
+}
+
+// PeerIdentity is the identity of the process listening on the provider socket
+type PeerIdentity struct {
+	PID        int32
+	UID        uint32
+	GID        uint32
+	Executable string
+}
+
+func validatePeer(peer *Peer, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if peer.UID != nil && *peer.UID < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("uid"), *peer.UID, "must be greater than or equal to 0"))
+	}
+	if peer.GID != nil && *peer.GID < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("gid"), *peer.GID, "must be greater than or equal to 0"))
+	}
+	if peer.SocketMode != "" {
+		if _, err := peer.socketMode(); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("socketMode"), peer.SocketMode, "must be an octal file mode, e.g. 0660"))
+		}
+	}
+	if peer.ExecutablePath != "" && !filepath.IsAbs(peer.ExecutablePath) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("executablePath"), peer.ExecutablePath, "must be an absolute path"))
+	}
+	if peer.ExecutableSHA256 != "" {
+		if b, err := hex.DecodeString(peer.ExecutableSHA256); err != nil || len(b) != 32 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("executableSHA256"), peer.ExecutableSHA256, "must be a hex encoded SHA-256 hash"))
+		}
+	}
+
+	return allErrs
+}
+
+// socketMode returns the permission mask of the socket file
+func (p *Peer) socketMode() (uint32, error) {
+	mode, err := strconv.ParseUint(p.SocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid socket mode %q", p.SocketMode)
+	}
+	return uint32(mode), nil
+}
+
+// peerCredentials verifies the identity of the provider when the connection to
+// the provider socket is created. The connection is not encrypted as it's only
+// secured through the socket peer credentials and filesystem ACLs.
+type peerCredentials struct {
+	peer       *Peer
+	socketPath string
+}
+
+// peerAuthInfo is the auth info of a connection verified with peerCredentials
+type peerAuthInfo struct {
+	credentials.CommonAuthInfo
+	Identity *PeerIdentity
+}
+
+func (peerAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+func (c *peerCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, ok := rawConn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: peer credentials require a unix socket connection", ErrPeerVerificationFailed)
+	}
+	identity, err := c.peer.Verify(conn, c.socketPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rawConn, peerAuthInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		Identity:       identity,
+	}, nil
+}
+
+func (c *peerCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials are only supported for clients")
+}
+
+func (c *peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (c *peerCredentials) Clone() credentials.TransportCredentials {
+	return &peerCredentials{peer: c.peer, socketPath: c.socketPath}
+}
+
+func (c *peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// VerifyPeer connects to the provider socket and verifies the identity of the
+// provider. It returns a nil identity if no identity is set for the provider.
+func (p *Provider) VerifyPeer(ctx context.Context) (*PeerIdentity, error) {
+	if p.Peer == nil {
+		return nil, nil
+	}
+	_, socketPath, err := ParseEndpoint(p.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	rawConn, err := (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rawConn.Close()
+	return p.Peer.Verify(rawConn.(*net.UnixConn), socketPath)
+}
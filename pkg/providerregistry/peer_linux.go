@@ -0,0 +1,149 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// executableKey identifies a version of an executable file
+type executableKey struct {
+	dev, ino uint64
+	size     int64
+	mtime    syscall.Timespec
+}
+
+// executableHashes caches the hashes of the provider executables so they are not
+// read again for every connection
+var executableHashes sync.Map
+
+// Verify verifies the identity of the process listening on the other end of the
+// connection with SO_PEERCRED, and the ownership and mode of the socket file. An
+// error wrapping ErrPeerVerificationFailed is returned if it doesn't match.
+func (p *Peer) Verify(conn *net.UnixConn, socketPath string) (*PeerIdentity, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, fmt.Errorf("failed to get provider peer credentials: %w", credErr)
+	}
+	identity := &PeerIdentity{PID: cred.Pid, UID: cred.Uid, GID: cred.Gid}
+
+	if p.UID != nil && int64(cred.Uid) != *p.UID {
+		return identity, fmt.Errorf("%w: provider process uid %d, expected %d", ErrPeerVerificationFailed, cred.Uid, *p.UID)
+	}
+	if p.GID != nil && int64(cred.Gid) != *p.GID {
+		return identity, fmt.Errorf("%w: provider process gid %d, expected %d", ErrPeerVerificationFailed, cred.Gid, *p.GID)
+	}
+
+	if err := p.verifySocketFile(socketPath); err != nil {
+		return identity, err
+	}
+
+	if p.ExecutablePath == "" && p.ExecutableSHA256 == "" {
+		return identity, nil
+	}
+	exe := fmt.Sprintf("/proc/%d/exe", cred.Pid)
+	if identity.Executable, err = os.Readlink(exe); err != nil {
+		return identity, fmt.Errorf("%w: failed to read provider executable: %w", ErrPeerVerificationFailed, err)
+	}
+	if p.ExecutablePath != "" && identity.Executable != p.ExecutablePath {
+		return identity, fmt.Errorf("%w: provider executable %s, expected %s", ErrPeerVerificationFailed, identity.Executable, p.ExecutablePath)
+	}
+	if p.ExecutableSHA256 != "" {
+		hash, err := executableSHA256(exe)
+		if err != nil {
+			return identity, fmt.Errorf("%w: failed to hash provider executable: %w", ErrPeerVerificationFailed, err)
+		}
+		if !strings.EqualFold(hash, p.ExecutableSHA256) {
+			return identity, fmt.Errorf("%w: provider executable sha256 %s, expected %s", ErrPeerVerificationFailed, hash, p.ExecutableSHA256)
+		}
+	}
+	return identity, nil
+}
+
+// verifySocketFile verifies the owner, group and mode of the socket file
+func (p *Peer) verifySocketFile(socketPath string) error {
+	var st unix.Stat_t
+	if err := unix.Stat(socketPath, &st); err != nil {
+		return fmt.Errorf("%w: failed to stat provider socket: %w", ErrPeerVerificationFailed, err)
+	}
+	if p.UID != nil && int64(st.Uid) != *p.UID {
+		return fmt.Errorf("%w: provider socket %s is owned by uid %d, expected %d", ErrPeerVerificationFailed, socketPath, st.Uid, *p.UID)
+	}
+	if p.GID != nil && int64(st.Gid) != *p.GID {
+		return fmt.Errorf("%w: provider socket %s is owned by gid %d, expected %d", ErrPeerVerificationFailed, socketPath, st.Gid, *p.GID)
+	}
+	if p.SocketMode != "" {
+		mask, err := p.socketMode()
+		if err != nil {
+			return err
+		}
+		if perm := st.Mode & 0777; perm&^mask != 0 {
+			return fmt.Errorf("%w: provider socket %s has mode %#o, expected at most %#o", ErrPeerVerificationFailed, socketPath, perm, mask)
+		}
+	}
+	return nil
+}
+
+// executableSHA256 returns the hex encoded SHA-256 hash of the executable. The hash
+// is cached until the executable file changes.
+func executableSHA256(exe string) (string, error) {
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var st unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+		return "", err
+	}
+	key := executableKey{dev: st.Dev, ino: st.Ino, size: st.Size, mtime: syscall.Timespec(st.Mtim)}
+	if hash, ok := executableHashes.Load(key); ok {
+		return hash.(string), nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	executableHashes.Store(key, hash)
+	return hash, nil
+}
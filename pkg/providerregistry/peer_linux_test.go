@@ -0,0 +1,139 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// listen creates a unix socket listener in a temporary directory. The peer is the
+// test process.
+func listen(t *testing.T) string {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "provider.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	if err := os.Chmod(socketPath, 0660); err != nil {
+		t.Fatal(err)
+	}
+	return socketPath
+}
+
+func TestVerifyPeer(t *testing.T) {
+	uid := int64(os.Getuid())
+	gid := int64(os.Getgid())
+	otherID := uid + 1
+	exe, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name          string
+		peer          *Peer
+		expectedError string
+	}{
+		{
+			name: "no identity",
+			peer: &Peer{},
+		},
+		{
+			name: "matching identity",
+			peer: &Peer{UID: &uid, GID: &gid, SocketMode: "0660", ExecutablePath: exe, ExecutableSHA256: strings.ToUpper(hash)},
+		},
+		{
+			name:          "uid mismatch",
+			peer:          &Peer{UID: &otherID},
+			expectedError: "provider process uid",
+		},
+		{
+			name:          "gid mismatch",
+			peer:          &Peer{GID: &otherID},
+			expectedError: "provider process gid",
+		},
+		{
+			name:          "socket mode not allowed",
+			peer:          &Peer{SocketMode: "0600"},
+			expectedError: "has mode 0660, expected at most 0600",
+		},
+		{
+			name:          "executable path mismatch",
+			peer:          &Peer{ExecutablePath: "/usr/bin/provider"},
+			expectedError: "expected /usr/bin/provider",
+		},
+		{
+			name:          "executable hash mismatch",
+			peer:          &Peer{ExecutableSHA256: strings.Repeat("0", 64)},
+			expectedError: "provider executable sha256",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			socketPath := listen(t)
+			provider := &Provider{Name: "provider1", Endpoint: "unix://" + socketPath, Peer: test.peer}
+
+			identity, err := provider.VerifyPeer(context.Background())
+			if test.expectedError == "" {
+				if err != nil {
+					t.Fatalf("VerifyPeer() error = %v", err)
+				}
+			} else if !errors.Is(err, ErrPeerVerificationFailed) || !strings.Contains(err.Error(), test.expectedError) {
+				t.Fatalf("VerifyPeer() error = %v, expected %q", err, test.expectedError)
+			}
+			if identity.PID != int32(os.Getpid()) || int64(identity.UID) != uid {
+				t.Errorf("VerifyPeer() identity = %+v, expected pid %d and uid %d", identity, os.Getpid(), uid)
+			}
+		})
+	}
+}
+
+func TestVerifyPeer_NoIdentity(t *testing.T) {
+	provider := &Provider{Name: "provider1", Endpoint: "unix:///does/not/exist.sock"}
+	if identity, err := provider.VerifyPeer(context.Background()); identity != nil || err != nil {
+		t.Fatalf("VerifyPeer() = %v, %v, expected no verification", identity, err)
+	}
+}
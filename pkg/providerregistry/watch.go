@@ -0,0 +1,73 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+)
+
+// Watch reloads the config file when it changes and calls onChange with the new
+// config. The directory of the file is watched so the config is also reloaded when
+// it's mounted from a ConfigMap, which is updated by swapping a symlink. If the new
+// config is invalid, the error is logged and the current config is kept.
+func Watch(ctx context.Context, path string, current *Config, onChange func(*Config)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	klog.InfoS("watching provider registry config", "path", path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			config, err := Load(path)
+			if err != nil {
+				klog.ErrorS(err, "failed to reload provider registry config, keeping the current config", "path", path)
+				continue
+			}
+			if reflect.DeepEqual(config, current) {
+				continue
+			}
+			klog.InfoS("reloaded provider registry config", "path", path, "providers", len(config.Providers))
+			current = config
+			onChange(config)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.ErrorS(err, "provider registry config watch error", "path", path)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerregistry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	if err := os.WriteFile(path, []byte(validConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	current, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan *Config, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, path, current, func(c *Config) { changes <- c })
+	}()
+
+	// wait for the watch to start by rewriting the file until a change is seen
+	updated := strings.Replace(validConfig, "maxConcurrency: 10", "maxConcurrency: 20", 1)
+	var config *Config
+	for config == nil {
+		if err := os.WriteFile(path, []byte(updated), 0600); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case config = <-changes:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if config.Providers[0].MaxConcurrency != 20 {
+		t.Errorf("expected the reloaded config, got maxConcurrency %d", config.Providers[0].MaxConcurrency)
+	}
+
+	// an invalid config is ignored
+	if err := os.WriteFile(path, []byte("kind: Foo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		t.Fatalf("expected the invalid config to be ignored, got %+v", c)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch() error = %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"fmt"
+	"strings"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/secretutil"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+// deriveFiles assembles the derived files declared in the secret provider class from
+// the files returned by the provider. The derived files get the mode of the object
+// that contains their password.
+func deriveFiles(files []*v1alpha1.File, derivedFiles []secretsstorev1.DerivedFile) ([]*v1alpha1.File, error) {
+	if len(derivedFiles) == 0 {
+		return nil, nil
+	}
+	byPath := make(map[string]*v1alpha1.File, len(files))
+	for _, file := range files {
+		byPath[file.GetPath()] = file
+	}
+
+	derived := make([]*v1alpha1.File, 0, len(derivedFiles))
+	for _, derivedFile := range derivedFiles {
+		if _, ok := byPath[derivedFile.Path]; ok {
+			return nil, fmt.Errorf("derived file %s conflicts with an object returned by the provider", derivedFile.Path)
+		}
+		if derivedFile.Keystore == nil {
+			return nil, fmt.Errorf("keystore is required for derived file %s", derivedFile.Path)
+		}
+		file, err := deriveKeystore(derivedFile.Path, derivedFile.Keystore, byPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive file %s, err: %w", derivedFile.Path, err)
+		}
+		derived = append(derived, file)
+	}
+	return derived, nil
+}
+
+// deriveKeystore assembles the keystore, or the truststore if no private key is set
+func deriveKeystore(path string, keystore *secretsstorev1.Keystore, files map[string]*v1alpha1.File) (*v1alpha1.File, error) {
+	passwordFile, err := objectFile(files, keystore.PasswordObjectName)
+	if err != nil {
+		return nil, err
+	}
+	password := strings.TrimRight(string(passwordFile.GetContents()), "\r\n")
+
+	var contents []byte
+	if len(keystore.KeyObjectName) > 0 {
+		key, err := objectFile(files, keystore.KeyObjectName)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := objectFile(files, keystore.CertObjectName)
+		if err != nil {
+			return nil, err
+		}
+		contents, err = secretutil.EncodeKeystore(keystore.Format, key.GetContents(), cert.GetContents(), keystore.Alias, password)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		objects := make([][]byte, 0, len(keystore.TrustedCertObjectNames))
+		for _, objectName := range keystore.TrustedCertObjectNames {
+			cert, err := objectFile(files, objectName)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, cert.GetContents())
+		}
+		contents, err = secretutil.EncodeTruststore(keystore.Format, objects, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &v1alpha1.File{Path: path, Mode: passwordFile.GetMode(), Contents: contents}, nil
+}
+
+// objectFile returns the file of the object returned by the provider
+func objectFile(files map[string]*v1alpha1.File, objectName string) (*v1alpha1.File, error) {
+	file, ok := files[objectName]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found in the mount response", objectName)
+	}
+	return file, nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestKeyPair returns a PEM encoded self-signed certificate and its private key
+func newTestKeyPair(t *testing.T, cn string) (cert, key []byte) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestDeriveFiles(t *testing.T) {
+	cert, key := newTestKeyPair(t, "server")
+	files := []*v1alpha1.File{
+		{Path: "tls.crt", Mode: 0644, Contents: cert},
+		{Path: "tls.key", Mode: 0600, Contents: key},
+		{Path: "password", Mode: 0400, Contents: []byte("changeit\n")},
+	}
+
+	tests := []struct {
+		name          string
+		derivedFiles  []secretsstorev1.DerivedFile
+		expectedError string
+	}{
+		{
+			name:         "no derived files",
+			derivedFiles: nil,
+		},
+		{
+			name: "keystore",
+			derivedFiles: []secretsstorev1.DerivedFile{{
+				Path:     "keystore.p12",
+				Keystore: &secretsstorev1.Keystore{KeyObjectName: "tls.key", CertObjectName: "tls.crt", PasswordObjectName: "password"},
+			}},
+		},
+		{
+			name: "jks truststore",
+			derivedFiles: []secretsstorev1.DerivedFile{{
+				Path:     "truststore.jks",
+				Keystore: &secretsstorev1.Keystore{Format: secretsstorev1.KeystoreFormatJKS, TrustedCertObjectNames: []string{"tls.crt"}, PasswordObjectName: "password"},
+			}},
+		},
+		{
+			name: "missing password object",
+			derivedFiles: []secretsstorev1.DerivedFile{{
+				Path:     "keystore.p12",
+				Keystore: &secretsstorev1.Keystore{KeyObjectName: "tls.key", CertObjectName: "tls.crt", PasswordObjectName: "keystore-password"},
+			}},
+			expectedError: "failed to derive file keystore.p12, err: object keystore-password not found in the mount response",
+		},
+		{
+			name: "invalid certificate object",
+			derivedFiles: []secretsstorev1.DerivedFile{{
+				Path:     "truststore.p12",
+				Keystore: &secretsstorev1.Keystore{TrustedCertObjectNames: []string{"password"}, PasswordObjectName: "password"},
+			}},
+			expectedError: "no certificate found",
+		},
+		{
+			name: "conflicting path",
+			derivedFiles: []secretsstorev1.DerivedFile{{
+				Path:     "tls.crt",
+				Keystore: &secretsstorev1.Keystore{TrustedCertObjectNames: []string{"tls.crt"}, PasswordObjectName: "password"},
+			}},
+			expectedError: "derived file tls.crt conflicts with an object returned by the provider",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			derived, err := deriveFiles(files, test.derivedFiles)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if len(derived) != len(test.derivedFiles) {
+				t.Fatalf("expected %d derived files, got %d", len(test.derivedFiles), len(derived))
+			}
+			for i, file := range derived {
+				if file.GetPath() != test.derivedFiles[i].Path {
+					t.Fatalf("expected derived file %s, got %s", test.derivedFiles[i].Path, file.GetPath())
+				}
+				// the derived files get the mode of the password object
+				if file.GetMode() != 0400 {
+					t.Fatalf("expected mode 0400, got %o", file.GetMode())
+				}
+				if len(file.GetContents()) == 0 {
+					t.Fatalf("expected derived file %s to have contents", file.GetPath())
+				}
+			}
+		})
+	}
+}
+
+func TestDeriveFilesPKCS12Password(t *testing.T) {
+	cert, key := newTestKeyPair(t, "server")
+	files := []*v1alpha1.File{
+		{Path: "tls.crt", Contents: cert},
+		{Path: "tls.key", Contents: key},
+		{Path: "password", Contents: []byte("changeit\r\n")},
+	}
+	derived, err := deriveFiles(files, []secretsstorev1.DerivedFile{{
+		Path:     "keystore.p12",
+		Keystore: &secretsstorev1.Keystore{KeyObjectName: "tls.key", CertObjectName: "tls.crt", PasswordObjectName: "password"},
+	}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	// the trailing line break of the password object is not part of the password
+	_, leaf, _, err := pkcs12.DecodeChain(derived[0].GetContents(), "changeit")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if leaf.Subject.CommonName != "server" {
+		t.Fatalf("expected certificate server, got %s", leaf.Subject.CommonName)
+	}
+}
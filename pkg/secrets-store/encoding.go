@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+// decodeFiles decodes the contents of the files in place using the encodings declared
+// for the objects in the secret provider class, by object name. Files without a declared
+// encoding are left as is.
+func decodeFiles(files []*v1alpha1.File, encodings map[string]secretsstorev1.ObjectEncoding) error {
+	if len(encodings) == 0 {
+		return nil
+	}
+	for _, file := range files {
+		encoding, ok := encodings[file.GetPath()]
+		if !ok {
+			continue
+		}
+		contents, err := decodeContent(file.GetContents(), encoding)
+		if err != nil {
+			return fmt.Errorf("failed to decode object %s with encoding %s: %w", file.GetPath(), encoding, err)
+		}
+		file.Contents = contents
+	}
+	return nil
+}
+
+// decodeContent decodes the content with the encoding. Whitespace, e.g. line breaks
+// added by the store, is ignored for the base64 and hex encodings.
+func decodeContent(content []byte, encoding secretsstorev1.ObjectEncoding) ([]byte, error) {
+	switch encoding {
+	case secretsstorev1.ObjectEncodingBase64:
+		return base64.StdEncoding.DecodeString(stripWhitespace(content))
+	case secretsstorev1.ObjectEncodingBase64URL:
+		s := stripWhitespace(content)
+		if strings.HasSuffix(s, "=") {
+			return base64.URLEncoding.DecodeString(s)
+		}
+		return base64.RawURLEncoding.DecodeString(s)
+	case secretsstorev1.ObjectEncodingHex:
+		return hex.DecodeString(stripWhitespace(content))
+	case secretsstorev1.ObjectEncodingUTF8:
+		if !utf8.Valid(content) {
+			return nil, fmt.Errorf("content is not valid UTF-8")
+		}
+		return content, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding")
+	}
+}
+
+func stripWhitespace(content []byte) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(content))
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"bytes"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+)
+
+func TestDecodeContent(t *testing.T) {
+	binary := []byte{0x00, 0xfe, 0xff, 0x3f, 0x3e}
+
+	tests := []struct {
+		name          string
+		content       string
+		encoding      secretsstorev1.ObjectEncoding
+		expected      []byte
+		expectedError bool
+	}{
+		{name: "base64", content: "AP7/Pz4=", encoding: secretsstorev1.ObjectEncodingBase64, expected: binary},
+		{name: "base64 with line breaks", content: "AP7/\r\nPz4=\n", encoding: secretsstorev1.ObjectEncodingBase64, expected: binary},
+		{name: "base64url with padding", content: "AP7_Pz4=", encoding: secretsstorev1.ObjectEncodingBase64URL, expected: binary},
+		{name: "base64url without padding", content: "AP7_Pz4", encoding: secretsstorev1.ObjectEncodingBase64URL, expected: binary},
+		{name: "hex", content: "00feff3f3e\n", encoding: secretsstorev1.ObjectEncodingHex, expected: binary},
+		{name: "utf-8", content: "pässword", encoding: secretsstorev1.ObjectEncodingUTF8, expected: []byte("pässword")},
+		{name: "invalid base64", content: "AP7_Pz4=", encoding: secretsstorev1.ObjectEncodingBase64, expectedError: true},
+		{name: "invalid base64url", content: "AP7/Pz4=", encoding: secretsstorev1.ObjectEncodingBase64URL, expectedError: true},
+		{name: "invalid hex", content: "00fef", encoding: secretsstorev1.ObjectEncodingHex, expectedError: true},
+		{name: "invalid utf-8", content: string(binary), encoding: secretsstorev1.ObjectEncodingUTF8, expectedError: true},
+		{name: "unsupported encoding", content: "foo", encoding: "base32", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := decodeContent([]byte(test.content), test.encoding)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !bytes.Equal(actual, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
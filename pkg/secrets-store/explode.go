@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"fmt"
+	"sort"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/secretutil"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+// explodeFiles replaces the files of the objects declared with explode in the secret
+// provider class, by object name, with a file for each field of the object in a
+// directory named after the object. The other files are left as is.
+func explodeFiles(files []*v1alpha1.File, explodes map[string]*secretsstorev1.ObjectExplode) ([]*v1alpha1.File, error) {
+	if len(explodes) == 0 {
+		return files, nil
+	}
+
+	exploded := make([]*v1alpha1.File, 0, len(files))
+	for _, file := range files {
+		explode, ok := explodes[file.GetPath()]
+		if !ok {
+			exploded = append(exploded, file)
+			continue
+		}
+		fieldFiles, err := explodeFile(file, explode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explode object %s, err: %w", file.GetPath(), err)
+		}
+		exploded = append(exploded, fieldFiles...)
+	}
+
+	if err := validateFilePaths(exploded); err != nil {
+		return nil, err
+	}
+	return exploded, nil
+}
+
+// explodeFile returns the files of the fields of the object. The fields are written
+// to the path of the item, or to a file named after the field if no items are set.
+func explodeFile(file *v1alpha1.File, explode *secretsstorev1.ObjectExplode) ([]*v1alpha1.File, error) {
+	fields, err := secretutil.ExplodeFields(file.GetContents(), explode.JSONPath)
+	if err != nil {
+		return nil, err
+	}
+	mode := file.GetMode()
+	if explode.DefaultMode != nil {
+		mode = *explode.DefaultMode
+	}
+
+	if len(explode.Items) == 0 {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		files := make([]*v1alpha1.File, 0, len(keys))
+		for _, key := range keys {
+			files = append(files, &v1alpha1.File{Path: file.GetPath() + "/" + key, Mode: mode, Contents: fields[key]})
+		}
+		return files, nil
+	}
+
+	files := make([]*v1alpha1.File, 0, len(explode.Items))
+	for _, item := range explode.Items {
+		contents, ok := fields[item.Key]
+		if !ok {
+			return nil, fmt.Errorf("field %s not found", item.Key)
+		}
+		path := item.Key
+		if len(item.Path) > 0 {
+			path = item.Path
+		}
+		itemMode := mode
+		if item.Mode != nil {
+			itemMode = *item.Mode
+		}
+		files = append(files, &v1alpha1.File{Path: file.GetPath() + "/" + path, Mode: itemMode, Contents: contents})
+	}
+	return files, nil
+}
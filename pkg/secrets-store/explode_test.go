@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestExplodeFiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         []*v1alpha1.File
+		explodes      map[string]*secretsstorev1.ObjectExplode
+		expectedFiles []*v1alpha1.File
+		expectedError string
+	}{
+		{
+			name:          "no explode",
+			files:         []*v1alpha1.File{{Path: "db", Mode: 0644, Contents: []byte(`{"username":"admin"}`)}},
+			expectedFiles: []*v1alpha1.File{{Path: "db", Mode: 0644, Contents: []byte(`{"username":"admin"}`)}},
+		},
+		{
+			name: "json fields",
+			files: []*v1alpha1.File{
+				{Path: "db", Mode: 0644, Contents: []byte(`{"username":"admin","password":"secret","port":5432}`)},
+				{Path: "other", Mode: 0644, Contents: []byte("other")},
+			},
+			explodes: map[string]*secretsstorev1.ObjectExplode{"db": {}},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "db/password", Mode: 0644, Contents: []byte("secret")},
+				{Path: "db/port", Mode: 0644, Contents: []byte("5432")},
+				{Path: "db/username", Mode: 0644, Contents: []byte("admin")},
+				{Path: "other", Mode: 0644, Contents: []byte("other")},
+			},
+		},
+		{
+			name:     "yaml fields selected by json path with default mode",
+			files:    []*v1alpha1.File{{Path: "config.yaml", Mode: 0644, Contents: []byte("db:\n  username: admin\n  password: secret\n")}},
+			explodes: map[string]*secretsstorev1.ObjectExplode{"config.yaml": {JSONPath: ".db", DefaultMode: int32Ptr(0400)}},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "config.yaml/password", Mode: 0400, Contents: []byte("secret")},
+				{Path: "config.yaml/username", Mode: 0400, Contents: []byte("admin")},
+			},
+		},
+		{
+			name:  "items",
+			files: []*v1alpha1.File{{Path: "db", Mode: 0644, Contents: []byte(`{"username":"admin","password":"secret","port":5432}`)}},
+			explodes: map[string]*secretsstorev1.ObjectExplode{"db": {
+				DefaultMode: int32Ptr(0440),
+				Items: []secretsstorev1.ObjectExplodeItem{
+					{Key: "username"},
+					{Key: "password", Path: "credentials/password", Mode: int32Ptr(0400)},
+				},
+			}},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "db/username", Mode: 0440, Contents: []byte("admin")},
+				{Path: "db/credentials/password", Mode: 0400, Contents: []byte("secret")},
+			},
+		},
+		{
+			name:          "not a map",
+			files:         []*v1alpha1.File{{Path: "db", Contents: []byte(`["admin"]`)}},
+			explodes:      map[string]*secretsstorev1.ObjectExplode{"db": {}},
+			expectedError: "failed to explode object db, err: jsonPath {} resolves to a list, expected a map",
+		},
+		{
+			name:          "missing item",
+			files:         []*v1alpha1.File{{Path: "db", Contents: []byte(`{"username":"admin"}`)}},
+			explodes:      map[string]*secretsstorev1.ObjectExplode{"db": {Items: []secretsstorev1.ObjectExplodeItem{{Key: "password"}}}},
+			expectedError: "failed to explode object db, err: field password not found",
+		},
+		{
+			name:          "invalid field file path",
+			files:         []*v1alpha1.File{{Path: "db", Contents: []byte(`{"..":"admin"}`)}},
+			explodes:      map[string]*secretsstorev1.ObjectExplode{"db": {}},
+			expectedError: "invalid path: must not contain '..': db/..",
+		},
+		{
+			name: "conflicting field file path",
+			files: []*v1alpha1.File{
+				{Path: "db", Contents: []byte(`{"username":"admin"}`)},
+				{Path: "db/username", Contents: []byte("admin")},
+			},
+			explodes:      map[string]*secretsstorev1.ObjectExplode{"db": {}},
+			expectedError: "file db/username is written more than once",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := explodeFiles(test.files, test.explodes)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(files, test.expectedFiles) {
+				t.Fatalf("expected files %v, got %v", test.expectedFiles, files)
+			}
+		})
+	}
+}
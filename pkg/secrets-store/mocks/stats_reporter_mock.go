@@ -21,12 +21,16 @@ import (
 )
 
 type FakeReporter struct {
-	reportNodePublishCtMetricInvoked        int
-	reportNodeUnPublishCtMetricInvoked      int
-	reportNodePublishErrorCtMetricInvoked   int
-	reportNodeUnPublishErrorCtMetricInvoked int
-	reportSyncK8SecretCtMetricInvoked       int
-	reportSyncK8SecretDurationInvoked       int
+	reportNodePublishCtMetricInvoked          int
+	reportNodeUnPublishCtMetricInvoked        int
+	reportNodePublishErrorCtMetricInvoked     int
+	reportNodeUnPublishErrorCtMetricInvoked   int
+	reportSyncK8SecretCtMetricInvoked         int
+	reportSyncK8SecretDurationInvoked         int
+	reportContentCacheFallbackCtMetricInvoked int
+	reportProviderHealthInvoked               int
+	reportPeerVerificationFailureInvoked      int
+	providerAvailable                         map[string]bool
 }
 
 func NewFakeReporter() *FakeReporter {
@@ -84,3 +88,40 @@ func (f *FakeReporter) ReportRotationErrorCtMetric(ctx context.Context, provider
 
 func (f *FakeReporter) ReportRotationDuration(ctx context.Context, duration float64) {
 }
+
+func (f *FakeReporter) ReportContentCacheFallbackCtMetric(ctx context.Context, provider string) {
+	f.reportContentCacheFallbackCtMetricInvoked++
+}
+
+func (f *FakeReporter) ReportContentCacheFallbackCtMetricInvoked() int {
+	return f.reportContentCacheFallbackCtMetricInvoked
+}
+
+func (f *FakeReporter) ReportProviderHealth(ctx context.Context, provider string, healthy bool, consecutiveFailures int) {
+	f.reportProviderHealthInvoked++
+}
+
+func (f *FakeReporter) ReportProviderHealthInvoked() int {
+	return f.reportProviderHealthInvoked
+}
+
+func (f *FakeReporter) ReportProviderAvailable(ctx context.Context, provider string, available bool) {
+	if f.providerAvailable == nil {
+		f.providerAvailable = make(map[string]bool)
+	}
+	f.providerAvailable[provider] = available
+}
+
+// ProviderAvailable returns the last reported availability of the provider
+func (f *FakeReporter) ProviderAvailable(provider string) (available, reported bool) {
+	available, reported = f.providerAvailable[provider]
+	return available, reported
+}
+
+func (f *FakeReporter) ReportProviderPeerVerificationFailure(ctx context.Context, provider string) {
+	f.reportPeerVerificationFailureInvoked++
+}
+
+func (f *FakeReporter) ReportProviderPeerVerificationFailureInvoked() int {
+	return f.reportPeerVerificationFailureInvoked
+}
@@ -26,11 +26,22 @@ import (
 	"path/filepath"
 	"time"
 
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/certmonitor"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/contentcache"
 	internalerrors "sigs.k8s.io/secrets-store-csi-driver/pkg/errors"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	mount "k8s.io/mount-utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,6 +57,14 @@ type nodeServer struct {
 	reader          client.Reader
 	providerClients *PluginClientBuilder
 	rotationConfig  *rotationConfig
+	// contentCache caches the last successful mount response for a secret provider class.
+	// It's nil if the content cache is disabled.
+	contentCache *contentcache.Cache
+	// certMonitor tracks the expiry of the certificates in the mounted content.
+	// It's nil if the certificate expiry monitor is disabled.
+	certMonitor *certmonitor.Monitor
+	// recorder records the events of the mounts on the pods
+	recorder record.EventRecorder
 }
 
 const (
@@ -60,8 +79,13 @@ const (
 	csiPodUID = "csi.storage.k8s.io/pod.uid"
 	// csiPodServiceAccountTokens is the service account tokens of the pod that the mount is created for
 	csiPodServiceAccountTokens = "csi.storage.k8s.io/serviceAccount.tokens" //nolint
+	// csiPodServiceAccountName is the service account name of the pod that the mount is created for
+	csiPodServiceAccountName = "csi.storage.k8s.io/serviceAccount.name"
 
 	secretProviderClassField = "secretProviderClass"
+	// selectedObjectsField is the volume attribute with the comma separated names of the
+	// objects written to the mount of the volume
+	selectedObjectsField = "selectedObjects"
 )
 
 //gocyclo:ignore
@@ -70,8 +94,10 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	var parameters map[string]string
 	var providerName string
 	var podName, podNamespace, podUID string
-	var targetPath string
-	var mounted, isRemountRequest, skipped, isErrorMasked bool
+	var targetPath, secretProviderClass string
+	var mounted, isRemountRequest, skipped, isErrorMasked, recordStatus, providerCalled bool
+	// maskedErr holds the error that was masked for remount requests
+	var maskedErr error
 	errorReason := internalerrors.FailedToMount
 	rotationEnabled := ns.rotationConfig.enabled
 
@@ -91,6 +117,13 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 			if isRemountRequest && !skipped {
 				ns.reporter.ReportRotationErrorCtMetric(ctx, providerName, errorReason, true)
 			}
+			if recordStatus {
+				mountErr := err
+				if isErrorMasked {
+					mountErr = maskedErr
+				}
+				ns.recordMountFailure(ctx, podName, podNamespace, podUID, secretProviderClass, targetPath, isRemountRequest, providerCalled, errorReason, mountErr)
+			}
 			return
 		}
 		if isRemountRequest && !skipped {
@@ -120,7 +153,7 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	mountFlags := req.GetVolumeCapability().GetMount().GetMountFlags()
 	secrets := req.GetSecrets()
 
-	secretProviderClass := attrib[secretProviderClassField]
+	secretProviderClass = attrib[secretProviderClassField]
 	providerName = attrib["providerName"]
 	podName = attrib[csiPodName]
 	podNamespace = attrib[csiPodNamespace]
@@ -180,6 +213,9 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	if secretProviderClass == "" {
 		return nil, fmt.Errorf("secretProviderClass is not set")
 	}
+	// from this point on, failures are recorded in the secret provider class pod status
+	// so the reason for a pod stuck in ContainerCreating is visible in the API
+	recordStatus = podName != "" && podNamespace != "" && podUID != ""
 
 	spc, err := getSecretProviderItem(ctx, ns.client, secretProviderClass, podNamespace)
 	if err != nil {
@@ -195,6 +231,23 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	if err != nil {
 		return nil, err
 	}
+	opts := fileOptions{
+		encodings:       getObjectEncodingsFromSPC(spc),
+		explodes:        getObjectExplodesFromSPC(spc),
+		files:           spc.Spec.Files,
+		selectedObjects: getSelectedObjects(attrib),
+		derivedFiles:    spc.Spec.DerivedFiles,
+	}
+	// the templates are read on every mount and rotation. The configmap isn't watched, the
+	// changes are rendered to the mounted files on the next rotation.
+	if opts.templates, err = getFileTemplates(ctx, ns.reader, spc); err != nil {
+		if apierrors.IsNotFound(err) {
+			errorReason = internalerrors.TemplateConfigMapNotFound
+		}
+		return nil, err
+	}
+	// the cache key is computed before the volume attributes are added to the parameters
+	cacheKey := contentCacheKey(spc, attrib[csiPodServiceAccountName], secrets, opts)
 	// send all the volume attributes sent from kubelet to the provider
 	maps.Copy(parameters, attrib)
 
@@ -240,22 +293,50 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		}
 	}
 	mounted = true
+	providerCalled = true
 	var objectVersions map[string]string
-	if objectVersions, errorReason, err = ns.mountSecretsStoreObjectContent(ctx, providerName, string(parametersStr), string(secretStr), targetPath, string(permissionStr), podName); err != nil {
+	var files []*v1alpha1.File
+	// cachedEntry is set if the content was mounted from the content cache
+	var cachedEntry *contentcache.Entry
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: podNamespace, UID: types.UID(podUID)}}
+	if objectVersions, files, errorReason, err = ns.mountSecretsStoreObjectContent(ctx, providerName, string(parametersStr), string(secretStr), targetPath, string(permissionStr), pod, getRequiredProviderCapabilitiesFromSPC(spc), opts); err != nil {
 		klog.ErrorS(err, "failed to mount secrets store object content", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName}, "isRemountRequest", isRemountRequest)
 		if isRemountRequest {
 			// Mask error until fix available for https://github.com/kubernetes/kubernetes/issues/121271
 			isErrorMasked = true
+			maskedErr = err
 			return &csi.NodePublishVolumeResponse{}, nil
 		}
-		return nil, fmt.Errorf("failed to mount secrets store objects for pod %s/%s, err: %w", podNamespace, podName, err)
+		if cachedEntry = ns.mountCachedContent(cacheKey, targetPath, errorReason, podName, podNamespace); cachedEntry == nil {
+			return nil, fmt.Errorf("failed to mount secrets store objects for pod %s/%s, err: %w", podNamespace, podName, err)
+		}
+		ns.reporter.ReportContentCacheFallbackCtMetric(ctx, providerName)
+	} else if ns.contentCache != nil && len(files) > 0 {
+		if cacheErr := ns.contentCache.Store(cacheKey, files, objectVersions); cacheErr != nil {
+			klog.ErrorS(cacheErr, "failed to store mount response in content cache", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName})
+		}
 	}
 
 	// create or update the secret provider class pod status object
 	// SPCPS is created the first time after the pod mount is complete. Update is required in scenarios where
 	// the pod with same name (pods created by statefulsets) is moved to a different node and the old SPCPS
 	// has not yet been garbage collected.
-	if err = createOrUpdateSecretProviderClassPodStatus(ctx, ns.client, ns.reader, podName, podNamespace, podUID, secretProviderClass, targetPath, ns.nodeID, true, objectVersions); err != nil {
+	conditions := []metav1.Condition{
+		newCondition(secretsstorev1.ConditionTypeMounted, metav1.ConditionTrue, secretsstorev1.ReasonMountSucceeded, "secrets store content mounted"),
+		newCondition(secretsstorev1.ConditionTypeProviderReachable, metav1.ConditionTrue, secretsstorev1.ReasonProviderResponded, fmt.Sprintf("provider %s responded to the mount request", providerName)),
+		newCondition(secretsstorev1.ConditionTypeContentUpToDate, metav1.ConditionTrue, secretsstorev1.ReasonContentUpdated, fmt.Sprintf("%d objects written to the mount", len(objectVersions))),
+	}
+	if cachedEntry != nil {
+		// the pod is running with the cached content, flag it until the provider serves the content again
+		message := fmt.Sprintf("provider failed to serve the content, mounted content cached at %s: %v", cachedEntry.Timestamp.UTC().Format(time.RFC3339), err)
+		conditions[1] = providerReachableCondition(errorReason, err)
+		conditions[2] = newCondition(secretsstorev1.ConditionTypeContentUpToDate, metav1.ConditionFalse, secretsstorev1.ReasonCachedContent, message)
+		objectVersions = cachedEntry.ObjectVersions
+		errorReason, err = "", nil
+	}
+	// the status is being written successfully, so there is no failure left to record
+	recordStatus = false
+	if err = createOrUpdateSecretProviderClassPodStatus(ctx, ns.client, ns.reader, podName, podNamespace, podUID, secretProviderClass, targetPath, ns.nodeID, true, objectVersions, conditions...); err != nil {
 		klog.ErrorS(err, "failed to create/update spcps", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName}, "isRemountRequest", isRemountRequest)
 		if isRemountRequest {
 			// Mask error until fix available for https://github.com/kubernetes/kubernetes/issues/121271
@@ -265,6 +346,11 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		return nil, fmt.Errorf("failed to create secret provider class pod status for pod %s/%s, err: %w", podNamespace, podName, err)
 	}
 
+	if cachedEntry != nil {
+		files = cachedEntry.ProtoFiles()
+	}
+	ns.certMonitor.TrackVolume(targetPath, pod, spc, fileContents(files))
+
 	klog.InfoS("node publish volume complete", "targetPath", targetPath, "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName}, "time", time.Since(startTime))
 	return &csi.NodePublishVolumeResponse{}, nil
 }
@@ -311,6 +397,8 @@ func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	ns.certMonitor.UntrackVolume(targetPath)
+
 	klog.InfoS("node unpublish volume complete", "targetPath", targetPath, "time", time.Since(startTime))
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
@@ -342,25 +430,172 @@ func (ns *nodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
-func (ns *nodeServer) mountSecretsStoreObjectContent(ctx context.Context, providerName, attributes, secrets, targetPath, permission, podName string) (map[string]string, string, error) {
+func (ns *nodeServer) mountSecretsStoreObjectContent(ctx context.Context, providerName, attributes, secrets, targetPath, permission string, pod *corev1.Pod, requiredCapabilities []string, opts fileOptions) (map[string]string, []*v1alpha1.File, string, error) {
 	if len(attributes) == 0 {
-		return nil, "", errors.New("missing attributes")
+		return nil, nil, "", errors.New("missing attributes")
 	}
 	if len(targetPath) == 0 {
-		return nil, "", errors.New("missing target path")
+		return nil, nil, "", errors.New("missing target path")
 	}
 	if len(permission) == 0 {
-		return nil, "", errors.New("missing file permissions")
+		return nil, nil, "", errors.New("missing file permissions")
+	}
+
+	// fail fast if the provider health check is failing
+	if err := ns.providerClients.Available(providerName); err != nil {
+		return nil, nil, internalerrors.GRPCProviderError, err
 	}
 
 	client, err := ns.providerClients.Get(ctx, providerName)
 	if err != nil {
-		return nil, "", fmt.Errorf("error connecting to provider %q: %w", providerName, err)
+		return nil, nil, internalerrors.FailedToLookupProviderGRPCClient, fmt.Errorf("error connecting to provider %q: %w", providerName, err)
 	}
 
-	klog.InfoS("Using gRPC client", "provider", providerName, "pod", podName)
+	// verify the provider identity before the pod's secrets and tokens are sent to it
+	if identity, err := ns.providerClients.VerifyPeer(ctx, providerName); err != nil {
+		if errors.Is(err, providerregistry.ErrPeerVerificationFailed) {
+			klog.ErrorS(err, "audit: refusing to mount, provider peer verification failed", "provider", providerName, "pod", klog.KObj(pod), "peer", identity)
+			ns.reporter.ReportProviderPeerVerificationFailure(ctx, providerName)
+			if pod.Name != "" && pod.Namespace != "" {
+				ns.recorder.Eventf(pod, corev1.EventTypeWarning, internalerrors.ProviderPeerVerificationFailed, "refused to mount, the identity of provider %q doesn't match the provider registry: %v", providerName, err)
+			}
+			return nil, nil, internalerrors.ProviderPeerVerificationFailed, err
+		}
+		return nil, nil, internalerrors.GRPCProviderError, fmt.Errorf("failed to verify provider %q: %w", providerName, err)
+	}
 
-	return MountContent(ctx, client, attributes, secrets, targetPath, permission, nil)
+	info, err := ns.providerClients.Negotiate(ctx, providerName)
+	if err != nil {
+		if errors.Is(err, errIncompatibleProvider) {
+			return nil, nil, internalerrors.IncompatibleProviderVersion, err
+		}
+		return nil, nil, internalerrors.GRPCProviderError, fmt.Errorf("failed to get version of provider %q: %w", providerName, err)
+	}
+	if missing := info.MissingCapabilities(requiredCapabilities); len(missing) > 0 {
+		return nil, nil, internalerrors.IncompatibleProviderVersion, fmt.Errorf("%w: provider %q %s doesn't support the capabilities %v required by the secret provider class", errIncompatibleProvider, providerName, info.RuntimeVersion, missing)
+	}
+
+	klog.InfoS("Using gRPC client", "provider", providerName, "pod", klog.KObj(pod))
+
+	return mountContent(ctx, client, info.Capabilities.Has(v1alpha1.CapabilityMountStream), attributes, secrets, targetPath, permission, nil, opts)
+}
+
+// mountCachedContent writes the content of the last successful mount for the secret provider
+// class to the target path if the provider failed to serve the content. It returns nil if the
+// content cache is disabled or there is no usable cache entry.
+func (ns *nodeServer) mountCachedContent(cacheKey, targetPath, errorReason, podName, podNamespace string) *contentcache.Entry {
+	if ns.contentCache == nil || errorReason != internalerrors.GRPCProviderError {
+		return nil
+	}
+	entry, err := ns.contentCache.Load(cacheKey)
+	if err != nil {
+		if !errors.Is(err, contentcache.ErrNotFound) {
+			klog.ErrorS(err, "failed to load content from content cache", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName})
+		}
+		return nil
+	}
+	if err := fileutil.WritePayloads(targetPath, entry.ProtoFiles()); err != nil {
+		klog.ErrorS(err, "failed to write cached content", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName})
+		return nil
+	}
+	klog.InfoS("mounted cached content as the provider failed", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName}, "cachedAt", entry.Timestamp)
+	return entry
+}
+
+// recordMountFailure records a failed mount request in the secret provider class pod status.
+// For the first mount request the status is created with mounted=false. For remount requests
+// the content from the previous mount is still available, so only the conditions are updated.
+func (ns *nodeServer) recordMountFailure(ctx context.Context, podName, podNamespace, podUID, spcName, targetPath string, isRemountRequest, providerCalled bool, errorReason string, mountErr error) {
+	if mountErr == nil {
+		return
+	}
+	if errorReason == "" {
+		errorReason = internalerrors.FailedToMount
+	}
+	// the request context could already be canceled by the kubelet, but the failure should still be recorded
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	message := mountErr.Error()
+	conditions := []metav1.Condition{
+		newCondition(secretsstorev1.ConditionTypeContentUpToDate, metav1.ConditionFalse, errorReason, message),
+	}
+	if providerCalled {
+		conditions = append(conditions, providerReachableCondition(errorReason, mountErr))
+	}
+
+	var err error
+	if isRemountRequest {
+		err = updateSecretProviderClassPodStatusConditions(ctx, ns.client, ns.reader, secretProviderClassPodStatusName(podName, podNamespace, spcName), podNamespace, conditions...)
+	} else {
+		conditions = append(conditions, newCondition(secretsstorev1.ConditionTypeMounted, metav1.ConditionFalse, errorReason, message))
+		err = createOrUpdateSecretProviderClassPodStatus(ctx, ns.client, ns.reader, podName, podNamespace, podUID, spcName, targetPath, ns.nodeID, false, nil, conditions...)
+	}
+	if err != nil {
+		klog.ErrorS(err, "failed to record mount failure in spcps", "pod", klog.ObjectRef{Namespace: podNamespace, Name: podName}, "isRemountRequest", isRemountRequest)
+	}
+}
+
+// trackMountedVolumes tracks the certificates in the volumes mounted on the node before the
+// driver started. The certificate monitor only tracks the volumes on publish, without it the
+// volumes would only be tracked again on the next rotation.
+func (ns *nodeServer) trackMountedVolumes(ctx context.Context) {
+	if ns.certMonitor == nil {
+		return
+	}
+	spcpsList := &secretsstorev1.SecretProviderClassPodStatusList{}
+	if err := ns.reader.List(ctx, spcpsList, client.MatchingLabels{secretsstorev1.InternalNodeLabel: ns.nodeID}); err != nil {
+		klog.ErrorS(err, "failed to list secret provider class pod status to track mounted certificates")
+		return
+	}
+	for i := range spcpsList.Items {
+		spcps := &spcpsList.Items[i]
+		if !spcps.Status.Mounted {
+			continue
+		}
+		paths, err := fileutil.GetMountedFiles(spcps.Status.TargetPath)
+		if err != nil {
+			klog.ErrorS(err, "failed to get mounted files to track certificates", "spcps", klog.KObj(spcps), "targetPath", spcps.Status.TargetPath)
+			continue
+		}
+		objects := make(map[string][]byte, len(paths))
+		for path, absPath := range paths {
+			if objects[path], err = os.ReadFile(absPath); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			klog.ErrorS(err, "failed to read mounted files to track certificates", "spcps", klog.KObj(spcps), "targetPath", spcps.Status.TargetPath)
+			continue
+		}
+		spc := &secretsstorev1.SecretProviderClass{}
+		if err := ns.reader.Get(ctx, client.ObjectKey{Namespace: spcps.Namespace, Name: spcps.Status.SecretProviderClassName}, spc); err != nil {
+			klog.ErrorS(err, "failed to get secret provider class to track mounted certificates", "spcps", klog.KObj(spcps))
+			continue
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: spcps.Status.PodName, Namespace: spcps.Namespace}}
+		for _, ref := range spcps.OwnerReferences {
+			if ref.Kind == "Pod" && ref.Name == spcps.Status.PodName {
+				pod.UID = ref.UID
+			}
+		}
+		ns.certMonitor.TrackVolume(spcps.Status.TargetPath, pod, spc, objects)
+	}
+}
+
+// providerReachableCondition returns the ProviderReachable condition for a failed mount request
+func providerReachableCondition(errorReason string, mountErr error) metav1.Condition {
+	unreachable := errorReason == internalerrors.FailedToLookupProviderGRPCClient || errorReason == internalerrors.ProviderPeerVerificationFailed
+	if errorReason == internalerrors.GRPCProviderError {
+		switch status.Code(mountErr) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+			unreachable = true
+		}
+	}
+	if unreachable {
+		return newCondition(secretsstorev1.ConditionTypeProviderReachable, metav1.ConditionFalse, errorReason, mountErr.Error())
+	}
+	return newCondition(secretsstorev1.ConditionTypeProviderReachable, metav1.ConditionTrue, secretsstorev1.ReasonProviderResponded, "provider responded to the mount request")
 }
 
 func (ns *nodeServer) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	internalerrors "sigs.k8s.io/secrets-store-csi-driver/pkg/errors"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/secrets-store/mocks"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMountSecretsStoreObjectContent_PeerVerificationFailed(t *testing.T) {
+	s, err := setupScheme()
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	reporter := mocks.NewFakeReporter()
+	ns, err := testNodeServer(t, fake.NewClientBuilder().WithScheme(s).Build(), reporter, &rotationConfig{})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	// the provider socket is owned by the test user, expect another user
+	socketPath := filepath.Join(ns.providerClients.socketPaths[0], "provider1.sock")
+	otherUID := int64(os.Getuid() + 1)
+	ns.providerClients.SetRegistry(&providerregistry.Config{
+		APIVersion: providerregistry.APIVersion,
+		Kind:       providerregistry.Kind,
+		Providers: []providerregistry.Provider{
+			{Name: "provider1", Endpoint: "unix://" + socketPath, Peer: &providerregistry.Peer{UID: &otherUID}},
+		},
+	})
+
+	_, _, errorReason, err := ns.mountSecretsStoreObjectContent(context.TODO(), "provider1", "{}", "{}", targetPath(t), "420", &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}, nil, fileOptions{})
+	if !errors.Is(err, providerregistry.ErrPeerVerificationFailed) {
+		t.Fatalf("expected peer verification error, got: %v", err)
+	}
+	if errorReason != internalerrors.ProviderPeerVerificationFailed {
+		t.Errorf("expected error reason %s, got: %s", internalerrors.ProviderPeerVerificationFailed, errorReason)
+	}
+	if reporter.ReportProviderPeerVerificationFailureInvoked() != 1 {
+		t.Errorf("expected the peer verification failure to be reported")
+	}
+	events := ns.recorder.(*record.FakeRecorder).Events
+	select {
+	case event := <-events:
+		if !strings.HasPrefix(event, "Warning "+internalerrors.ProviderPeerVerificationFailed) {
+			t.Errorf("expected a %s warning event, got: %s", internalerrors.ProviderPeerVerificationFailed, event)
+		}
+	default:
+		t.Errorf("expected a %s event to be recorded on the pod", internalerrors.ProviderPeerVerificationFailed)
+	}
+}
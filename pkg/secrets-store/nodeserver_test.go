@@ -18,24 +18,36 @@ package secretsstore
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/certmonitor"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/contentcache"
+	internalerrors "sigs.k8s.io/secrets-store-csi-driver/pkg/errors"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/secrets-store/mocks"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
 	providerfake "sigs.k8s.io/secrets-store-csi-driver/provider/fake"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	mount "k8s.io/mount-utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func testNodeServer(t *testing.T, client client.Client, reporter StatsReporter, rotationConfig *rotationConfig) (*nodeServer, error) {
@@ -54,7 +66,7 @@ func testNodeServer(t *testing.T, client client.Client, reporter StatsReporter,
 	t.Cleanup(server.Stop)
 
 	providerClients := NewPluginClientBuilder([]string{socketPath})
-	return newNodeServer("testnode", mount.NewFakeMounter([]mount.MountPoint{}), providerClients, client, client, reporter, rotationConfig)
+	return newNodeServer("testnode", mount.NewFakeMounter([]mount.MountPoint{}), providerClients, client, client, reporter, rotationConfig, nil, nil, record.NewFakeRecorder(10))
 }
 
 func TestNodePublishVolume_Errors(t *testing.T) {
@@ -215,6 +227,64 @@ func TestNodePublishVolume_Errors(t *testing.T) {
 			},
 			want: codes.Unknown,
 		},
+		{
+			name: "provider doesn't support required capabilities",
+			nodePublishVolReq: &csi.NodePublishVolumeRequest{
+				VolumeCapability: &csi.VolumeCapability{},
+				VolumeId:         "testvolid1",
+				TargetPath:       targetPath(t),
+				VolumeContext: map[string]string{
+					"secretProviderClass": "provider1",
+					csiPodName:            "pod1",
+					csiPodNamespace:       "default",
+					csiPodUID:             "poduid1",
+				},
+				Readonly: true,
+			},
+			initObjects: []client.Object{
+				&secretsstorev1.SecretProviderClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "provider1",
+						Namespace: "default",
+					},
+					Spec: secretsstorev1.SecretProviderClassSpec{
+						Provider:                     "provider1",
+						Parameters:                   map[string]string{"parameter1": "value1"},
+						RequiredProviderCapabilities: []secretsstorev1.ProviderCapability{secretsstorev1.ProviderCapabilityMountStream},
+					},
+				},
+			},
+			want: codes.Unknown,
+		},
+		{
+			name: "template configmap not found",
+			nodePublishVolReq: &csi.NodePublishVolumeRequest{
+				VolumeCapability: &csi.VolumeCapability{},
+				VolumeId:         "testvolid1",
+				TargetPath:       targetPath(t),
+				VolumeContext: map[string]string{
+					"secretProviderClass": "provider1",
+					csiPodName:            "pod1",
+					csiPodNamespace:       "default",
+					csiPodUID:             "poduid1",
+				},
+				Readonly: true,
+			},
+			initObjects: []client.Object{
+				&secretsstorev1.SecretProviderClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "provider1",
+						Namespace: "default",
+					},
+					Spec: secretsstorev1.SecretProviderClassSpec{
+						Provider:          "provider1",
+						Parameters:        map[string]string{"parameter1": "value1"},
+						TemplateConfigMap: &secretsstorev1.TemplateConfigMap{Name: "templates"},
+					},
+				},
+			},
+			want: codes.Unknown,
+		},
 	}
 
 	s := scheme.Scheme
@@ -419,6 +489,249 @@ func TestNodePublishVolume(t *testing.T) {
 	}
 }
 
+func TestNodePublishVolume_RecordsFailure(t *testing.T) {
+	s, err := setupScheme()
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	spc := &secretsstorev1.SecretProviderClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spc1",
+			Namespace: "default",
+		},
+		Spec: secretsstorev1.SecretProviderClassSpec{
+			Provider:   "provider_not_installed",
+			Parameters: map[string]string{"parameter1": "value1"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(spc).Build()
+
+	ns, err := testNodeServer(t, c, mocks.NewFakeReporter(), &rotationConfig{})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	req := &csi.NodePublishVolumeRequest{
+		VolumeCapability: &csi.VolumeCapability{},
+		VolumeId:         "testvolid1",
+		TargetPath:       targetPath(t),
+		VolumeContext: map[string]string{
+			"secretProviderClass": "spc1",
+			csiPodName:            "pod1",
+			csiPodNamespace:       "default",
+			csiPodUID:             "poduid1",
+		},
+		Readonly: true,
+	}
+	if _, err = ns.NodePublishVolume(context.TODO(), req); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+
+	spcps := &secretsstorev1.SecretProviderClassPodStatus{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "pod1-default-spc1", Namespace: "default"}, spcps); err != nil {
+		t.Fatalf("expected spcps to be created for failed mount, got: %v", err)
+	}
+	if spcps.Status.Mounted {
+		t.Errorf("expected Status.Mounted to be false")
+	}
+	for _, conditionType := range []string{secretsstorev1.ConditionTypeMounted, secretsstorev1.ConditionTypeProviderReachable, secretsstorev1.ConditionTypeContentUpToDate} {
+		condition := meta.FindStatusCondition(spcps.Status.Conditions, conditionType)
+		if condition == nil {
+			t.Fatalf("expected condition %s to be set, got: %v", conditionType, spcps.Status.Conditions)
+		}
+		if condition.Status != metav1.ConditionFalse || condition.Reason != internalerrors.FailedToLookupProviderGRPCClient {
+			t.Errorf("condition %s got: %s/%s, want: %s/%s", conditionType, condition.Status, condition.Reason, metav1.ConditionFalse, internalerrors.FailedToLookupProviderGRPCClient)
+		}
+	}
+}
+
+func TestNodePublishVolume_TemplateErrors(t *testing.T) {
+	s, err := setupScheme()
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	spc := &secretsstorev1.SecretProviderClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spc1",
+			Namespace: "default",
+		},
+		Spec: secretsstorev1.SecretProviderClassSpec{
+			Provider:          "provider1",
+			Parameters:        map[string]string{"parameter1": "value1"},
+			TemplateConfigMap: &secretsstorev1.TemplateConfigMap{Name: "templates"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		rotation       bool
+		getErr         error
+		expectedReason string
+	}{
+		{
+			name:           "configmap not found",
+			rotation:       true,
+			expectedReason: internalerrors.TemplateConfigMapNotFound,
+		},
+		{
+			name:           "configmap not found without rotation",
+			expectedReason: internalerrors.TemplateConfigMapNotFound,
+		},
+		{
+			name:           "configmap forbidden",
+			rotation:       true,
+			getErr:         apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "templates", errors.New("forbidden")),
+			expectedReason: internalerrors.FailedToMount,
+		},
+		{
+			name:           "configmap get timeout",
+			rotation:       true,
+			getErr:         apierrors.NewTimeoutError("request timed out", 1),
+			expectedReason: internalerrors.FailedToMount,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(s).WithObjects(spc).Build(), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*corev1.ConfigMap); ok && test.getErr != nil {
+						return test.getErr
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+			})
+			ns, err := testNodeServer(t, c, mocks.NewFakeReporter(), &rotationConfig{enabled: test.rotation})
+			if err != nil {
+				t.Fatalf("expected error to be nil, got: %+v", err)
+			}
+
+			req := &csi.NodePublishVolumeRequest{
+				VolumeCapability: &csi.VolumeCapability{},
+				VolumeId:         "testvolid1",
+				TargetPath:       targetPath(t),
+				VolumeContext: map[string]string{
+					"secretProviderClass": "spc1",
+					csiPodName:            "pod1",
+					csiPodNamespace:       "default",
+					csiPodUID:             "poduid1",
+				},
+				Readonly: true,
+			}
+			if _, err = ns.NodePublishVolume(context.TODO(), req); err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			spcps := &secretsstorev1.SecretProviderClassPodStatus{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: "pod1-default-spc1", Namespace: "default"}, spcps); err != nil {
+				t.Fatalf("expected spcps to be created for failed mount, got: %v", err)
+			}
+			condition := meta.FindStatusCondition(spcps.Status.Conditions, secretsstorev1.ConditionTypeContentUpToDate)
+			if condition == nil || condition.Reason != test.expectedReason {
+				t.Errorf("expected ContentUpToDate condition with reason %s, got: %v", test.expectedReason, condition)
+			}
+		})
+	}
+}
+
+func TestNodePublishVolume_ContentCacheFallback(t *testing.T) {
+	s, err := setupScheme()
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	spc := &secretsstorev1.SecretProviderClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spc1",
+			Namespace: "default",
+		},
+		Spec: secretsstorev1.SecretProviderClassSpec{
+			Provider:   "provider1",
+			Parameters: map[string]string{"parameter1": "value1"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(spc).Build()
+
+	socketPath := t.TempDir()
+	server, err := providerfake.NewMocKCSIProviderServer(filepath.Join(socketPath, "provider1.sock"))
+	if err != nil {
+		t.Fatalf("unexpected mock provider failure: %v", err)
+	}
+	server.SetObjects(map[string]string{"secret/object1": "v1"})
+	server.SetFiles([]*v1alpha1.File{{Path: "object1", Mode: 0644, Contents: []byte("secret")}})
+	if err := server.Start(); err != nil {
+		t.Fatalf("unexpected mock provider start failure: %v", err)
+	}
+	t.Cleanup(server.Stop)
+
+	cacheDir := t.TempDir()
+	contentCache, err := contentcache.New(cacheDir, filepath.Join(t.TempDir(), "key"), time.Hour)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	reporter := mocks.NewFakeReporter()
+	ns, err := newNodeServer("testnode", mount.NewFakeMounter([]mount.MountPoint{}), NewPluginClientBuilder([]string{socketPath}), c, c, reporter, &rotationConfig{}, contentCache, nil, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	newRequest := func(podName string) *csi.NodePublishVolumeRequest {
+		return &csi.NodePublishVolumeRequest{
+			VolumeCapability: &csi.VolumeCapability{},
+			VolumeId:         "testvolid1",
+			TargetPath:       targetPath(t),
+			VolumeContext: map[string]string{
+				"secretProviderClass":    "spc1",
+				csiPodName:               podName,
+				csiPodNamespace:          "default",
+				csiPodUID:                podName + "-uid",
+				csiPodServiceAccountName: "sa1",
+			},
+			Readonly: true,
+		}
+	}
+
+	// the first mount succeeds and stores the content in the cache
+	if _, err = ns.NodePublishVolume(context.TODO(), newRequest("pod1")); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	// the provider is down, the second pod is mounted with the cached content
+	server.SetReturnError(status.Error(codes.Internal, "external store unavailable"))
+	req := newRequest("pod2")
+	if _, err = ns.NodePublishVolume(context.TODO(), req); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	contents, err := os.ReadFile(filepath.Join(req.GetTargetPath(), "object1"))
+	if err != nil || string(contents) != "secret" {
+		t.Fatalf("expected cached content to be mounted, got: %q, err: %v", contents, err)
+	}
+	if got := reporter.ReportContentCacheFallbackCtMetricInvoked(); got != 1 {
+		t.Errorf("expected content cache fallback metric to be reported once, got: %d", got)
+	}
+
+	spcps := &secretsstorev1.SecretProviderClassPodStatus{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "pod2-default-spc1", Namespace: "default"}, spcps); err != nil {
+		t.Fatalf("expected spcps to be created, got: %v", err)
+	}
+	if !spcps.Status.Mounted {
+		t.Errorf("expected Status.Mounted to be true")
+	}
+	if len(spcps.Status.Objects) != 1 || spcps.Status.Objects[0].Version != "v1" {
+		t.Errorf("expected cached object versions, got: %v", spcps.Status.Objects)
+	}
+	condition := meta.FindStatusCondition(spcps.Status.Conditions, secretsstorev1.ConditionTypeContentUpToDate)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != secretsstorev1.ReasonCachedContent {
+		t.Errorf("expected ContentUpToDate condition to be False with reason %s, got: %v", secretsstorev1.ReasonCachedContent, condition)
+	}
+
+	// a pod with a different service account doesn't get the cached content
+	req = newRequest("pod3")
+	req.VolumeContext[csiPodServiceAccountName] = "sa2"
+	if _, err = ns.NodePublishVolume(context.TODO(), req); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
 func TestNodeUnpublishVolume(t *testing.T) {
 	s := scheme.Scheme
 	s.AddKnownTypes(schema.GroupVersion{Group: secretsstorev1.GroupVersion.Group, Version: secretsstorev1.GroupVersion.Version},
@@ -533,6 +846,66 @@ func TestNodeUnpublishVolume_Error(t *testing.T) {
 }
 
 // targetPath returns a tmp file path that looks like a valid target path.
+func TestTrackMountedVolumes(t *testing.T) {
+	s, err := setupScheme()
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	// the volume was mounted before the driver started
+	cert, _ := newTestKeyPair(t, "mounted")
+	mountedPath := targetPath(t)
+	if err := fileutil.WritePayloads(mountedPath, []*v1alpha1.File{{Path: "tls.crt", Mode: 0644, Contents: cert}}); err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	spcps := func(name string, mounted bool) *secretsstorev1.SecretProviderClassPodStatus {
+		return &secretsstorev1.SecretProviderClassPodStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name + "-default-spc1",
+				Namespace: "default",
+				Labels:    map[string]string{secretsstorev1.InternalNodeLabel: "testnode"},
+			},
+			Status: secretsstorev1.SecretProviderClassPodStatusStatus{
+				PodName:                 name,
+				SecretProviderClassName: "spc1",
+				TargetPath:              mountedPath,
+				Mounted:                 mounted,
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&secretsstorev1.SecretProviderClass{ObjectMeta: metav1.ObjectMeta{Name: "spc1", Namespace: "default"}},
+		spcps("pod1", true),
+		spcps("pod2", false),
+	).Build()
+
+	recorder := record.NewFakeRecorder(10)
+	monitor, err := certmonitor.New(24*time.Hour, recorder)
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+	ns, err := newNodeServer("testnode", mount.NewFakeMounter([]mount.MountPoint{}), NewPluginClientBuilder([]string{t.TempDir()}), c, c, mocks.NewFakeReporter(), &rotationConfig{}, nil, monitor, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("expected error to be nil, got: %+v", err)
+	}
+
+	ns.trackMountedVolumes(context.TODO())
+
+	// the certificate that expires within the warning window is reported for the mounted pod
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected events on the pod and secret provider class, got: %v", events)
+	}
+	for _, event := range events {
+		if !strings.Contains(event, certmonitor.CertificateExpiringReason) || !strings.Contains(event, "pod1") {
+			t.Errorf("expected %s event for pod1, got: %s", certmonitor.CertificateExpiringReason, event)
+		}
+	}
+}
+
 func targetPath(t *testing.T) string {
 	uid := "fake-uid"
 	vol := "spc-volume"
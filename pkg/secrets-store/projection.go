@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"fmt"
+	"strings"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+// projectFiles moves the files of the objects returned by the provider to the paths
+// declared in the files of the secret provider class, with their modes, and drops the
+// files of the objects that aren't listed, or aren't selected by the volume. The files
+// of an exploded object follow the object to its path. The files are left as is if
+// neither files nor selected objects are set.
+func projectFiles(objects, files []*v1alpha1.File, projections []secretsstorev1.ObjectFile, selectedObjects []string) ([]*v1alpha1.File, error) {
+	if len(projections) == 0 && len(selectedObjects) == 0 {
+		return files, nil
+	}
+	returned := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		returned[object.GetPath()] = true
+	}
+
+	// the projections of the objects written to the mount, by object name
+	written := make(map[string]secretsstorev1.ObjectFile)
+	optional := make(map[string]bool)
+	if len(projections) == 0 {
+		for name := range returned {
+			written[name] = secretsstorev1.ObjectFile{ObjectName: name}
+		}
+	}
+	for _, projection := range projections {
+		if !returned[projection.ObjectName] {
+			if projection.Optional {
+				optional[projection.ObjectName] = true
+				continue
+			}
+			return nil, fmt.Errorf("object %s not found in the mount response", projection.ObjectName)
+		}
+		written[projection.ObjectName] = projection
+	}
+	if len(selectedObjects) > 0 {
+		selected := make(map[string]secretsstorev1.ObjectFile, len(selectedObjects))
+		for _, name := range selectedObjects {
+			projection, ok := written[name]
+			if !ok {
+				if optional[name] {
+					continue
+				}
+				return nil, fmt.Errorf("selected object %s not found in the files of the mount", name)
+			}
+			selected[name] = projection
+		}
+		written = selected
+	}
+
+	projected := make([]*v1alpha1.File, 0, len(files))
+	for _, file := range files {
+		name := objectName(file.GetPath(), returned)
+		projection, ok := written[name]
+		if !ok {
+			continue
+		}
+		path := name
+		if len(projection.Path) > 0 {
+			path = projection.Path
+		}
+		mode := file.GetMode()
+		// the modes of the files of exploded objects are set with explode
+		if projection.Mode != nil && file.GetPath() == name {
+			mode = *projection.Mode
+		}
+		projected = append(projected, &v1alpha1.File{Path: path + strings.TrimPrefix(file.GetPath(), name), Mode: mode, Contents: file.GetContents()})
+	}
+	return projected, nil
+}
+
+// objectName returns the name of the object of the file, the path of the file or of
+// the directory of an exploded object
+func objectName(path string, objects map[string]bool) string {
+	name := path
+	for !objects[name] {
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return path
+		}
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+)
+
+func TestProjectFiles(t *testing.T) {
+	objects := []*v1alpha1.File{
+		{Path: "username", Mode: 0644, Contents: []byte("admin")},
+		{Path: "password", Mode: 0644, Contents: []byte("secret")},
+		{Path: "db", Mode: 0644, Contents: []byte(`{"host":"localhost"}`)},
+	}
+	// the db object is exploded
+	files := []*v1alpha1.File{
+		objects[0],
+		objects[1],
+		{Path: "db/host", Mode: 0440, Contents: []byte("localhost")},
+	}
+
+	tests := []struct {
+		name            string
+		projections     []secretsstorev1.ObjectFile
+		selectedObjects []string
+		expectedFiles   []*v1alpha1.File
+		expectedError   string
+	}{
+		{
+			name:          "no projection",
+			expectedFiles: files,
+		},
+		{
+			name: "files",
+			projections: []secretsstorev1.ObjectFile{
+				{ObjectName: "password", Path: "credentials/password", Mode: int32Ptr(0400)},
+				{ObjectName: "db", Path: "database", Mode: int32Ptr(0400)},
+				{ObjectName: "api-key", Optional: true},
+			},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "credentials/password", Mode: 0400, Contents: []byte("secret")},
+				{Path: "database/host", Mode: 0440, Contents: []byte("localhost")},
+			},
+		},
+		{
+			name:          "missing object",
+			projections:   []secretsstorev1.ObjectFile{{ObjectName: "api-key"}},
+			expectedError: "object api-key not found in the mount response",
+		},
+		{
+			name:            "selected objects",
+			selectedObjects: []string{"username", "db"},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "username", Mode: 0644, Contents: []byte("admin")},
+				{Path: "db/host", Mode: 0440, Contents: []byte("localhost")},
+			},
+		},
+		{
+			name: "selected files",
+			projections: []secretsstorev1.ObjectFile{
+				{ObjectName: "username", Path: "user"},
+				{ObjectName: "password", Path: "pass"},
+				{ObjectName: "api-key", Optional: true},
+			},
+			selectedObjects: []string{"password", "api-key"},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "pass", Mode: 0644, Contents: []byte("secret")},
+			},
+		},
+		{
+			name:            "selected object not returned",
+			selectedObjects: []string{"api-key"},
+			expectedError:   "selected object api-key not found in the files of the mount",
+		},
+		{
+			name:            "selected object not in the files",
+			projections:     []secretsstorev1.ObjectFile{{ObjectName: "username"}},
+			selectedObjects: []string{"password"},
+			expectedError:   "selected object password not found in the files of the mount",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projected, err := projectFiles(objects, files, test.projections, test.selectedObjects)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(projected, test.expectedFiles) {
+				t.Fatalf("expected files %v, got %v", test.expectedFiles, projected)
+			}
+		})
+	}
+}
+
+func TestGetSelectedObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrib   map[string]string
+		expected []string
+	}{
+		{
+			name:   "not set",
+			attrib: map[string]string{secretProviderClassField: "spc"},
+		},
+		{
+			name:     "selected objects",
+			attrib:   map[string]string{selectedObjectsField: " username, password,,"},
+			expected: []string{"username", "password"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getSelectedObjects(test.attrib); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("getSelectedObjects() = %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
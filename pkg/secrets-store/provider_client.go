@@ -20,16 +20,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
 	internalerrors "sigs.k8s.io/secrets-store-csi-driver/pkg/errors"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/runtimeutil"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/validation"
 	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
 
 	"google.golang.org/grpc"
@@ -37,6 +42,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
@@ -64,20 +70,94 @@ const ServiceConfig = `
 }
 `
 
+const (
+	// protocolVersion is the version of the provider API implemented by the driver
+	protocolVersion = "v1alpha1"
+	// DefaultMaxMountStreamSize is the default maximum total size in bytes of the file
+	// contents received through the MountStream() RPC
+	DefaultMaxMountStreamSize = 64 * 1024 * 1024
+)
+
 var (
-	// pluginNameRe is the regular expression used to validate plugin names.
-	pluginNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{0,30}$`)
+	// maxMountStreamSize is the maximum total size of the file contents received through
+	// the MountStream() RPC. The files are kept in memory until the stream is complete so
+	// they can be written to the target path atomically. It's set with SetMaxMountStreamSize.
+	maxMountStreamSize = DefaultMaxMountStreamSize
 
 	errInvalidProvider       = errors.New("invalid provider")
 	errProviderNotFound      = errors.New("provider not found")
 	errMissingObjectVersions = errors.New("missing object versions")
+	errInvalidMountStream    = errors.New("invalid mount stream")
+	errIncompatibleProvider  = errors.New("incompatible provider")
+	// errDerivedFilesWithoutFiles is returned when derived files are declared for a provider
+	// that writes its own files, the objects to assemble them from aren't in the response
+	errDerivedFilesWithoutFiles = errors.New("derived files require the provider to return the object contents in the mount response")
+	// errTemplatesWithoutFiles is returned when a template ConfigMap is referenced for a
+	// provider that writes its own files, the objects to render them with aren't in the response
+	errTemplatesWithoutFiles = errors.New("templates require the provider to return the object contents in the mount response")
+	// errProjectionWithoutFiles is returned when files or selected objects are declared
+	// for a provider that writes its own files, the driver can't move or drop them
+	errProjectionWithoutFiles = errors.New("files and selected objects require the provider to return the object contents in the mount response")
 )
 
+// fileOptions declares how the files returned by the provider are processed before
+// they're written to the mount
+type fileOptions struct {
+	// encodings are the encodings declared for the objects, by object name
+	encodings map[string]secretsstorev1.ObjectEncoding
+	// explodes are the explode options declared for the objects, by object name
+	explodes map[string]*secretsstorev1.ObjectExplode
+	// files are the paths and modes of the objects written to the mount
+	files []secretsstorev1.ObjectFile
+	// selectedObjects are the names of the objects selected by the volume
+	selectedObjects []string
+	// derivedFiles are assembled from the objects
+	derivedFiles []secretsstorev1.DerivedFile
+	// templates are rendered with the objects
+	templates *fileTemplates
+}
+
+// ProviderInfo is the result of the version negotiation with a provider
+type ProviderInfo struct {
+	// RuntimeName is the name of the provider
+	RuntimeName string
+	// RuntimeVersion is the version of the provider
+	RuntimeVersion string
+	// Capabilities are the optional features of the provider API that the
+	// provider advertised
+	Capabilities sets.Set[string]
+}
+
+// MissingCapabilities returns the required capabilities that the provider
+// didn't advertise.
+func (i *ProviderInfo) MissingCapabilities(required []string) []string {
+	var missing []string
+	for _, c := range required {
+		if !i.Capabilities.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// providerHealth is the health of a provider as observed by the health check
+type providerHealth struct {
+	// healthy is false once the health check failed failureThreshold times in a row.
+	// Mounts fail fast while the provider is unhealthy.
+	healthy             bool
+	lastSuccess         time.Time
+	consecutiveFailures int
+}
+
 // PluginClientBuilder builds and stores grpc clients for communicating with
 // provider plugins.
 type PluginClientBuilder struct {
 	clients     map[string]v1alpha1.CSIDriverProviderClient
 	conns       map[string]*grpc.ClientConn
+	infos       map[string]*ProviderInfo
+	health      map[string]*providerHealth
+	inventory   map[string]os.FileInfo // provider socket file info, maintained by Watch
+	registry    *providerregistry.Config
 	socketPaths []string
 	lock        sync.RWMutex
 	opts        []grpc.DialOption
@@ -89,14 +169,18 @@ type PluginClientBuilder struct {
 //
 //	<path>/<plugin_name>.sock
 //
-// where <plugin_name> must match the PluginNameRe regular expression.
+// where <plugin_name> must be a valid provider name.
 //
 // Additional grpc dial options can also be set through opts and will be used
-// when creating all clients.
+// when creating all clients. The endpoint and transport settings of a provider
+// can be set in the provider registry with SetRegistry.
 func NewPluginClientBuilder(paths []string, opts ...grpc.DialOption) *PluginClientBuilder {
 	return &PluginClientBuilder{
 		clients:     make(map[string]v1alpha1.CSIDriverProviderClient),
 		conns:       make(map[string]*grpc.ClientConn),
+		infos:       make(map[string]*ProviderInfo),
+		health:      make(map[string]*providerHealth),
+		inventory:   make(map[string]os.FileInfo),
 		socketPaths: paths,
 		lock:        sync.RWMutex{},
 		opts: append(opts, []grpc.DialOption{
@@ -122,37 +206,16 @@ func (p *PluginClientBuilder) Get(ctx context.Context, provider string) (v1alpha
 	}
 
 	// client does not exist, create a new one
-	if !pluginNameRe.MatchString(provider) {
+	if !validation.IsValidProviderName(provider) {
 		return nil, fmt.Errorf("%w: provider %q", errInvalidProvider, provider)
 	}
 
-	// check all paths
-	socketPath := ""
-	for k := range p.socketPaths {
-		tryPath := filepath.Join(p.socketPaths[k], provider+".sock")
-		if _, err := os.Stat(tryPath); err == nil {
-			socketPath = tryPath
-			break
-		}
-		// TODO: This is a workaround for Windows 20H2 issue for os.Stat(). See
-		// microsoft/Windows-Containers#97 for details.
-		// Once the issue is resolved, the following os.Lstat() is not needed.
-		if runtimeutil.IsRuntimeWindows() {
-			if _, err := os.Lstat(tryPath); err == nil {
-				socketPath = tryPath
-				break
-			}
-		}
-	}
-
-	if socketPath == "" {
-		return nil, fmt.Errorf("%w: provider %q", errProviderNotFound, provider)
+	target, opts, err := p.dialTarget(provider)
+	if err != nil {
+		return nil, err
 	}
 
-	conn, err := grpc.NewClient(
-		"unix:"+socketPath,
-		p.opts...,
-	)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -168,11 +231,119 @@ func (p *PluginClientBuilder) Get(ctx context.Context, provider string) (v1alpha
 	} else {
 		p.conns[provider] = conn
 		p.clients[provider] = out
+		if _, ok := p.health[provider]; !ok {
+			p.health[provider] = &providerHealth{healthy: true}
+		}
 	}
 
 	return out, nil
 }
 
+// Negotiate returns the version and capabilities of the provider. The provider
+// is called with the Version() RPC the first time and the result is cached and
+// refreshed by the health check. An error wrapping errIncompatibleProvider is
+// returned if the provider doesn't implement the driver's protocol version.
+func (p *PluginClientBuilder) Negotiate(ctx context.Context, provider string) (*ProviderInfo, error) {
+	p.lock.RLock()
+	info, ok := p.infos[provider]
+	p.lock.RUnlock()
+	if ok {
+		return info, nil
+	}
+
+	client, err := p.Get(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	info, err = negotiate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	klog.InfoS("negotiated provider version", "provider", provider, "runtimeName", info.RuntimeName, "runtimeVersion", info.RuntimeVersion, "capabilities", sets.List(info.Capabilities))
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.infos[provider] = info
+	return info, nil
+}
+
+// dialTarget returns the gRPC target and dial options of the provider. The
+// provider registry entry is used if it exists, otherwise the provider socket
+// is looked up in the socket paths.
+func (p *PluginClientBuilder) dialTarget(provider string) (string, []grpc.DialOption, error) {
+	p.lock.RLock()
+	entry, ok := p.registry.Get(provider)
+	p.lock.RUnlock()
+	if ok {
+		target, opts, err := entry.DialOptions()
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid provider registry entry for provider %q: %w", provider, err)
+		}
+		return target, append(slices.Clone(p.opts), opts...), nil
+	}
+
+	socketPath, _ := p.findSocket(provider)
+	if socketPath == "" {
+		return "", nil, fmt.Errorf("%w: provider %q", errProviderNotFound, provider)
+	}
+	return "unix:" + socketPath, p.opts, nil
+}
+
+// VerifyPeer verifies the identity of the provider process and socket file if it's
+// set in the provider registry. It returns a nil identity if it's not set. An error
+// wrapping providerregistry.ErrPeerVerificationFailed is returned if the identity
+// doesn't match. The connections to the provider are also verified when they are
+// created, this is used to refuse mounts before any request is sent to the provider.
+func (p *PluginClientBuilder) VerifyPeer(ctx context.Context, provider string) (*providerregistry.PeerIdentity, error) {
+	p.lock.RLock()
+	entry, ok := p.registry.Get(provider)
+	p.lock.RUnlock()
+	if !ok || entry.Peer == nil {
+		return nil, nil
+	}
+	return entry.VerifyPeer(ctx)
+}
+
+// SetRegistry sets the provider registry config. The connections to the providers
+// whose registry entry changed are closed, new connections are created with the
+// new settings on the next Get.
+func (p *PluginClientBuilder) SetRegistry(config *providerregistry.Config) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	old := p.registry
+	p.registry = config
+	for provider := range p.conns {
+		oldEntry, oldOK := old.Get(provider)
+		newEntry, newOK := config.Get(provider)
+		if oldOK != newOK || !reflect.DeepEqual(oldEntry, newEntry) {
+			klog.InfoS("provider registry entry changed, closing provider connection", "provider", provider)
+			p.disconnectLocked(provider)
+		}
+	}
+}
+
+// findSocket returns the path and file info of the provider socket in the first
+// socket path that contains it. An empty path is returned if the socket is not found.
+func (p *PluginClientBuilder) findSocket(provider string) (string, os.FileInfo) {
+	// check all paths
+	for k := range p.socketPaths {
+		tryPath := filepath.Join(p.socketPaths[k], provider+".sock")
+		if info, err := os.Stat(tryPath); err == nil {
+			return tryPath, info
+		}
+		// TODO: This is a workaround for Windows 20H2 issue for os.Stat(). See
+		// microsoft/Windows-Containers#97 for details.
+		// Once the issue is resolved, the following os.Lstat() is not needed.
+		if runtimeutil.IsRuntimeWindows() {
+			if info, err := os.Lstat(tryPath); err == nil {
+				return tryPath, info
+			}
+		}
+	}
+	return "", nil
+}
+
 // Cleanup closes all underlying connections and removes all clients.
 func (p *PluginClientBuilder) Cleanup() {
 	p.lock.Lock()
@@ -185,13 +356,36 @@ func (p *PluginClientBuilder) Cleanup() {
 	}
 	p.clients = make(map[string]v1alpha1.CSIDriverProviderClient)
 	p.conns = make(map[string]*grpc.ClientConn)
+	p.infos = make(map[string]*ProviderInfo)
+	p.health = make(map[string]*providerHealth)
+}
+
+// Available returns an Unavailable error if the provider is unhealthy, i.e. the
+// health check failed too many times in a row, so mounts fail fast instead of
+// waiting for the provider.
+func (p *PluginClientBuilder) Available(provider string) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	h, ok := p.health[provider]
+	if !ok || h.healthy {
+		return nil
+	}
+	lastSuccess := "never"
+	if !h.lastSuccess.IsZero() {
+		lastSuccess = h.lastSuccess.UTC().Format(time.RFC3339)
+	}
+	return status.Errorf(codes.Unavailable, "provider %q is unavailable after %d failed health checks, last successful health check: %s", provider, h.consecutiveFailures, lastSuccess)
 }
 
 // HealthCheck enables periodic healthcheck for configured provider clients by making
-// a Version() RPC call. If the provider healthcheck fails, we log an error.
+// a Version() RPC call. A provider is marked unhealthy after failureThreshold
+// consecutive failures. Mounts for an unhealthy provider fail fast and the connection
+// to the provider is recreated until a healthcheck succeeds. The negotiated provider
+// info is refreshed if the healthcheck succeeds.
 //
 // This method blocks until the parent context is canceled during termination.
-func (p *PluginClientBuilder) HealthCheck(ctx context.Context, interval time.Duration) {
+func (p *PluginClientBuilder) HealthCheck(ctx context.Context, interval time.Duration, failureThreshold int, reporter StatsReporter) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -200,28 +394,106 @@ func (p *PluginClientBuilder) HealthCheck(ctx context.Context, interval time.Dur
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			p.lock.RLock()
-
-			for provider, client := range p.clients {
-				c, cancel := context.WithTimeout(ctx, 5*time.Second)
-				defer cancel()
-
-				runtimeVersion, err := Version(c, client)
-				if err != nil {
-					klog.V(4).ErrorS(err, "provider healthcheck failed", "provider", provider)
-					continue
-				}
-				klog.V(4).InfoS("provider healthcheck successful", "provider", provider, "runtimeVersion", runtimeVersion)
+			p.checkHealth(ctx, failureThreshold, reporter)
+		}
+	}
+}
+
+// checkHealth runs the healthcheck for all the providers and updates their health.
+func (p *PluginClientBuilder) checkHealth(ctx context.Context, failureThreshold int, reporter StatsReporter) {
+	p.lock.RLock()
+	clients := make(map[string]v1alpha1.CSIDriverProviderClient)
+	for provider := range p.health {
+		clients[provider] = p.clients[provider]
+	}
+	p.lock.RUnlock()
+
+	for provider, client := range clients {
+		info, err := p.checkProvider(ctx, provider, client)
+
+		p.lock.Lock()
+		h, ok := p.health[provider]
+		if !ok {
+			// the provider was removed by Cleanup or its socket was removed
+			p.lock.Unlock()
+			continue
+		}
+		if err != nil {
+			h.consecutiveFailures++
+			h.healthy = h.consecutiveFailures < failureThreshold
+		} else {
+			h.consecutiveFailures = 0
+			h.healthy = true
+			h.lastSuccess = time.Now()
+			if _, ok := p.clients[provider]; ok {
+				p.infos[provider] = info
+			}
+		}
+		healthy, consecutiveFailures := h.healthy, h.consecutiveFailures
+		p.lock.Unlock()
+
+		reporter.ReportProviderHealth(ctx, provider, healthy, consecutiveFailures)
+		if err != nil {
+			klog.V(4).ErrorS(err, "provider healthcheck failed", "provider", provider, "consecutiveFailures", consecutiveFailures)
+			if !healthy {
+				klog.ErrorS(err, "provider is unhealthy, reconnecting", "provider", provider, "consecutiveFailures", consecutiveFailures)
+				p.disconnect(provider)
 			}
+			continue
+		}
+		klog.V(4).InfoS("provider healthcheck successful", "provider", provider, "runtimeVersion", info.RuntimeVersion)
+	}
+}
+
+// checkProvider calls the provider's Version() RPC. A new client is created if the
+// previous connection was closed because the provider was unhealthy.
+func (p *PluginClientBuilder) checkProvider(ctx context.Context, provider string, client v1alpha1.CSIDriverProviderClient) (*ProviderInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-			p.lock.RUnlock()
+	if client == nil {
+		var err error
+		if client, err = p.Get(ctx, provider); err != nil {
+			return nil, err
 		}
 	}
+	return negotiate(ctx, client)
 }
 
-// MountContent calls the client's Mount() RPC with helpers to format the
-// request and interpret the response.
-func MountContent(ctx context.Context, client v1alpha1.CSIDriverProviderClient, attributes, secrets, targetPath, permission string, oldObjectVersions map[string]string) (map[string]string, string, error) {
+// disconnect closes the connection to the provider. A new connection is created
+// on the next Get.
+func (p *PluginClientBuilder) disconnect(provider string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.disconnectLocked(provider)
+}
+
+// disconnectLocked is disconnect for callers that hold the lock
+func (p *PluginClientBuilder) disconnectLocked(provider string) {
+	if conn, ok := p.conns[provider]; ok {
+		if err := conn.Close(); err != nil {
+			klog.ErrorS(err, "error shutting down provider connection", "provider", provider)
+		}
+	}
+	delete(p.conns, provider)
+	delete(p.clients, provider)
+	delete(p.infos, provider)
+}
+
+// SetMaxMountStreamSize sets the maximum total size in bytes of the file contents
+// received through the MountStream() RPC.
+func SetMaxMountStreamSize(size int) {
+	maxMountStreamSize = size
+}
+
+// mountContent calls the client's MountStream() RPC if the provider advertised the
+// MountStream capability, or the Mount() RPC otherwise, and returns the object versions
+// and the files written to the target path. The file contents are decoded with the encodings declared for the objects
+// and the derived files are assembled from them, then the objects declared with explode
+// are split into a file per field, the objects are moved to their files and the templates
+// are rendered with the resulting files before they're all written together.
+func mountContent(ctx context.Context, client v1alpha1.CSIDriverProviderClient, stream bool, attributes, secrets, targetPath, permission string, oldObjectVersions map[string]string, opts fileOptions) (map[string]string, []*v1alpha1.File, string, error) {
 	var objVersions []*v1alpha1.ObjectVersion
 	for obj, version := range oldObjectVersions {
 		objVersions = append(objVersions, &v1alpha1.ObjectVersion{Id: obj, Version: version})
@@ -235,20 +507,26 @@ func MountContent(ctx context.Context, client v1alpha1.CSIDriverProviderClient,
 		CurrentObjectVersion: objVersions,
 	}
 
-	resp, err := client.Mount(ctx, req)
+	var resp *v1alpha1.MountResponse
+	var err error
+	if stream {
+		resp, err = mountStream(ctx, client, req)
+	} else {
+		resp, err = client.Mount(ctx, req)
+	}
 	if err != nil {
 		if isMaxRecvMsgSizeError(err) {
 			klog.ErrorS(err, "Set --max-call-recv-msg-size to configure larger maximum size in bytes of gRPC response")
 		}
-		return nil, internalerrors.GRPCProviderError, err
+		return nil, nil, internalerrors.GRPCProviderError, err
 	}
 	if resp != nil && resp.GetError() != nil && len(resp.GetError().Code) > 0 {
-		return nil, resp.GetError().Code, fmt.Errorf("mount request failed with provider error code %s", resp.GetError().Code)
+		return nil, nil, resp.GetError().Code, fmt.Errorf("mount request failed with provider error code %s", resp.GetError().Code)
 	}
 
 	ov := resp.GetObjectVersion()
 	if ov == nil {
-		return nil, internalerrors.GRPCProviderError, errMissingObjectVersions
+		return nil, nil, internalerrors.GRPCProviderError, errMissingObjectVersions
 	}
 	objectVersions := make(map[string]string)
 	for _, v := range ov {
@@ -266,22 +544,107 @@ func MountContent(ctx context.Context, client v1alpha1.CSIDriverProviderClient,
 		// The plugin mount response contains no files. Possible that the plugin
 		// is writing its own files instead of the driver (See Issue #551).
 		klog.V(5).Info("Empty files in mount response. It is possible that the plugin has not migrated to driver-written files (Issue #551).")
-		return objectVersions, "", nil
+		if len(opts.derivedFiles) > 0 {
+			return nil, nil, internalerrors.FileWriteError, errDerivedFilesWithoutFiles
+		}
+		if opts.templates != nil {
+			return nil, nil, internalerrors.FileWriteError, errTemplatesWithoutFiles
+		}
+		if len(opts.files) > 0 || len(opts.selectedObjects) > 0 {
+			return nil, nil, internalerrors.FileWriteError, errProjectionWithoutFiles
+		}
+		return objectVersions, nil, "", nil
 	}
 
-	if err := fileutil.WritePayloads(targetPath, resp.GetFiles()); err != nil {
-		return nil, internalerrors.FileWriteError, err
+	objects := resp.GetFiles()
+	if err := decodeFiles(objects, opts.encodings); err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	derived, err := deriveFiles(objects, opts.derivedFiles)
+	if err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	files, err := explodeFiles(objects, opts.explodes)
+	if err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	if files, err = projectFiles(objects, files, opts.files, opts.selectedObjects); err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	files = append(files, derived...)
+	if err := validateFilePaths(files); err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	rendered, err := renderTemplates(files, opts.templates)
+	if err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
+	}
+	files = append(files, rendered...)
+	if err := fileutil.WritePayloads(targetPath, files); err != nil {
+		return nil, nil, internalerrors.FileWriteError, err
 	}
 	klog.V(5).Info("mount response files written.")
 
-	return objectVersions, "", nil
+	return objectVersions, files, "", nil
+}
+
+// mountStream calls the client's MountStream() RPC and assembles the file chunks into
+// a mount response. The chunks are appended to the file contents as they are received
+// so only the files and a single message are held in memory.
+func mountStream(ctx context.Context, client v1alpha1.CSIDriverProviderClient, req *v1alpha1.MountRequest) (*v1alpha1.MountResponse, error) {
+	// cancel the stream if the response is rejected before it's fully received
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.MountStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &v1alpha1.MountResponse{}
+	seen := make(map[string]bool)
+	var current *v1alpha1.File
+	size := 0
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp.ObjectVersion = append(resp.ObjectVersion, msg.GetObjectVersion()...)
+		if len(msg.GetError().GetCode()) > 0 {
+			resp.Error = msg.GetError()
+			return resp, nil
+		}
+
+		chunk := msg.GetFileChunk()
+		if chunk == nil {
+			continue
+		}
+		if current == nil || current.GetPath() != chunk.GetPath() {
+			if seen[chunk.GetPath()] {
+				return nil, fmt.Errorf("%w: chunks of file %q are not contiguous", errInvalidMountStream, chunk.GetPath())
+			}
+			seen[chunk.GetPath()] = true
+			current = &v1alpha1.File{Path: chunk.GetPath(), Mode: chunk.GetMode()}
+			resp.Files = append(resp.Files, current)
+		}
+		size += len(chunk.GetContents())
+		if size > maxMountStreamSize {
+			return nil, fmt.Errorf("%w: files are larger than %d bytes", errInvalidMountStream, maxMountStreamSize)
+		}
+		current.Contents = append(current.Contents, chunk.GetContents()...)
+	}
 }
 
 // Version calls the client's Version() RPC
 // returns provider runtime version and error.
 func Version(ctx context.Context, client v1alpha1.CSIDriverProviderClient) (string, error) {
 	req := &v1alpha1.VersionRequest{
-		Version: "v1alpha1",
+		Version: protocolVersion,
 	}
 
 	resp, err := client.Version(ctx, req)
@@ -291,6 +654,24 @@ func Version(ctx context.Context, client v1alpha1.CSIDriverProviderClient) (stri
 	return resp.RuntimeVersion, nil
 }
 
+// negotiate calls the client's Version() RPC and checks that the provider implements
+// the driver's protocol version.
+func negotiate(ctx context.Context, client v1alpha1.CSIDriverProviderClient) (*ProviderInfo, error) {
+	resp, err := client.Version(ctx, &v1alpha1.VersionRequest{Version: protocolVersion})
+	if err != nil {
+		return nil, err
+	}
+	// providers that don't set the version are assumed to implement the driver's version
+	if version := resp.GetVersion(); version != "" && version != protocolVersion {
+		return nil, fmt.Errorf("%w: provider implements version %q, driver requires %q", errIncompatibleProvider, version, protocolVersion)
+	}
+	return &ProviderInfo{
+		RuntimeName:    resp.GetRuntimeName(),
+		RuntimeVersion: resp.GetRuntimeVersion(),
+		Capabilities:   sets.New(resp.GetCapabilities()...),
+	}, nil
+}
+
 // isMaxRecvMsgSizeError checks if the grpc error is of ResourceExhausted type and
 // msg size is larger than max configured.
 func isMaxRecvMsgSizeError(err error) bool {
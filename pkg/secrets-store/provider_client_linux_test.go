@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
+)
+
+func TestPluginClientBuilder_VerifyPeer(t *testing.T) {
+	uid := int64(os.Getuid())
+	otherUID := uid + 1
+
+	tests := []struct {
+		name        string
+		peer        *providerregistry.Peer
+		expectedErr bool
+	}{
+		{
+			name: "no identity",
+		},
+		{
+			name: "matching identity",
+			peer: &providerregistry.Peer{UID: &uid},
+		},
+		{
+			name:        "identity mismatch",
+			peer:        &providerregistry.Peer{UID: &otherUID},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := t.TempDir()
+			cb := NewPluginClientBuilder([]string{path})
+			defer cb.Cleanup()
+
+			server, cleanup := fakeServer(t, path, "provider1")
+			defer cleanup()
+			if err := server.Start(); err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			cb.SetRegistry(&providerregistry.Config{
+				APIVersion: providerregistry.APIVersion,
+				Kind:       providerregistry.Kind,
+				Providers: []providerregistry.Provider{
+					{Name: "provider1", Endpoint: "unix://" + filepath.Join(path, "provider1.sock"), Peer: test.peer},
+				},
+			})
+
+			identity, err := cb.VerifyPeer(context.TODO(), "provider1")
+			if test.expectedErr {
+				if !errors.Is(err, providerregistry.ErrPeerVerificationFailed) {
+					t.Fatalf("expected peer verification error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if (test.peer == nil) != (identity == nil) {
+				t.Errorf("unexpected peer identity: %+v", identity)
+			}
+
+			// the connection to the provider is verified with the same identity
+			if _, err := cb.Negotiate(context.TODO(), "provider1"); err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+		})
+	}
+}
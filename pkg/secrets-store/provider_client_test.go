@@ -17,16 +17,24 @@ limitations under the License.
 package secretsstore
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	internalerrors "sigs.k8s.io/secrets-store-csi-driver/pkg/errors"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/providerregistry"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/secrets-store/mocks"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
 	"sigs.k8s.io/secrets-store-csi-driver/provider/fake"
 	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
@@ -35,6 +43,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
 func fakeServer(t *testing.T, path, provider string) (*fake.MockCSIProviderServer, func()) {
@@ -195,7 +204,7 @@ func TestMountContent(t *testing.T) {
 				t.Fatalf("expected err to be nil, got: %+v", err)
 			}
 
-			objectVersions, _, err := MountContent(context.TODO(), client, "{}", "{}", targetPath, test.permission, nil)
+			objectVersions, _, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, test.permission, nil, fileOptions{})
 			if err != nil {
 				t.Errorf("expected err to be nil, got: %+v", err)
 			}
@@ -218,7 +227,7 @@ func TestMountContent(t *testing.T) {
 			}
 
 			if diff := cmp.Diff(test.expectedFiles, gotFiles); diff != "" {
-				t.Errorf("MountContent() file mismatch (-want +got):\n%s", diff)
+				t.Errorf("mountContent() file mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
@@ -253,7 +262,7 @@ func TestMountContent_TooLarge(t *testing.T) {
 	}
 
 	// rpc error: code = ResourceExhausted desc = grpc: received message larger than max (28 vs. 5)
-	_, errorCode, err := MountContent(context.TODO(), client, "{}", "{}", targetPath, "777", nil)
+	_, _, errorCode, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{})
 	if err == nil {
 		t.Errorf("expected err to be not nil")
 	}
@@ -265,6 +274,522 @@ func TestMountContent_TooLarge(t *testing.T) {
 	}
 }
 
+func TestMountContent_Stream(t *testing.T) {
+	socketPath := t.TempDir()
+	targetPath := t.TempDir()
+
+	// the files are larger than the max message size but each chunk fits in a message
+	pool := NewPluginClientBuilder([]string{socketPath}, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024)))
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	files := []*v1alpha1.File{
+		{
+			Path:     "foo",
+			Mode:     0644,
+			Contents: bytes.Repeat([]byte("foo"), 4096),
+		},
+		{
+			Path:     "bar",
+			Mode:     0600,
+			Contents: []byte("bar"),
+		},
+		{
+			Path: "empty",
+			Mode: 0644,
+		},
+	}
+	server.SetObjects(map[string]string{"foo": "v1"})
+	server.SetFiles(files)
+	server.SetMountStreamChunkSize(512)
+	server.SetCapabilities([]string{v1alpha1.CapabilityMountStream})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	objectVersions, _, _, err := mountContent(context.TODO(), client, true, "{}", "{}", targetPath, "777", nil, fileOptions{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if want := map[string]string{"foo": "v1"}; !reflect.DeepEqual(want, objectVersions) {
+		t.Errorf("expected object versions: %v, got: %+v", want, objectVersions)
+	}
+	for _, f := range files {
+		got, err := os.ReadFile(filepath.Join(targetPath, f.Path))
+		if err != nil {
+			t.Fatalf("unable to read mounted file %s: %s", f.Path, err)
+		}
+		if !bytes.Equal(f.Contents, got) {
+			t.Errorf("file %s contents mismatch, expected %d bytes, got %d bytes", f.Path, len(f.Contents), len(got))
+		}
+	}
+}
+
+func TestMountContent_Encoding(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	server.SetObjects(map[string]string{"keystore": "v1", "password": "v1"})
+	server.SetFiles([]*v1alpha1.File{
+		{Path: "keystore", Mode: 0644, Contents: []byte("a2V5\nc3RvcmU=\n")},
+		{Path: "password", Mode: 0644, Contents: []byte("cGFzc3dvcmQ=")},
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	targetPath := t.TempDir()
+	encodings := map[string]secretsstorev1.ObjectEncoding{"keystore": secretsstorev1.ObjectEncodingBase64}
+	_, files, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{encodings: encodings})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	for path, want := range map[string]string{"keystore": "keystore", "password": "cGFzc3dvcmQ="} {
+		got, err := os.ReadFile(filepath.Join(targetPath, path))
+		if err != nil {
+			t.Fatalf("unable to read mounted file %s: %s", path, err)
+		}
+		if string(got) != want {
+			t.Errorf("file %s contents mismatch, expected %q, got %q", path, want, string(got))
+		}
+	}
+	if len(files) != 2 || string(files[0].Contents) != "keystore" {
+		t.Errorf("expected the returned files to be decoded, got: %+v", files)
+	}
+
+	// content that isn't encoded with the declared encoding is not written
+	targetPath = t.TempDir()
+	encodings = map[string]secretsstorev1.ObjectEncoding{"password": secretsstorev1.ObjectEncodingHex}
+	_, _, errorCode, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{encodings: encodings})
+	if err == nil || !strings.Contains(err.Error(), "object password") {
+		t.Errorf("expected decode error for object password, got: %v", err)
+	}
+	if want := internalerrors.FileWriteError; errorCode != want {
+		t.Errorf("expected error code: %v, got: %+v", want, errorCode)
+	}
+	if entries, _ := os.ReadDir(targetPath); len(entries) != 0 {
+		t.Errorf("expected no files to be written, got: %d", len(entries))
+	}
+}
+
+func TestMountContent_Explode(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	server.SetObjects(map[string]string{"db": "v1", "other": "v1"})
+	server.SetFiles([]*v1alpha1.File{
+		// {"username":"admin","password":"secret"}
+		{Path: "db", Mode: 0644, Contents: []byte("eyJ1c2VybmFtZSI6ImFkbWluIiwicGFzc3dvcmQiOiJzZWNyZXQifQ==")},
+		{Path: "other", Mode: 0644, Contents: []byte("other")},
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	targetPath := t.TempDir()
+	encodings := map[string]secretsstorev1.ObjectEncoding{"db": secretsstorev1.ObjectEncodingBase64}
+	explodes := map[string]*secretsstorev1.ObjectExplode{"db": {
+		Items: []secretsstorev1.ObjectExplodeItem{{Key: "username"}, {Key: "password", Mode: int32Ptr(0600)}},
+	}}
+	_, files, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{encodings: encodings, explodes: explodes})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected the exploded files to be returned, got: %+v", files)
+	}
+	for path, want := range map[string]string{"db/username": "admin", "db/password": "secret", "other": "other"} {
+		got, err := os.ReadFile(filepath.Join(targetPath, path))
+		if err != nil {
+			t.Fatalf("unable to read mounted file %s: %s", path, err)
+		}
+		if string(got) != want {
+			t.Errorf("file %s contents mismatch, expected %q, got %q", path, want, string(got))
+		}
+	}
+	info, err := os.Stat(filepath.Join(targetPath, "db", "password"))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	// objects that can't be exploded are not written
+	targetPath = t.TempDir()
+	explodes = map[string]*secretsstorev1.ObjectExplode{"other": {}}
+	_, _, errorCode, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{encodings: encodings, explodes: explodes})
+	if err == nil || !strings.Contains(err.Error(), "failed to explode object other") {
+		t.Errorf("expected explode error for object other, got: %v", err)
+	}
+	if want := internalerrors.FileWriteError; errorCode != want {
+		t.Errorf("expected error code: %v, got: %+v", want, errorCode)
+	}
+	if entries, _ := os.ReadDir(targetPath); len(entries) != 0 {
+		t.Errorf("expected no files to be written, got: %d", len(entries))
+	}
+}
+
+func TestMountContent_Templates(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	server.SetObjects(map[string]string{"password": "v1"})
+	server.SetFiles([]*v1alpha1.File{{Path: "password", Mode: 0644, Contents: []byte("secret")}})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	targetPath := t.TempDir()
+	for _, want := range []string{"password=secret", "spring.datasource.password=secret"} {
+		// the templates are rendered again on every mount request
+		templates := &fileTemplates{templates: map[string]string{"app.properties": strings.TrimSuffix(want, "secret") + "{{ .password }}"}, mode: 0400}
+		_, files, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{templates: templates})
+		if err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		if len(files) != 2 {
+			t.Errorf("expected the rendered file to be returned, got: %+v", files)
+		}
+		got, err := os.ReadFile(filepath.Join(targetPath, "app.properties"))
+		if err != nil {
+			t.Fatalf("unable to read rendered file: %s", err)
+		}
+		if string(got) != want {
+			t.Errorf("rendered file contents mismatch, expected %q, got %q", want, string(got))
+		}
+	}
+}
+
+func TestMountContent_Files(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	server.SetObjects(map[string]string{"username": "v1", "password": "v1"})
+	server.SetFiles([]*v1alpha1.File{
+		{Path: "username", Mode: 0644, Contents: []byte("admin")},
+		{Path: "password", Mode: 0644, Contents: []byte("secret")},
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	targetPath := t.TempDir()
+	opts := fileOptions{
+		files: []secretsstorev1.ObjectFile{
+			{ObjectName: "username"},
+			{ObjectName: "password", Path: "db/password", Mode: int32Ptr(0400)},
+		},
+		selectedObjects: []string{"password"},
+	}
+	_, files, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, opts)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if len(files) != 1 || files[0].GetPath() != "db/password" {
+		t.Errorf("expected the projected file to be returned, got: %+v", files)
+	}
+	info, err := os.Stat(filepath.Join(targetPath, "db", "password"))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if info.Mode().Perm() != 0400 {
+		t.Errorf("expected mode 0400, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(targetPath, "username")); !os.IsNotExist(err) {
+		t.Errorf("expected the object that isn't selected not to be written, got: %v", err)
+	}
+}
+
+func TestMountContent_DerivedFiles(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	cert, _ := newTestKeyPair(t, "ca")
+	server.SetObjects(map[string]string{"ca.crt": "v1", "password": "v1"})
+	server.SetFiles([]*v1alpha1.File{
+		{Path: "ca.crt", Mode: 0644, Contents: cert},
+		{Path: "password", Mode: 0600, Contents: []byte("changeit")},
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	targetPath := t.TempDir()
+	derivedFiles := []secretsstorev1.DerivedFile{{
+		Path:     "truststore.p12",
+		Keystore: &secretsstorev1.Keystore{TrustedCertObjectNames: []string{"ca.crt"}, PasswordObjectName: "password"},
+	}}
+	_, files, _, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{derivedFiles: derivedFiles})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if len(files) != 3 || files[2].GetPath() != "truststore.p12" {
+		t.Fatalf("expected the derived file to be returned, got: %+v", files)
+	}
+	got, err := os.ReadFile(filepath.Join(targetPath, "truststore.p12"))
+	if err != nil {
+		t.Fatalf("unable to read derived file: %s", err)
+	}
+	if !bytes.Equal(files[2].GetContents(), got) {
+		t.Errorf("derived file contents mismatch, expected %d bytes, got %d bytes", len(files[2].GetContents()), len(got))
+	}
+
+	// the derived files are not written without their objects
+	targetPath = t.TempDir()
+	derivedFiles[0].Keystore.TrustedCertObjectNames = []string{"missing"}
+	_, _, errorCode, err := mountContent(context.TODO(), client, false, "{}", "{}", targetPath, "777", nil, fileOptions{derivedFiles: derivedFiles})
+	if err == nil || !strings.Contains(err.Error(), "object missing not found") {
+		t.Errorf("expected object not found error, got: %v", err)
+	}
+	if want := internalerrors.FileWriteError; errorCode != want {
+		t.Errorf("expected error code: %v, got: %+v", want, errorCode)
+	}
+	if entries, _ := os.ReadDir(targetPath); len(entries) != 0 {
+		t.Errorf("expected no files to be written, got: %d", len(entries))
+	}
+}
+
+func TestMountContent_StreamProviderErrorCode(t *testing.T) {
+	socketPath := t.TempDir()
+
+	pool := NewPluginClientBuilder([]string{socketPath})
+	defer pool.Cleanup()
+
+	server, cleanup := fakeServer(t, socketPath, "provider1")
+	defer cleanup()
+
+	server.SetObjects(map[string]string{"foo": "v1"})
+	server.SetProviderErrorCode("AuthenticationFailed")
+	server.SetMountStreamChunkSize(512)
+	server.SetCapabilities([]string{v1alpha1.CapabilityMountStream})
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	client, err := pool.Get(context.Background(), "provider1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	_, _, errorCode, err := mountContent(context.TODO(), client, true, "{}", "{}", t.TempDir(), "777", nil, fileOptions{})
+	if err == nil {
+		t.Errorf("expected err to be not nil")
+	}
+	if want := "AuthenticationFailed"; errorCode != want {
+		t.Errorf("expected error code: %v, got: %+v", want, errorCode)
+	}
+}
+
+// recordingProviderClient records the mount RPCs called by the driver
+type recordingProviderClient struct {
+	v1alpha1.CSIDriverProviderClient
+	calls []string
+}
+
+func (c *recordingProviderClient) Mount(ctx context.Context, in *v1alpha1.MountRequest, opts ...grpc.CallOption) (*v1alpha1.MountResponse, error) {
+	c.calls = append(c.calls, "Mount")
+	return &v1alpha1.MountResponse{ObjectVersion: []*v1alpha1.ObjectVersion{{Id: "foo", Version: "v1"}}}, nil
+}
+
+func (c *recordingProviderClient) MountStream(ctx context.Context, in *v1alpha1.MountRequest, opts ...grpc.CallOption) (v1alpha1.CSIDriverProvider_MountStreamClient, error) {
+	c.calls = append(c.calls, "MountStream")
+	return &fakeMountStreamClient{msgs: []*v1alpha1.MountStreamResponse{{ObjectVersion: []*v1alpha1.ObjectVersion{{Id: "foo", Version: "v1"}}}}}, nil
+}
+
+func TestMountContent_MountStreamCapability(t *testing.T) {
+	cases := []struct {
+		name          string
+		stream        bool
+		expectedCalls []string
+	}{
+		{
+			name:          "provider advertises MountStream",
+			stream:        true,
+			expectedCalls: []string{"MountStream"},
+		},
+		{
+			name:          "provider doesn't advertise MountStream",
+			stream:        false,
+			expectedCalls: []string{"Mount"},
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			client := &recordingProviderClient{}
+			objectVersions, _, _, err := mountContent(context.TODO(), client, test.stream, "{}", "{}", t.TempDir(), "777", nil, fileOptions{})
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if diff := cmp.Diff(map[string]string{"foo": "v1"}, objectVersions); diff != "" {
+				t.Errorf("mountContent() object versions mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.expectedCalls, client.calls); diff != "" {
+				t.Errorf("mountContent() calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeMountStreamClient struct {
+	grpc.ClientStream
+	msgs []*v1alpha1.MountStreamResponse
+}
+
+func (c *fakeMountStreamClient) Recv() (*v1alpha1.MountStreamResponse, error) {
+	if len(c.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := c.msgs[0]
+	c.msgs = c.msgs[1:]
+	return msg, nil
+}
+
+type fakeStreamProviderClient struct {
+	v1alpha1.CSIDriverProviderClient
+	msgs []*v1alpha1.MountStreamResponse
+}
+
+func (c *fakeStreamProviderClient) MountStream(ctx context.Context, in *v1alpha1.MountRequest, opts ...grpc.CallOption) (v1alpha1.CSIDriverProvider_MountStreamClient, error) {
+	return &fakeMountStreamClient{msgs: c.msgs}, nil
+}
+
+func TestMountStream(t *testing.T) {
+	chunk := func(path string, contents string) *v1alpha1.MountStreamResponse {
+		return &v1alpha1.MountStreamResponse{FileChunk: &v1alpha1.FileChunk{Path: path, Mode: 0644, Contents: []byte(contents)}}
+	}
+	objectVersion := func(id string) *v1alpha1.MountStreamResponse {
+		return &v1alpha1.MountStreamResponse{ObjectVersion: []*v1alpha1.ObjectVersion{{Id: id, Version: "v1"}}}
+	}
+
+	cases := []struct {
+		name          string
+		msgs          []*v1alpha1.MountStreamResponse
+		expectedResp  *v1alpha1.MountResponse
+		expectedError error
+	}{
+		{
+			name: "files and object versions are assembled",
+			msgs: []*v1alpha1.MountStreamResponse{
+				objectVersion("foo"),
+				chunk("foo", "fo"),
+				chunk("foo", "o"),
+				chunk("bar", "bar"),
+				objectVersion("bar"),
+			},
+			expectedResp: &v1alpha1.MountResponse{
+				ObjectVersion: []*v1alpha1.ObjectVersion{{Id: "foo", Version: "v1"}, {Id: "bar", Version: "v1"}},
+				Files: []*v1alpha1.File{
+					{Path: "foo", Mode: 0644, Contents: []byte("foo")},
+					{Path: "bar", Mode: 0644, Contents: []byte("bar")},
+				},
+			},
+		},
+		{
+			name: "provider error code stops the stream",
+			msgs: []*v1alpha1.MountStreamResponse{
+				chunk("foo", "foo"),
+				{Error: &v1alpha1.Error{Code: "AuthenticationFailed"}},
+				chunk("bar", "bar"),
+			},
+			expectedResp: &v1alpha1.MountResponse{
+				Error: &v1alpha1.Error{Code: "AuthenticationFailed"},
+				Files: []*v1alpha1.File{{Path: "foo", Mode: 0644, Contents: []byte("foo")}},
+			},
+		},
+		{
+			name: "interleaved file chunks",
+			msgs: []*v1alpha1.MountStreamResponse{
+				chunk("foo", "fo"),
+				chunk("bar", "bar"),
+				chunk("foo", "o"),
+			},
+			expectedError: errInvalidMountStream,
+		},
+		{
+			name: "files larger than the max size",
+			msgs: []*v1alpha1.MountStreamResponse{
+				chunk("foo", "foo"),
+				chunk("bar", "bar!"),
+			},
+			expectedError: errInvalidMountStream,
+		},
+	}
+
+	defer func(size int) { maxMountStreamSize = size }(maxMountStreamSize)
+	maxMountStreamSize = 6
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := mountStream(context.TODO(), &fakeStreamProviderClient{msgs: test.msgs}, &v1alpha1.MountRequest{})
+			if !errors.Is(err, test.expectedError) {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if diff := cmp.Diff(test.expectedResp, resp, protocmp.Transform()); diff != "" {
+				t.Errorf("mountStream() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestMountContentError(t *testing.T) {
 	cases := []struct {
 		name                  string
@@ -347,7 +872,7 @@ func TestMountContentError(t *testing.T) {
 				t.Fatalf("expected err to be nil, got: %+v", err)
 			}
 
-			objectVersions, errorCode, err := MountContent(context.TODO(), client, test.attributes, test.secrets, test.targetPath, test.permission, nil)
+			objectVersions, _, errorCode, err := mountContent(context.TODO(), client, false, test.attributes, test.secrets, test.targetPath, test.permission, nil, fileOptions{})
 			if err == nil {
 				t.Errorf("expected err to be not nil")
 			}
@@ -485,6 +1010,54 @@ func TestPluginClientBuilderErrorInvalid(t *testing.T) {
 	}
 }
 
+func TestPluginClientBuilder_Registry(t *testing.T) {
+	path := t.TempDir()
+	registryPath := t.TempDir()
+
+	cb := NewPluginClientBuilder([]string{path})
+	defer cb.Cleanup()
+	ctx := context.Background()
+
+	// the provider socket is not in the socket paths
+	server, cleanup := fakeServer(t, registryPath, "vault-provider")
+	defer cleanup()
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if _, err := cb.Get(ctx, "vault"); !errors.Is(err, errProviderNotFound) {
+		t.Fatalf("Get(vault) = %v, want %v", err, errProviderNotFound)
+	}
+
+	registry := &providerregistry.Config{
+		APIVersion: providerregistry.APIVersion,
+		Kind:       providerregistry.Kind,
+		Providers: []providerregistry.Provider{
+			{Name: "vault", Endpoint: "unix://" + filepath.Join(registryPath, "vault-provider.sock"), MaxConcurrency: 1},
+		},
+	}
+	cb.SetRegistry(registry)
+	if _, err := cb.Negotiate(ctx, "vault"); err != nil {
+		t.Fatalf("Negotiate(vault) = %v, want nil", err)
+	}
+
+	// the connection is kept if the registry entry didn't change
+	reloaded := *registry
+	reloaded.Providers = []providerregistry.Provider{registry.Providers[0]}
+	cb.SetRegistry(&reloaded)
+	if !isConnected(cb, "vault") {
+		t.Fatalf("expected the provider connection to be kept")
+	}
+
+	// the connection is closed if the registry entry was removed
+	cb.SetRegistry(&providerregistry.Config{APIVersion: providerregistry.APIVersion, Kind: providerregistry.Kind})
+	if isConnected(cb, "vault") {
+		t.Fatalf("expected the provider connection to be closed")
+	}
+	if _, err := cb.Get(ctx, "vault"); !errors.Is(err, errProviderNotFound) {
+		t.Errorf("Get(vault) = %v, want %v", err, errProviderNotFound)
+	}
+}
+
 func TestVersion(t *testing.T) {
 	cases := []struct {
 		name                   string
@@ -526,6 +1099,89 @@ func TestVersion(t *testing.T) {
 	}
 }
 
+func TestPluginClientBuilder_Negotiate(t *testing.T) {
+	cases := []struct {
+		name                 string
+		version              string
+		capabilities         []string
+		required             []string
+		expectedMissing      []string
+		expectedIncompatible bool
+	}{
+		{
+			name:    "provider implements the driver version",
+			version: "v1alpha1",
+		},
+		{
+			name:    "provider doesn't set the version",
+			version: "",
+		},
+		{
+			name:                 "provider implements another version",
+			version:              "v2",
+			expectedIncompatible: true,
+		},
+		{
+			name:         "provider supports the required capabilities",
+			version:      "v1alpha1",
+			capabilities: []string{v1alpha1.CapabilityMountStream, v1alpha1.CapabilityObjectTTL},
+			required:     []string{v1alpha1.CapabilityMountStream},
+		},
+		{
+			name:            "provider doesn't support the required capabilities",
+			version:         "v1alpha1",
+			capabilities:    []string{v1alpha1.CapabilityMountStream},
+			required:        []string{v1alpha1.CapabilityMountStream, v1alpha1.CapabilityPerObjectErrors},
+			expectedMissing: []string{v1alpha1.CapabilityPerObjectErrors},
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			socketPath := t.TempDir()
+
+			pool := NewPluginClientBuilder([]string{socketPath})
+			defer pool.Cleanup()
+
+			server, cleanup := fakeServer(t, socketPath, "provider1")
+			defer cleanup()
+
+			server.SetVersion(test.version)
+			server.SetCapabilities(test.capabilities)
+			if err := server.Start(); err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+
+			info, err := pool.Negotiate(context.TODO(), "provider1")
+			if test.expectedIncompatible {
+				if !errors.Is(err, errIncompatibleProvider) {
+					t.Fatalf("expected incompatible provider error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if info.RuntimeVersion != "0.0.10" {
+				t.Errorf("expected runtime version: 0.0.10, got: %s", info.RuntimeVersion)
+			}
+			if diff := cmp.Diff(test.expectedMissing, info.MissingCapabilities(test.required)); diff != "" {
+				t.Errorf("MissingCapabilities() mismatch (-want +got):\n%s", diff)
+			}
+
+			// the result is cached
+			server.SetCapabilities(nil)
+			cached, err := pool.Negotiate(context.TODO(), "provider1")
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if cached != info {
+				t.Errorf("expected the negotiated provider info to be cached")
+			}
+		})
+	}
+}
+
 func TestPluginClientBuilder_HealthCheck(t *testing.T) {
 	// this test asserts the read lock and unlock semantics in the
 	// HealthCheck() method work as expected
@@ -543,7 +1199,7 @@ func TestPluginClientBuilder_HealthCheck(t *testing.T) {
 	}
 
 	// run the provider healthcheck
-	go cb.HealthCheck(ctx, healthCheckInterval)
+	go cb.HealthCheck(ctx, healthCheckInterval, 3, mocks.NewFakeReporter())
 	var wg sync.WaitGroup
 
 	// try a concurrent get with the healthcheck running in the background
@@ -560,6 +1216,60 @@ func TestPluginClientBuilder_HealthCheck(t *testing.T) {
 	wg.Wait()
 }
 
+func TestPluginClientBuilder_CheckHealth(t *testing.T) {
+	path := t.TempDir()
+
+	cb := NewPluginClientBuilder([]string{path})
+	defer cb.Cleanup()
+	ctx := context.Background()
+	reporter := mocks.NewFakeReporter()
+
+	provider := "server"
+	server, cleanup := fakeServer(t, path, provider)
+	defer cleanup()
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if _, err := cb.Get(ctx, provider); err != nil {
+		t.Fatalf("Get(%q) = %v, want nil", provider, err)
+	}
+
+	// the healthcheck fails because the provider implements another version
+	server.SetVersion("v2")
+	cb.checkHealth(ctx, 2, reporter)
+	if err := cb.Available(provider); err != nil {
+		t.Fatalf("expected provider to be available after a single failure, got: %v", err)
+	}
+	cb.checkHealth(ctx, 2, reporter)
+	if err := cb.Available(provider); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable error, got: %v", err)
+	}
+	// the connection is closed so the provider is reconnected on the next healthcheck
+	cb.lock.RLock()
+	_, connected := cb.clients[provider]
+	cb.lock.RUnlock()
+	if connected {
+		t.Errorf("expected the unhealthy provider to be disconnected")
+	}
+
+	// the provider recovers
+	server.SetVersion("v1alpha1")
+	cb.checkHealth(ctx, 2, reporter)
+	if err := cb.Available(provider); err != nil {
+		t.Fatalf("expected provider to be available, got: %v", err)
+	}
+	cb.lock.RLock()
+	h := *cb.health[provider]
+	_, connected = cb.clients[provider]
+	cb.lock.RUnlock()
+	if !connected || !h.healthy || h.consecutiveFailures != 0 || h.lastSuccess.IsZero() {
+		t.Errorf("expected provider to be connected and healthy, got connected: %v, health: %+v", connected, h)
+	}
+	if got := reporter.ReportProviderHealthInvoked(); got != 3 {
+		t.Errorf("expected provider health to be reported 3 times, got: %d", got)
+	}
+}
+
 func TestIsMaxRecvMsgSizeError(t *testing.T) {
 	cases := []struct {
 		name string
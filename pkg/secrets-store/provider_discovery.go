@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/validation"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+const socketSuffix = ".sock"
+
+// Watch watches the provider socket paths and keeps the inventory of the providers
+// available on the node. The connection to a provider is dropped when its socket is
+// removed, e.g. the provider is uninstalled, or replaced, e.g. the provider restarted.
+// The inventory is logged and reported with the provider_available metric.
+//
+// This method blocks until the parent context is canceled during termination.
+func (p *PluginClientBuilder) Watch(ctx context.Context, reporter StatsReporter) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, path := range p.socketPaths {
+		if err := watcher.Add(path); err != nil {
+			klog.ErrorS(err, "failed to watch provider socket path, providers added to the path are found on the first mount", "path", path)
+		}
+	}
+	p.refreshInventory(ctx, reporter)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !strings.HasSuffix(event.Name, socketSuffix) || event.Has(fsnotify.Chmod) {
+				continue
+			}
+			klog.V(5).InfoS("provider socket changed", "socket", event.Name, "op", event.Op.String())
+			p.refreshInventory(ctx, reporter)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.ErrorS(err, "provider socket watcher error")
+		}
+	}
+}
+
+// Providers returns the names of the providers available on the node
+func (p *PluginClientBuilder) Providers() []string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return sets.List(sets.KeySet(p.inventory))
+}
+
+// refreshInventory lists the provider sockets in the socket paths and updates the
+// inventory. The connection to a provider is dropped if its socket was removed or
+// replaced since the last refresh.
+func (p *PluginClientBuilder) refreshInventory(ctx context.Context, reporter StatsReporter) {
+	inventory := make(map[string]os.FileInfo)
+	for _, path := range p.socketPaths {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				klog.ErrorS(err, "failed to list provider sockets", "path", path)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			provider, ok := strings.CutSuffix(entry.Name(), socketSuffix)
+			if !ok || !validation.IsValidProviderName(provider) {
+				continue
+			}
+			if _, ok := inventory[provider]; ok {
+				continue
+			}
+			// the socket in the first socket path is used like in Get
+			if _, info := p.findSocket(provider); info != nil {
+				inventory[provider] = info
+			}
+		}
+	}
+
+	var removed, replaced []string
+	p.lock.Lock()
+	previous := p.inventory
+	for provider, info := range previous {
+		current, ok := inventory[provider]
+		if !ok {
+			removed = append(removed, provider)
+			p.disconnectLocked(provider)
+			delete(p.health, provider)
+			continue
+		}
+		if !sameSocket(info, current) {
+			replaced = append(replaced, provider)
+			p.disconnectLocked(provider)
+		}
+	}
+	p.inventory = inventory
+	p.lock.Unlock()
+
+	for _, provider := range removed {
+		reporter.ReportProviderAvailable(ctx, provider, false)
+	}
+	for provider := range inventory {
+		reporter.ReportProviderAvailable(ctx, provider, true)
+	}
+
+	if len(removed) > 0 || len(replaced) > 0 || !sets.KeySet(previous).Equal(sets.KeySet(inventory)) {
+		klog.InfoS("provider inventory updated", "providers", sets.List(sets.KeySet(inventory)), "removed", removed, "replaced", replaced)
+	}
+}
+
+// sameSocket returns true if the file infos are of the same socket. The modification
+// time is compared as well because the inode of a removed socket can be reused.
+func sameSocket(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime())
+}
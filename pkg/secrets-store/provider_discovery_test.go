@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/secrets-store/mocks"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestRefreshInventory(t *testing.T) {
+	path := t.TempDir()
+
+	cb := NewPluginClientBuilder([]string{path})
+	defer cb.Cleanup()
+	ctx := context.Background()
+	reporter := mocks.NewFakeReporter()
+
+	server, cleanup := fakeServer(t, path, "provider1")
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	// files that are not provider sockets are ignored
+	if err := os.WriteFile(path+"/provider.txt", nil, 0600); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	// negotiate so the server is serving before it's stopped
+	if _, err := cb.Negotiate(ctx, "provider1"); err != nil {
+		t.Fatalf("Negotiate() = %v, want nil", err)
+	}
+
+	cb.refreshInventory(ctx, reporter)
+	if got, want := cb.Providers(), []string{"provider1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Providers() = %v, want %v", got, want)
+	}
+	if available, _ := reporter.ProviderAvailable("provider1"); !available {
+		t.Errorf("expected provider1 to be reported available")
+	}
+	if !isConnected(cb, "provider1") {
+		t.Errorf("expected provider1 to stay connected")
+	}
+
+	// the provider restarts with a new socket
+	cleanup()
+	server, cleanup = fakeServer(t, path, "provider1")
+	defer cleanup()
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	cb.refreshInventory(ctx, reporter)
+	if got, want := cb.Providers(), []string{"provider1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Providers() = %v, want %v", got, want)
+	}
+	if isConnected(cb, "provider1") {
+		t.Errorf("expected the stale provider1 connection to be dropped")
+	}
+	if _, err := cb.Get(ctx, "provider1"); err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	// the provider is uninstalled
+	cleanup()
+	cb.refreshInventory(ctx, reporter)
+	if got := cb.Providers(); len(got) != 0 {
+		t.Errorf("Providers() = %v, want empty", got)
+	}
+	if available, reported := reporter.ProviderAvailable("provider1"); available || !reported {
+		t.Errorf("expected provider1 to be reported unavailable")
+	}
+	if isConnected(cb, "provider1") {
+		t.Errorf("expected the provider1 connection to be dropped")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := t.TempDir()
+
+	cb := NewPluginClientBuilder([]string{path})
+	defer cb.Cleanup()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := cb.Watch(ctx, mocks.NewFakeReporter()); err != nil {
+			t.Errorf("Watch() = %v, want nil", err)
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForProviders := func(want []string) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			return reflect.DeepEqual(cb.Providers(), want), nil
+		})
+		if err != nil {
+			t.Fatalf("expected providers %v, got: %v", want, cb.Providers())
+		}
+	}
+
+	server, cleanup := fakeServer(t, path, "provider1")
+	if err := server.Start(); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	waitForProviders([]string{"provider1"})
+
+	cleanup()
+	waitForProviders([]string{})
+}
+
+func isConnected(cb *PluginClientBuilder, provider string) bool {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	_, ok := cb.clients[provider]
+	return ok
+}
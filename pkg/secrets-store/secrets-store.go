@@ -21,8 +21,11 @@ import (
 	"os"
 	"time"
 
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/certmonitor"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/contentcache"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/version"
 
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	mount "k8s.io/mount-utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,6 +41,10 @@ type SecretsStore struct {
 	ids *identityServer
 }
 
+// trackMountedVolumesTimeout is the timeout to track the certificates of the volumes
+// mounted before the driver started
+const trackMountedVolumesTimeout = 30 * time.Second
+
 // rotationConfig stores the information required to rotate the secrets.
 type rotationConfig struct {
 	enabled               bool
@@ -47,17 +54,15 @@ type rotationConfig struct {
 func NewSecretsStoreDriver(driverName, nodeID, endpoint string,
 	providerClients *PluginClientBuilder,
 	client client.Client,
-	reader client.Reader, rotationEnabled bool, rotationPollInterval time.Duration) *SecretsStore {
+	reader client.Reader, rotationEnabled bool, rotationPollInterval time.Duration,
+	contentCache *contentcache.Cache,
+	certMonitor *certmonitor.Monitor,
+	statsReporter StatsReporter,
+	recorder record.EventRecorder) *SecretsStore {
 	klog.InfoS("Initializing Secrets Store CSI Driver", "driver", driverName, "version", version.BuildVersion, "buildTime", version.BuildTime)
 
-	sr, err := NewStatsReporter()
-	if err != nil {
-		klog.ErrorS(err, "failed to initialize stats reporter")
-		os.Exit(1)
-	}
-
 	rc := newRotationConfig(rotationEnabled, rotationPollInterval)
-	ns, err := newNodeServer(nodeID, mount.New(""), providerClients, client, reader, sr, rc)
+	ns, err := newNodeServer(nodeID, mount.New(""), providerClients, client, reader, statsReporter, rc, contentCache, certMonitor, recorder)
 	if err != nil {
 		klog.ErrorS(err, "failed to initialize node server")
 		os.Exit(1)
@@ -77,7 +82,10 @@ func newNodeServer(nodeID string,
 	client client.Client,
 	reader client.Reader,
 	statsReporter StatsReporter,
-	rotationConfig *rotationConfig) (*nodeServer, error) {
+	rotationConfig *rotationConfig,
+	contentCache *contentcache.Cache,
+	certMonitor *certmonitor.Monitor,
+	recorder record.EventRecorder) (*nodeServer, error) {
 	return &nodeServer{
 		mounter:         mounter,
 		reporter:        statsReporter,
@@ -86,6 +94,9 @@ func newNodeServer(nodeID string,
 		reader:          reader,
 		providerClients: providerClients,
 		rotationConfig:  rotationConfig,
+		contentCache:    contentCache,
+		certMonitor:     certMonitor,
+		recorder:        recorder,
 	}, nil
 }
 
@@ -98,6 +109,11 @@ func newRotationConfig(enabled bool, interval time.Duration) *rotationConfig {
 
 // Run starts the CSI plugin
 func (s *SecretsStore) Run(ctx context.Context) {
+	// the mounted volumes are tracked before the node server starts, so they aren't tracked
+	// again after they are published or unpublished
+	trackCtx, cancel := context.WithTimeout(ctx, trackMountedVolumesTimeout)
+	s.ns.trackMountedVolumes(trackCtx)
+	cancel()
 	server := NewNonBlockingGRPCServer()
 	server.Start(ctx, s.endpoint, s.ids, s.cs, s.ns)
 	server.Wait()
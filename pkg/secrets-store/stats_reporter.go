@@ -47,6 +47,11 @@ type reporter struct {
 	rotationReconcileTotal      metric.Int64Counter
 	rotationReconcileErrorTotal metric.Int64Counter
 	rotationReconcileDuration   metric.Float64Histogram
+	contentCacheFallbackTotal   metric.Int64Counter
+	providerHealthy             metric.Int64Gauge
+	providerHealthFailures      metric.Int64Gauge
+	providerAvailable           metric.Int64Gauge
+	providerPeerVerificationErr metric.Int64Counter
 }
 
 type StatsReporter interface {
@@ -59,6 +64,10 @@ type StatsReporter interface {
 	ReportRotationCtMetric(ctx context.Context, provider string, wasRotated bool)
 	ReportRotationErrorCtMetric(ctx context.Context, provider, errType string, wasRotated bool)
 	ReportRotationDuration(ctx context.Context, duration float64)
+	ReportContentCacheFallbackCtMetric(ctx context.Context, provider string)
+	ReportProviderHealth(ctx context.Context, provider string, healthy bool, consecutiveFailures int)
+	ReportProviderAvailable(ctx context.Context, provider string, available bool)
+	ReportProviderPeerVerificationFailure(ctx context.Context, provider string)
 }
 
 func NewStatsReporter() (StatsReporter, error) {
@@ -95,6 +104,22 @@ func NewStatsReporter() (StatsReporter, error) {
 		return nil, err
 	}
 
+	if r.contentCacheFallbackTotal, err = meter.Int64Counter("node_publish_content_cache_fallback", metric.WithDescription("Total number of node publish calls that mounted cached content because the provider failed")); err != nil {
+		return nil, err
+	}
+	if r.providerHealthy, err = meter.Int64Gauge("provider_healthy", metric.WithDescription("Whether the provider is healthy (1) or unhealthy (0) as observed by the provider health check")); err != nil {
+		return nil, err
+	}
+	if r.providerHealthFailures, err = meter.Int64Gauge("provider_health_check_consecutive_failures", metric.WithDescription("Number of consecutive failed provider health checks")); err != nil {
+		return nil, err
+	}
+	if r.providerAvailable, err = meter.Int64Gauge("provider_available", metric.WithDescription("Whether the provider socket is available (1) or not (0) on the node")); err != nil {
+		return nil, err
+	}
+	if r.providerPeerVerificationErr, err = meter.Int64Counter("provider_peer_verification_failure", metric.WithDescription("Total number of mounts refused because the provider process or socket file didn't match the expected identity")); err != nil {
+		return nil, err
+	}
+
 	return r, nil
 }
 
@@ -169,3 +194,44 @@ func (r *reporter) ReportRotationDuration(ctx context.Context, duration float64)
 	)
 	r.rotationReconcileDuration.Record(ctx, duration, opt)
 }
+
+func (r *reporter) ReportContentCacheFallbackCtMetric(ctx context.Context, provider string) {
+	opt := metric.WithAttributes(
+		attribute.Key(providerKey).String(provider),
+		attribute.Key(osTypeKey).String(runtimeOS),
+	)
+	r.contentCacheFallbackTotal.Add(ctx, 1, opt)
+}
+
+func (r *reporter) ReportProviderHealth(ctx context.Context, provider string, healthy bool, consecutiveFailures int) {
+	opt := metric.WithAttributes(
+		attribute.Key(providerKey).String(provider),
+		attribute.Key(osTypeKey).String(runtimeOS),
+	)
+	var value int64
+	if healthy {
+		value = 1
+	}
+	r.providerHealthy.Record(ctx, value, opt)
+	r.providerHealthFailures.Record(ctx, int64(consecutiveFailures), opt)
+}
+
+func (r *reporter) ReportProviderAvailable(ctx context.Context, provider string, available bool) {
+	opt := metric.WithAttributes(
+		attribute.Key(providerKey).String(provider),
+		attribute.Key(osTypeKey).String(runtimeOS),
+	)
+	var value int64
+	if available {
+		value = 1
+	}
+	r.providerAvailable.Record(ctx, value, opt)
+}
+
+func (r *reporter) ReportProviderPeerVerificationFailure(ctx context.Context, provider string) {
+	opt := metric.WithAttributes(
+		attribute.Key(providerKey).String(provider),
+		attribute.Key(osTypeKey).String(runtimeOS),
+	)
+	r.providerPeerVerificationErr.Add(ctx, 1, opt)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/secretutil"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fileTemplates are the templates of the template ConfigMap of a secret provider class
+type fileTemplates struct {
+	// templates are the template texts, by the path of the rendered file
+	templates map[string]string
+	// mode is the mode of the rendered files
+	mode int32
+}
+
+// getFileTemplates returns the templates of the template ConfigMap referenced by the
+// secret provider class, or nil if it doesn't reference one. The ConfigMap is read from
+// the API server so changes are picked up by the next rotation.
+func getFileTemplates(ctx context.Context, reader client.Reader, spc *secretsstorev1.SecretProviderClass) (*fileTemplates, error) {
+	ref := spc.Spec.TemplateConfigMap
+	if ref == nil {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: spc.Namespace, Name: ref.Name}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get template configmap %s/%s, error: %w", spc.Namespace, ref.Name, err)
+	}
+	mode := int32(filePermission)
+	if ref.DefaultMode != nil {
+		mode = *ref.DefaultMode
+	}
+	return &fileTemplates{templates: cm.Data, mode: mode}, nil
+}
+
+// renderTemplates renders the templates with the contents of the files, by path, and
+// returns the rendered files sorted by path
+func renderTemplates(files []*v1alpha1.File, templates *fileTemplates) ([]*v1alpha1.File, error) {
+	if templates == nil || len(templates.templates) == 0 {
+		return nil, nil
+	}
+	objects := make(map[string]string, len(files))
+	for _, file := range files {
+		objects[file.GetPath()] = string(file.GetContents())
+	}
+
+	paths := make([]string, 0, len(templates.templates))
+	for path := range templates.templates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	rendered := make([]*v1alpha1.File, 0, len(paths))
+	for _, path := range paths {
+		if _, ok := objects[path]; ok {
+			return nil, fmt.Errorf("rendered file %s conflicts with an object returned by the provider", path)
+		}
+		contents, err := secretutil.RenderTemplate(path, templates.templates[path], objects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template %s, err: %w", path, err)
+		}
+		rendered = append(rendered, &v1alpha1.File{Path: path, Mode: templates.mode, Contents: contents})
+	}
+	return rendered, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsstore
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetFileTemplates(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "templates", Namespace: "default"},
+		Data:       map[string]string{"app.conf": "password={{ .password }}"},
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+
+	tests := []struct {
+		name              string
+		templateConfigMap *secretsstorev1.TemplateConfigMap
+		expected          *fileTemplates
+		expectedError     string
+	}{
+		{
+			name: "no template configmap",
+		},
+		{
+			name:              "default mode",
+			templateConfigMap: &secretsstorev1.TemplateConfigMap{Name: "templates"},
+			expected:          &fileTemplates{templates: cm.Data, mode: 0644},
+		},
+		{
+			name:              "mode",
+			templateConfigMap: &secretsstorev1.TemplateConfigMap{Name: "templates", DefaultMode: int32Ptr(0400)},
+			expected:          &fileTemplates{templates: cm.Data, mode: 0400},
+		},
+		{
+			name:              "template configmap not found",
+			templateConfigMap: &secretsstorev1.TemplateConfigMap{Name: "missing"},
+			expectedError:     "failed to get template configmap default/missing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spc := &secretsstorev1.SecretProviderClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "spc", Namespace: "default"},
+				Spec:       secretsstorev1.SecretProviderClassSpec{TemplateConfigMap: test.templateConfigMap},
+			}
+			templates, err := getFileTemplates(context.TODO(), reader, spc)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(templates, test.expected) {
+				t.Fatalf("expected templates %+v, got %+v", test.expected, templates)
+			}
+		})
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	files := []*v1alpha1.File{
+		{Path: "username", Mode: 0644, Contents: []byte("admin")},
+		{Path: "db/password", Mode: 0644, Contents: []byte("secret")},
+	}
+
+	tests := []struct {
+		name          string
+		templates     *fileTemplates
+		expectedFiles []*v1alpha1.File
+		expectedError string
+	}{
+		{
+			name: "no templates",
+		},
+		{
+			name: "templates",
+			templates: &fileTemplates{
+				templates: map[string]string{
+					"application.yaml": "user: {{ .username }}\npassword: {{ index . \"db/password\" | quote }}\n",
+					"nginx.conf":       "auth {{ .username | upper }};",
+				},
+				mode: 0400,
+			},
+			expectedFiles: []*v1alpha1.File{
+				{Path: "application.yaml", Mode: 0400, Contents: []byte("user: admin\npassword: \"secret\"\n")},
+				{Path: "nginx.conf", Mode: 0400, Contents: []byte("auth ADMIN;")},
+			},
+		},
+		{
+			name:          "missing object",
+			templates:     &fileTemplates{templates: map[string]string{"app.conf": "{{ .password }}"}, mode: 0644},
+			expectedError: `failed to render template app.conf, err: template: app.conf:1:3: executing "app.conf" at <.password>: map has no entry for key "password"`,
+		},
+		{
+			name:          "invalid template",
+			templates:     &fileTemplates{templates: map[string]string{"app.conf": "{{ .username "}, mode: 0644},
+			expectedError: "failed to render template app.conf",
+		},
+		{
+			name:          "conflicting path",
+			templates:     &fileTemplates{templates: map[string]string{"username": "{{ .username }}"}, mode: 0644},
+			expectedError: "rendered file username conflicts with an object returned by the provider",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := renderTemplates(files, test.templates)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if len(rendered) != len(test.expectedFiles) {
+				t.Fatalf("expected %d rendered files, got %v", len(test.expectedFiles), rendered)
+			}
+			for i := range rendered {
+				if !reflect.DeepEqual(rendered[i], test.expectedFiles[i]) {
+					t.Fatalf("expected file %v, got %v", test.expectedFiles[i], rendered[i])
+				}
+			}
+		})
+	}
+}
@@ -18,18 +18,25 @@ package secretsstore
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/contentcache"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/fileutil"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/runtimeutil"
 	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/spcpsutil"
+	"sigs.k8s.io/secrets-store-csi-driver/provider/v1alpha1"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -97,12 +104,43 @@ func getSecretProviderItem(ctx context.Context, c client.Client, name, namespace
 	return spc, nil
 }
 
+// contentCacheKey returns the content cache key for the secret provider class, the
+// service account of the pod, the nodePublishSecretRef data and the file options of the
+// volume. The key changes with everything that changes the mounted files, i.e. the spec
+// of the secret provider class except the secret objects and required capabilities, the
+// templates and the selected objects, so content cached for a previous version of the
+// secret provider class or for other credentials isn't used. The key is a hash, the
+// secrets are not stored in it.
+func contentCacheKey(spc *secretsstorev1.SecretProviderClass, serviceAccount string, secrets map[string]string, opts fileOptions) string {
+	spec := spc.Spec.DeepCopy()
+	spec.SecretObjects = nil
+	spec.RequiredProviderCapabilities = nil
+	// json.Marshal sorts the map keys, so the spec, secrets and templates are encoded the
+	// same way every time
+	specJSON, _ := json.Marshal(spec)
+	secretsJSON, _ := json.Marshal(secrets)
+	parts := []string{spc.Namespace, spc.Name, serviceAccount, string(secretsJSON), string(specJSON), strings.Join(opts.selectedObjects, ",")}
+	if opts.templates != nil {
+		templatesJSON, _ := json.Marshal(opts.templates.templates)
+		parts = append(parts, string(templatesJSON), strconv.FormatInt(int64(opts.templates.mode), 8))
+	}
+	return contentcache.Key(parts...)
+}
+
+// secretProviderClassPodStatusName returns the name of the secret provider class pod status
+// for the pod and secret provider class
+func secretProviderClassPodStatusName(podname, namespace, spcName string) string {
+	return podname + "-" + namespace + "-" + spcName
+}
+
 // createOrUpdateSecretProviderClassPodStatus creates secret provider class pod status if not exists.
 // if the secret provider class pod status already exists, it'll update the status and owner references.
-func createOrUpdateSecretProviderClassPodStatus(ctx context.Context, c client.Client, reader client.Reader, podname, namespace, podUID, spcName, targetPath, nodeID string, mounted bool, objects map[string]string) error {
+// The conditions are merged into the existing conditions, preserving the last transition time
+// of conditions whose status did not change.
+func createOrUpdateSecretProviderClassPodStatus(ctx context.Context, c client.Client, reader client.Reader, podname, namespace, podUID, spcName, targetPath, nodeID string, mounted bool, objects map[string]string, conditions ...metav1.Condition) error {
 	var o []secretsstorev1.SecretProviderClassObject
 	var err error
-	spcpsName := podname + "-" + namespace + "-" + spcName
+	spcpsName := secretProviderClassPodStatusName(podname, namespace, spcName)
 
 	for k, v := range objects {
 		o = append(o, secretsstorev1.SecretProviderClassObject{ID: k, Version: v})
@@ -123,6 +161,9 @@ func createOrUpdateSecretProviderClassPodStatus(ctx context.Context, c client.Cl
 			Objects:                 o,
 		},
 	}
+	for _, condition := range conditions {
+		meta.SetStatusCondition(&spcPodStatus.Status.Conditions, condition)
+	}
 
 	// Set owner reference to the pod as the mapping between secret provider class pod status and
 	// pod is 1 to 1. When pod is deleted, the spc pod status will automatically be garbage collected
@@ -140,25 +181,69 @@ func createOrUpdateSecretProviderClassPodStatus(ctx context.Context, c client.Cl
 	}
 	klog.InfoS("secret provider class pod status already exists, updating it", "spcps", klog.ObjectRef{Name: spcPodStatus.Name, Namespace: spcPodStatus.Namespace})
 
-	spcps := &secretsstorev1.SecretProviderClassPodStatus{}
-	// the secret provider class pod status with the name already exists, update it
-	if err = c.Get(ctx, client.ObjectKey{Name: spcpsName, Namespace: namespace}, spcps); err != nil {
-		if !apierrors.IsNotFound(err) {
-			return err
+	// the secret provider class pod status with the name already exists, update it. The
+	// controller could update the conditions concurrently, so retry on conflicts.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		spcps := &secretsstorev1.SecretProviderClassPodStatus{}
+		if err := c.Get(ctx, client.ObjectKey{Name: spcpsName, Namespace: namespace}, spcps); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			// the secret provider class pod status could be missing in the cache because it was labeled with a different node
+			// label, so we need to get it from the API server
+			if err = reader.Get(ctx, client.ObjectKey{Name: spcpsName, Namespace: namespace}, spcps); err != nil {
+				return err
+			}
 		}
-		// the secret provider class pod status could be missing in the cache because it was labeled with a different node
-		// label, so we need to get it from the API server
-		if err = reader.Get(ctx, client.ObjectKey{Name: spcpsName, Namespace: namespace}, spcps); err != nil {
-			return err
+
+		// update the labels of the secret provider class pod status to match the node label
+		spcps.Labels[secretsstorev1.InternalNodeLabel] = nodeID
+		existingConditions := spcps.Status.Conditions
+		spcps.Status = spcPodStatus.Status
+		spcps.Status.Conditions = existingConditions
+		for _, condition := range conditions {
+			meta.SetStatusCondition(&spcps.Status.Conditions, condition)
 		}
-	}
+		spcps.OwnerReferences = spcPodStatus.OwnerReferences
 
-	// update the labels of the secret provider class pod status to match the node label
-	spcps.Labels[secretsstorev1.InternalNodeLabel] = nodeID
-	spcps.Status = spcPodStatus.Status
-	spcps.OwnerReferences = spcPodStatus.OwnerReferences
+		return c.Update(ctx, spcps)
+	})
+}
 
-	return c.Update(ctx, spcps)
+// updateSecretProviderClassPodStatusConditions merges the conditions into the existing
+// secret provider class pod status without modifying any other field.
+func updateSecretProviderClassPodStatusConditions(ctx context.Context, c client.Client, reader client.Reader, name, namespace string, conditions ...metav1.Condition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		spcps := &secretsstorev1.SecretProviderClassPodStatus{}
+		if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, spcps); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			if err = reader.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, spcps); err != nil {
+				return err
+			}
+		}
+		changed := false
+		for _, condition := range conditions {
+			if meta.SetStatusCondition(&spcps.Status.Conditions, condition) {
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return c.Update(ctx, spcps)
+	})
+}
+
+// newCondition returns a condition with the given type, status, reason and message
+func newCondition(conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
 }
 
 // getProviderFromSPC returns the provider as defined in SecretProviderClass
@@ -177,6 +262,76 @@ func getParametersFromSPC(spc *secretsstorev1.SecretProviderClass) (map[string]s
 	return spc.Spec.Parameters, nil
 }
 
+// getRequiredProviderCapabilitiesFromSPC returns the provider capabilities required by the secret provider class
+func getRequiredProviderCapabilitiesFromSPC(spc *secretsstorev1.SecretProviderClass) []string {
+	var capabilities []string
+	for _, c := range spc.Spec.RequiredProviderCapabilities {
+		capabilities = append(capabilities, string(c))
+	}
+	return capabilities
+}
+
+// getObjectEncodingsFromSPC returns the encodings declared for the objects in the
+// secret provider class, by object name
+func getObjectEncodingsFromSPC(spc *secretsstorev1.SecretProviderClass) map[string]secretsstorev1.ObjectEncoding {
+	encodings := make(map[string]secretsstorev1.ObjectEncoding)
+	for _, obj := range spc.Spec.Objects {
+		if len(obj.Encoding) > 0 {
+			encodings[obj.ObjectName] = obj.Encoding
+		}
+	}
+	return encodings
+}
+
+// getObjectExplodesFromSPC returns the explode options declared for the objects in the
+// secret provider class, by object name
+func getObjectExplodesFromSPC(spc *secretsstorev1.SecretProviderClass) map[string]*secretsstorev1.ObjectExplode {
+	explodes := make(map[string]*secretsstorev1.ObjectExplode)
+	for _, obj := range spc.Spec.Objects {
+		if obj.Explode != nil {
+			explodes[obj.ObjectName] = obj.Explode
+		}
+	}
+	return explodes
+}
+
+// getSelectedObjects returns the names of the objects selected by the volume with the
+// comma separated selectedObjects volume attribute
+func getSelectedObjects(attrib map[string]string) []string {
+	var names []string
+	for _, name := range strings.Split(attrib[selectedObjectsField], ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateFilePaths ensures the paths of the files written to the mount are well
+// formatted and unique
+func validateFilePaths(files []*v1alpha1.File) error {
+	if err := fileutil.Validate(files); err != nil {
+		return err
+	}
+	paths := make(map[string]bool, len(files))
+	for _, file := range files {
+		if paths[file.GetPath()] {
+			return fmt.Errorf("file %s is written more than once", file.GetPath())
+		}
+		paths[file.GetPath()] = true
+	}
+	return nil
+}
+
+// fileContents returns the contents of the files written to the mount, by path
+func fileContents(files []*v1alpha1.File) map[string][]byte {
+	contents := make(map[string][]byte, len(files))
+	for _, file := range files {
+		contents[file.GetPath()] = file.GetContents()
+	}
+	return contents
+}
+
 // isMockProvider returns true if the provider is mock
 func isMockProvider(provider string) bool {
 	return strings.EqualFold(provider, "mock_provider")
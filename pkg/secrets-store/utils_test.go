@@ -161,3 +161,134 @@ func TestCreateOrUpdateSecretProviderClassPodStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateSecretProviderClassPodStatusConditions(t *testing.T) {
+	scheme, _ := setupScheme()
+	spcpsName := fmt.Sprintf("%s-%s-%s", testPodName, testNamespace, testSPCName)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newSecretProviderClassPodStatus(spcpsName, testNamespace, "test-node")).Build()
+
+	mounted := newCondition(secretsstorev1.ConditionTypeMounted, metav1.ConditionTrue, secretsstorev1.ReasonMountSucceeded, "mounted")
+	if err := updateSecretProviderClassPodStatusConditions(context.TODO(), client, client, spcpsName, testNamespace, mounted); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := &secretsstorev1.SecretProviderClassPodStatus{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: spcpsName, Namespace: testNamespace}, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	transitionTime := got.Status.Conditions[0].LastTransitionTime
+
+	// a failed rotation only updates the content condition and preserves the rest of the status
+	stale := newCondition(secretsstorev1.ConditionTypeContentUpToDate, metav1.ConditionFalse, "GRPCProviderError", "rpc error")
+	if err := updateSecretProviderClassPodStatusConditions(context.TODO(), client, client, spcpsName, testNamespace, mounted, stale); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: spcpsName, Namespace: testNamespace}, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !got.Status.Mounted {
+		t.Errorf("Status.Mounted got: false, want: true")
+	}
+	if len(got.Status.Conditions) != 2 {
+		t.Fatalf("Status.Conditions got: %v, want 2 conditions", got.Status.Conditions)
+	}
+	if c := got.Status.Conditions[0]; c.Type != secretsstorev1.ConditionTypeMounted || !c.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("Mounted condition got: %v, want last transition time %v", c, transitionTime)
+	}
+	if c := got.Status.Conditions[1]; c.Type != secretsstorev1.ConditionTypeContentUpToDate || c.Status != metav1.ConditionFalse || c.Reason != "GRPCProviderError" {
+		t.Errorf("ContentUpToDate condition got: %v, want: %v", c, stale)
+	}
+}
+
+func TestContentCacheKey(t *testing.T) {
+	spc := &secretsstorev1.SecretProviderClass{
+		ObjectMeta: metav1.ObjectMeta{Name: testSPCName, Namespace: testNamespace},
+		Spec: secretsstorev1.SecretProviderClassSpec{
+			Provider:   "provider1",
+			Parameters: map[string]string{"objects": "foo"},
+		},
+	}
+	secrets := map[string]string{"clientid": "id", "clientsecret": "secret"}
+	key := contentCacheKey(spc, "sa", secrets, fileOptions{})
+
+	tests := []struct {
+		name        string
+		mutate      func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions)
+		expectEqual bool
+	}{
+		{
+			name:        "unchanged",
+			mutate:      func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {},
+			expectEqual: true,
+		},
+		{
+			name: "secret objects changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.SecretObjects = []*secretsstorev1.SecretObject{{SecretName: "foo", Type: "Opaque"}}
+			},
+			expectEqual: true,
+		},
+		{
+			name: "node publish secret changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				secrets["clientsecret"] = "rotated"
+			},
+		},
+		{
+			name: "parameters changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.Parameters["objects"] = "bar"
+			},
+		},
+		{
+			name: "encoding changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.Objects = []secretsstorev1.MountObject{{ObjectName: "foo", Encoding: secretsstorev1.ObjectEncodingBase64}}
+			},
+		},
+		{
+			name: "files changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.Files = []secretsstorev1.ObjectFile{{ObjectName: "foo", Path: "bar"}}
+			},
+		},
+		{
+			name: "derived files changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.DerivedFiles = []secretsstorev1.DerivedFile{{Path: "keystore.p12"}}
+			},
+		},
+		{
+			name: "template configmap changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				spc.Spec.TemplateConfigMap = &secretsstorev1.TemplateConfigMap{Name: "templates"}
+			},
+		},
+		{
+			name: "templates changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				opts.templates = &fileTemplates{templates: map[string]string{"app.conf": "{{ .foo }}"}, mode: 0644}
+			},
+		},
+		{
+			name: "selected objects changed",
+			mutate: func(spc *secretsstorev1.SecretProviderClass, secrets map[string]string, opts *fileOptions) {
+				opts.selectedObjects = []string{"foo"}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutatedSPC := spc.DeepCopy()
+			mutatedSecrets := map[string]string{}
+			for k, v := range secrets {
+				mutatedSecrets[k] = v
+			}
+			opts := fileOptions{}
+			test.mutate(mutatedSPC, mutatedSecrets, &opts)
+			if got := contentCacheKey(mutatedSPC, "sa", mutatedSecrets, opts); (got == key) != test.expectEqual {
+				t.Errorf("contentCacheKey() equal got: %v, want: %v", got == key, test.expectEqual)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+	"k8s.io/klog/v2"
+)
+
+// certChain is the certificate chain of a PEM or PKCS#12 object
+type certChain struct {
+	// leaf is the certificate of the private key, or the certificate that
+	// isn't the issuer of any other certificate if there is no private key
+	leaf *pem.Block
+	// cas are the issuers of the leaf certificate, from the issuer of the
+	// leaf certificate to the root certificate
+	cas []*pem.Block
+	// dropped are the subjects of the certificates of the object that are
+	// not part of the chain
+	dropped []string
+}
+
+// certs returns the certificates of the chain, leaf first
+func (c *certChain) certs() []*pem.Block {
+	return append([]*pem.Block{c.leaf}, c.cas...)
+}
+
+// decodeBlocks returns the PEM blocks of a PEM object, or of a PKCS#12 object
+// decrypted with the password if the object isn't PEM encoded. A PKCS#12 object
+// can contain the full chain, each certificate is returned as a block.
+func decodeBlocks(data []byte, password string) ([]*pem.Block, error) {
+	var blocks []*pem.Block
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) > 0 {
+		return blocks, nil
+	}
+	return pkcs12.ToPEM(data, password)
+}
+
+// ParseCertificates returns the X.509 certificates of a PEM object, or of a PKCS#12
+// object decrypted with the password if the object isn't PEM encoded.
+func ParseCertificates(data []byte, password string) ([]*x509.Certificate, error) {
+	blocks, err := decodeBlocks(data, password)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		if block.Type != certType {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate, err: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return certs, nil
+}
+
+// parseCertChain returns the certificate chain of the object, the certificates
+// that are not part of the chain are dropped.
+func parseCertChain(data []byte, password string) (*certChain, error) {
+	blocks, err := decodeBlocks(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return newCertChain(blocks)
+}
+
+// newCertChain returns the certificate chain of the certificate and private key blocks
+func newCertChain(blocks []*pem.Block) (*certChain, error) {
+	var certBlocks []*pem.Block
+	var key crypto.PrivateKey
+	for _, block := range blocks {
+		if block.Type == certType {
+			certBlocks = append(certBlocks, block)
+			continue
+		}
+		if !isPrivateKeyBlock(block) {
+			continue
+		}
+		if k, err := parsePrivateKey(block.Bytes); err == nil {
+			key = k
+		}
+	}
+	if len(certBlocks) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return orderChain(certBlocks, key)
+}
+
+// orderChain orders the certificates leaf first and verifies that each certificate
+// is signed by the next one. The leaf certificate is the certificate of the private key,
+// or if there is no private key, the certificate with the longest chain among the
+// certificates that don't issue another certificate, preferring end-entity certificates
+// and then the first certificate of the object. If a certificate has more than one
+// issuer in the object, e.g. a cross-signed intermediate, the first issuer is used. The
+// certificates that are not part of the chain, e.g. an unrelated CA or the other path of
+// a cross-signed intermediate, are dropped.
+func orderChain(blocks []*pem.Block, key crypto.PrivateKey) (*certChain, error) {
+	certs := make([]*x509.Certificate, len(blocks))
+	for i, block := range blocks {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate, err: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	var chain []int
+	if key != nil {
+		pub, ok := publicKey(key).(interface{ Equal(crypto.PublicKey) bool })
+		for i, cert := range certs {
+			if ok && pub.Equal(cert.PublicKey) {
+				chain = chainOf(certs, i)
+				break
+			}
+		}
+		if chain == nil {
+			return nil, fmt.Errorf("no certificate matches the private key")
+		}
+	} else {
+		for i := range certs {
+			if issuesAny(certs, i) {
+				continue
+			}
+			candidate := chainOf(certs, i)
+			if chain == nil || betterLeaf(certs, candidate, chain) {
+				chain = candidate
+			}
+		}
+		if chain == nil {
+			return nil, fmt.Errorf("unable to determine the leaf certificate, every certificate is the issuer of another certificate")
+		}
+	}
+
+	result := &certChain{leaf: blocks[chain[0]]}
+	if len(chain) < len(certs) {
+		inChain := make(map[int]bool, len(chain))
+		for _, i := range chain {
+			inChain[i] = true
+		}
+		for i, cert := range certs {
+			if !inChain[i] {
+				result.dropped = append(result.dropped, cert.Subject.String())
+			}
+		}
+		klog.InfoS("dropping certificates that are not part of the chain of the leaf certificate", "leaf", certs[chain[0]].Subject.String(), "dropped", result.dropped)
+	}
+	for _, i := range chain[1:] {
+		result.cas = append(result.cas, blocks[i])
+	}
+	return result, nil
+}
+
+// chainOf returns the indexes of the certificates of the chain of the certificate, from
+// the certificate to the root certificate. The first issuer of each certificate is used.
+func chainOf(certs []*x509.Certificate, leaf int) []int {
+	chain := []int{leaf}
+	used := map[int]bool{leaf: true}
+	for current := leaf; ; {
+		next := -1
+		for i := range certs {
+			if !used[i] && isIssuer(certs[i], certs[current]) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return chain
+		}
+		chain = append(chain, next)
+		used[next] = true
+		current = next
+	}
+}
+
+// issuesAny returns true if the certificate is the issuer of another certificate
+func issuesAny(certs []*x509.Certificate, issuer int) bool {
+	for i := range certs {
+		if i != issuer && isIssuer(certs[issuer], certs[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// betterLeaf returns true if the chain of the candidate is a better choice than the chain
+// of the current leaf: an end-entity certificate over a CA certificate, then the longer
+// chain. The chains are compared in the order of the certificates, so the first one wins
+// a tie.
+func betterLeaf(certs []*x509.Certificate, candidate, current []int) bool {
+	if candidateCA, currentCA := certs[candidate[0]].IsCA, certs[current[0]].IsCA; candidateCA != currentCA {
+		return currentCA
+	}
+	return len(candidate) > len(current)
+}
+
+// isIssuer returns true if the certificate is signed by the issuer
+func isIssuer(issuer, cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, issuer.RawSubject) && cert.CheckSignatureFrom(issuer) == nil
+}
+
+// isPrivateKeyBlock returns true if the PEM block is a private key, e.g. RSA PRIVATE KEY
+func isPrivateKeyBlock(block *pem.Block) bool {
+	return strings.HasSuffix(block.Type, privateKeyType)
+}
+
+// parsePrivateKey parses a private key in PKCS #1, PKCS #8 or SEC 1, ASN.1 DER form.
+// RSA, ECDSA, Ed25519 and X25519 keys are supported.
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return k, nil
+		case *ecdh.PrivateKey:
+			if k.Curve() == ecdh.X25519() {
+				return k, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported private key type %T, only RSA, ECDSA, Ed25519 and X25519 keys are supported", key)
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key, expected an RSA, ECDSA, Ed25519 or X25519 key in PKCS #1, PKCS #8 or SEC 1 DER form")
+}
+
+// publicKey returns the public key of the private key
+func publicKey(key crypto.PrivateKey) crypto.PublicKey {
+	if k, ok := key.(interface{ Public() crypto.PublicKey }); ok {
+		return k.Public()
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// chainPFXFile is a PKCS#12 file protected with the password "password" that contains
+// the private key of the leaf certificate and the certificates leaf, root and intermediate.
+// openssl pkcs12 -export -inkey leaf.key -in leaf.crt -certfile cas.pem -certpbe PBE-SHA1-3DES -keypbe PBE-SHA1-3DES -macalg sha1
+const chainPFXFile = `MIIHCgIBAzCCBtAGCSqGSIb3DQEHAaCCBsEEgga9MIIGuTCCBa8GCSqGSIb3DQEHBqCCBaAwggWcAgEAMIIFlQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQIJSdb+4wMUpQCAggAgIIFaII6/2iMtXqoUA1J9I/ZmJvEOdelISPYSXbDmVetNdccWaYU6PBNp+4gPtLrB5pV+gv1KxJS8+4DZkD4fIp2/4Bys921ZjDPgH/CsL5AN+MfPUmwoz8fEA2KrryORoJpmizqkVuTJD7J7FRwc3yd7vXDTWWnqW30pK9LSGHngjWKr5ADIQQseyX2fAx2s6bNPpqIuGkeH7x7RGJWrojnuD3m4KE1tLv6+YfkY83Hg8vdKHRVSqGk6FtX9amYUQJa4+K0nl1sm0LI/cbXK3r2FolDopNPQZRYbXHoRDj+8vEtEL1f2gcijBw/VXTvBieF9Z7iN4giL18peLLIhkeOEnq2jgDBQttslsrc1Mg18eMtBp0O6FNpAPujAAYJ6lG37idd75vQxO+GV4pBVNxMieyGU6dPS9oOtZCdbcECN52yhiguguE+sgq72kV05MTlbaxPdi54a/ZfxH3yl3O2lqpTtdrUP/0coI0V8mxTetpMcDwxmFK/VL4WNyUVpB2H6VlXLgGhT3OZ/j1dyEHVxBwak7ygMuQTExPg7RRjAEBUH2Dj2GkU/bdiC9FJMd7jWv48E0LYZp0KFDJhTjm2NRnd7RIO6CpVWbnEBIrCRxPxrVlcOThd5MfPeyhZu5lBRENtUXJnOlTbf19Es8IXLaSvuBmZvQPdlYLqqI+1NE0XKYVtMwO9siluJMs1mTWkacSa6QT/1L1pNfUxPT8cAyV9uVF6LfSjGuUa/lIJ1SgvncQWrcT2PJ/GvrB8R72HDGyNXhHXlSIz6+gW/a0PVPAetZluTvVYuMZx5MNJnAbhD25g6VqwGDTbjYmQorXudhJPlZmuLF4ZSd6vc39IZRedkniBqAmU8uYsq/3Cu9mkmwWNZIhBlqhRfBFp3k7QuwM/nKgizQoLB5t798ngmR47labtUqQ8D1RmipYknJ7b0sDN8qYOanKAglf+ox9bDbJ6+KZdrsaMVKL6yLZpauluvGBgBEk6uyFB1Hs9jq7N2OOLJExJuTVNKi1Nzx0nk8sHRBWwuGGHSausBoeCrTRxWvgVh3zHyWKkA1fOFwX5Gnxmx+qTNvTscuDE7VSwKMb6x8pNGAgs5kfH1ADPxrwxBHTgcOUvRjSX1dgS4gvbSnTKBKE5Uq/8IqkF4IXevQmMx9c7RgXMHMwLoVAwUOpPlIQuqVJqMARfpQU9D950PJDiiWFHNAQ3t6EFKy2oT3XjOH57KUSTpIOYn9FTWLmE+vRKSRZSkjxbQwwY9ftosL19zyJHlogBsjZyRVfefedAdM3YXlOdjPvGziBg83uw4dM0hFDtQg1qveru39xaQIUPGLbMonKW+f4B9EYIKIVNsU+XaNI7xC8ZhulaR6FcoRvMNN2v6XzPgMKNIlyW7HBc1YN/U6q+76RaaaShIWRO439LnUysCCLpQvTwScnn6xnj05txjrblr7ZrFFQUygWEShIXGx90JIvKNtcCtviPAsxV+wzGY7NIWSqRPuXLwg021vfaO2jMNtOY3cmalKSjzgb+sPcX6KfNfXzVKxPbjbC8tv0MPDwLH8x5HyfGXsMNQIjDEQQnLgJnh1m6lUkUpo4hlD4iJeru7L3s1fSW6dIZFc47+bQ6SLHsEqZh1gRqZxP9lnBobEyndRirurEQihRu0AomRZngFdVvzhWvsw6tKmhJO8ugLW7IVH6JrdLYaJ1VfimlWOGJwimdDh+j6AifZFoK6Ug7XSpbUDOgSDa1B1d/NtS6EYjhTkqQ4uIWayn8BHicRQbpn3uItk1fVkA4ydc3jGW8DiX+d5NKgx2s3ulPtFAOJ4XyRkq5M+Olm9jhngDZOdoZQ1u8PzwkAMmikHcwggECBgkqhkiG9w0BBwGggfQEgfEwge4wgesGCyqGSIb3DQEMCgECoIG0MIGxMBwGCiqGSIb3DQEMAQMwDgQIROBa8FH1G0UCAggABIGQ+WWc5JGA4xK4e9THVuFsHViawyn5x26LwDDGm9e0epWBzA/e4ikTuM1MUMrfqlNR4V7WnH7R5wBdKvM+N1BxX8GLOCUtgxgIxNpgOibUQysr7mUEQYIezzYyWphjbN+z6iOO3jxtnR4Mui3YwVMG5O6c6H3b1PlL7PAR9fbBdtzlUqgZh94U6N6lm/bytqxhMSUwIwYJKoZIhvcNAQkVMRYEFEbe1GbmKufvwDNrR/HJjUH/bH2LMDEwITAJBgUrDgMCGgUABBQKQrK3xhKIcP/g2N/15cjnGLhV9QQIJfA4M2m6DXwCAggA`
+
+// testChain is a certificate chain root -> intermediate -> leaf. The intermediate
+// certificate is cross-signed by crossRoot in crossIntermediate.
+type testChain struct {
+	root, intermediate, leaf, leafKey string
+	crossRoot, crossIntermediate      string
+}
+
+func newTestChain(t *testing.T) testChain {
+	t.Helper()
+	root, rootCert, rootKey := newTestCert(t, "root", nil, nil, true)
+	intermediate, intermediateCert, intermediateKey := newTestCert(t, "intermediate", rootCert, rootKey, true)
+	leaf, _, leafKey := newTestCert(t, "leaf", intermediateCert, intermediateKey, false)
+	crossRoot, crossRootCert, crossRootKey := newTestCert(t, "cross-root", nil, nil, true)
+	crossIntermediate, _ := signTestCert(t, "intermediate", intermediateKey, crossRootCert, crossRootKey, true)
+	der, err := x509.MarshalPKCS8PrivateKey(leafKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	return testChain{
+		root:              root,
+		intermediate:      intermediate,
+		leaf:              leaf,
+		leafKey:           string(pem.EncodeToMemory(&pem.Block{Type: privateKeyType, Bytes: der})),
+		crossRoot:         crossRoot,
+		crossIntermediate: crossIntermediate,
+	}
+}
+
+// newTestCert returns a certificate signed by the parent, or a self-signed certificate
+// if parent is nil
+func newTestCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (string, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	cert, parsed := signTestCert(t, cn, key, parent, parentKey, isCA)
+	return cert, parsed, key
+}
+
+// signTestCert returns a certificate of the key signed by the parent, or a self-signed
+// certificate if parent is nil
+func signTestCert(t *testing.T, cn string, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (string, *x509.Certificate) {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: certType, Bytes: der})), cert
+}
+
+// commonNames returns the common names of the PEM encoded certificates
+func commonNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	var names []string
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return names
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		names = append(names, cert.Subject.CommonName)
+		data = rest
+	}
+}
+
+func TestGetCertPartChain(t *testing.T) {
+	chain := newTestChain(t)
+	_, _, otherKey := newTestCert(t, "other", nil, nil, false)
+	otherKeyDER, err := x509.MarshalECPrivateKey(otherKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	other, _, _ := newTestCert(t, "other", nil, nil, false)
+	otherCA, _, _ := newTestCert(t, "other-ca", nil, nil, true)
+	pfx, err := base64.StdEncoding.DecodeString(chainPFXFile)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	tests := []struct {
+		name          string
+		data          string
+		part          string
+		password      string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "chain ordered leaf first",
+			data:     chain.root + chain.leafKey + chain.leaf + chain.intermediate,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name:     "chain without private key",
+			data:     chain.intermediate + chain.root + chain.leaf,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name:     "ca certificates of the chain",
+			data:     chain.root + chain.leaf + chain.intermediate + chain.leafKey,
+			part:     "ca.crt",
+			expected: []string{"intermediate", "root"},
+		},
+		{
+			name:     "ca certificates of a chain without private key",
+			data:     chain.leaf + chain.root + chain.intermediate,
+			part:     "ca.crt",
+			expected: []string{"intermediate", "root"},
+		},
+		{
+			name:     "ca bundle",
+			data:     chain.root + chain.intermediate,
+			part:     "ca.crt",
+			expected: []string{"root", "intermediate"},
+		},
+		{
+			name:     "password protected PKCS#12 chain",
+			data:     string(pfx),
+			part:     "tls.crt",
+			password: "password",
+			expected: []string{"test.domain.com", "intermediate", "root"},
+		},
+		{
+			name:     "password protected PKCS#12 ca certificates",
+			data:     string(pfx),
+			part:     "ca.crt",
+			password: "password",
+			expected: []string{"intermediate", "root"},
+		},
+		{
+			name:          "PKCS#12 with the wrong password",
+			data:          string(pfx),
+			part:          "tls.crt",
+			password:      "wrong",
+			expectedError: "decryption password incorrect",
+		},
+		{
+			name:          "PKCS#12 without password",
+			data:          string(pfx),
+			part:          "tls.key",
+			expectedError: "decryption password incorrect",
+		},
+		{
+			name:     "certificate not part of the chain is dropped",
+			data:     chain.leaf + chain.leafKey + chain.intermediate + other,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate"},
+		},
+		{
+			name:     "unrelated CA is dropped",
+			data:     otherCA + chain.leaf + chain.leafKey + chain.intermediate + chain.root,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name:     "unrelated CA without private key is dropped",
+			data:     otherCA + chain.root + chain.intermediate + chain.leaf,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name:     "cross-signed intermediate",
+			data:     chain.leaf + chain.leafKey + chain.intermediate + chain.crossIntermediate + chain.root + chain.crossRoot,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name:     "cross-signed intermediate first",
+			data:     chain.leaf + chain.crossIntermediate + chain.crossRoot + chain.intermediate + chain.root,
+			part:     "tls.crt",
+			expected: []string{"leaf", "intermediate", "cross-root"},
+		},
+		{
+			name:     "ca certificates of a cross-signed intermediate",
+			data:     chain.leaf + chain.leafKey + chain.intermediate + chain.crossIntermediate + chain.root + chain.crossRoot,
+			part:     "ca.crt",
+			expected: []string{"intermediate", "root"},
+		},
+		{
+			name:          "private key doesn't match",
+			data:          chain.leaf + string(pem.EncodeToMemory(&pem.Block{Type: privateKeyTypeEC, Bytes: otherKeyDER})),
+			part:          "tls.crt",
+			expectedError: "no certificate matches the private key",
+		},
+		{
+			name:     "unrelated certificates without private key",
+			data:     chain.leaf + other,
+			part:     "tls.crt",
+			expected: []string{"leaf"},
+		},
+		{
+			name:          "no ca certificates",
+			data:          chain.leaf + chain.leafKey,
+			part:          "ca.crt",
+			expectedError: "no CA certificates found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := GetCertPart([]byte(test.data), test.part, test.password, "")
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if got := commonNames(t, actual); !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("expected certificates %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetSecretDataPKCS12Password(t *testing.T) {
+	pfx, err := base64.StdEncoding.DecodeString(chainPFXFile)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{}
+	for objectName, content := range map[string][]byte{"cert": pfx, "password": []byte("password\n")} {
+		files[objectName] = filepath.Join(dir, objectName)
+		if err := os.WriteFile(files[objectName], content, 0600); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+	}
+	data := []*secretsstorev1.SecretObjectData{
+		{Key: "tls.crt", ObjectName: "cert"},
+		{Key: "tls.key", ObjectName: "cert"},
+	}
+
+	tests := []struct {
+		name               string
+		password           *secretsstorev1.PKCS12PasswordSource
+		nodePublishSecrets map[string][]byte
+		expectedError      bool
+	}{
+		{
+			name:     "password from object",
+			password: &secretsstorev1.PKCS12PasswordSource{ObjectName: "password"},
+		},
+		{
+			name:               "password from nodePublishSecretRef",
+			password:           &secretsstorev1.PKCS12PasswordSource{NodePublishSecretRefKey: "pfx-password"},
+			nodePublishSecrets: map[string][]byte{"pfx-password": []byte("password")},
+		},
+		{
+			name:          "password object not found",
+			password:      &secretsstorev1.PKCS12PasswordSource{ObjectName: "missing"},
+			expectedError: true,
+		},
+		{
+			name:               "password key not found in nodePublishSecretRef",
+			password:           &secretsstorev1.PKCS12PasswordSource{NodePublishSecretRefKey: "missing"},
+			nodePublishSecrets: map[string][]byte{"pfx-password": []byte("password")},
+			expectedError:      true,
+		},
+		{
+			name:          "both password sources set",
+			password:      &secretsstorev1.PKCS12PasswordSource{ObjectName: "password", NodePublishSecretRefKey: "pfx-password"},
+			expectedError: true,
+		},
+		{
+			name:          "no password",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretObj := secretsstorev1.SecretObject{SecretName: "secret1", Data: data, PKCS12Password: test.password}
+			datamap, _, err := GetSecretData(secretObj, corev1.SecretTypeTLS, files, test.nodePublishSecrets)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if got, want := commonNames(t, datamap["tls.crt"]), []string{"test.domain.com", "intermediate", "root"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("expected tls.crt certificates %v, got %v", want, got)
+			}
+			if _, ok := datamap["ca.crt"]; ok {
+				t.Errorf("expected ca.crt to not be synced if it's not declared")
+			}
+			if block, _ := pem.Decode(datamap["tls.key"]); block == nil || block.Type != privateKeyTypeEC {
+				t.Errorf("expected tls.key to be an EC private key, got: %q", datamap["tls.key"])
+			}
+		})
+	}
+}
+
+func TestGetSecretDataDroppedCertificates(t *testing.T) {
+	chain := newTestChain(t)
+	otherCA, _, _ := newTestCert(t, "other-ca", nil, nil, true)
+	dir := t.TempDir()
+	files := map[string]string{"cert": filepath.Join(dir, "cert")}
+	if err := os.WriteFile(files["cert"], []byte(otherCA+chain.leaf+chain.leafKey+chain.intermediate+chain.root), 0600); err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	secretObj := secretsstorev1.SecretObject{
+		SecretName: "secret1",
+		Data: []*secretsstorev1.SecretObjectData{
+			{Key: "tls.crt", ObjectName: "cert"},
+			{Key: "tls.key", ObjectName: "cert"},
+			{Key: "ca.crt", ObjectName: "cert"},
+		},
+	}
+
+	datamap, dropped, err := GetSecretData(secretObj, corev1.SecretTypeTLS, files, nil)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	if got, want := commonNames(t, datamap["ca.crt"]), []string{"intermediate", "root"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected ca.crt certificates %v, got %v", want, got)
+	}
+	if want := []string{"CN=other-ca"}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("expected dropped certificates %v, got %v", want, dropped)
+	}
+}
+
+func TestParseCertificates(t *testing.T) {
+	chain := newTestChain(t)
+	pfx, err := base64.StdEncoding.DecodeString(chainPFXFile)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+
+	tests := []struct {
+		name          string
+		data          string
+		password      string
+		expected      []string
+		expectedError string
+	}{
+		{name: "pem", data: chain.leaf + chain.leafKey + chain.intermediate, expected: []string{"leaf", "intermediate"}},
+		{name: "pkcs12", data: string(pfx), password: "password", expected: []string{"test.domain.com", "root", "intermediate"}},
+		{name: "pkcs12 wrong password", data: string(pfx), password: "wrong", expectedError: "decryption password incorrect"},
+		{name: "no certificate", data: chain.leafKey, expectedError: "no certificate found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			certs, err := ParseCertificates([]byte(test.data), test.password)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			var actual []string
+			for _, cert := range certs {
+				actual = append(actual, cert.Subject.CommonName)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
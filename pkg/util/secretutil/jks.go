@@ -0,0 +1,195 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // the JKS format is defined with SHA-1, it's not used for security here
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	jksMagic          = 0xfeedfeed
+	jksVersion        = 2
+	jksPrivateKeyTag  = 1
+	jksTrustedCertTag = 2
+	jksCertType       = "X.509"
+	// jksWhitener is added to the password in the keystore integrity check
+	jksWhitener = "Mighty Aphrodite"
+)
+
+// oidJKSKeyProtector is the algorithm of the private keys protected by the
+// sun.security.provider.KeyProtector of the JKS keystores
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// jksEntry is a private key entry, or a trusted certificate entry if key is nil
+type jksEntry struct {
+	alias string
+	key   crypto.PrivateKey
+	// certs is the certificate chain of the private key, leaf first, or the trusted
+	// certificate
+	certs []*x509.Certificate
+}
+
+// encryptedPrivateKeyInfo is the PKCS#8 EncryptedPrivateKeyInfo structure
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// encodeJKS returns the Java KeyStore with the entries. The private keys are protected
+// with the keystore password.
+func encodeJKS(entries []jksEntry, password string) ([]byte, error) {
+	passwd := jksPassword(password)
+	timestamp := time.Now().UnixMilli()
+
+	w := &jksWriter{}
+	w.writeUint32(jksMagic)
+	w.writeUint32(jksVersion)
+	w.writeLen(len(entries), math.MaxUint32)
+	for _, entry := range entries {
+		if entry.key == nil {
+			w.writeUint32(jksTrustedCertTag)
+			w.writeUTF(entry.alias)
+			w.writeInt64(timestamp)
+			w.writeCert(entry.certs[0])
+			continue
+		}
+		protected, err := protectJKSKey(entry.key, passwd)
+		if err != nil {
+			return nil, err
+		}
+		w.writeUint32(jksPrivateKeyTag)
+		w.writeUTF(entry.alias)
+		w.writeInt64(timestamp)
+		w.writeBytes(protected)
+		w.writeLen(len(entry.certs), math.MaxUint32)
+		for _, cert := range entry.certs {
+			w.writeCert(cert)
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	digest := sha1.New()
+	digest.Write(passwd)
+	digest.Write([]byte(jksWhitener))
+	digest.Write(w.buf.Bytes())
+	return append(w.buf.Bytes(), digest.Sum(nil)...), nil
+}
+
+// protectJKSKey returns the EncryptedPrivateKeyInfo of the private key protected with
+// the proprietary algorithm of the JKS keystores: the PKCS#8 encoded key is XORed with
+// a SHA-1 keystream derived from the password and a random salt, and followed by the
+// SHA-1 digest of the password and the key.
+func protectJKSKey(key crypto.PrivateKey, passwd []byte) ([]byte, error) {
+	plain, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key, err: %w", err)
+	}
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	protected := make([]byte, 0, 2*sha1.Size+len(plain))
+	protected = append(protected, salt...)
+	digest := salt
+	for i := 0; i < len(plain); i += sha1.Size {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(plain); j++ {
+			protected = append(protected, plain[i+j]^digest[j])
+		}
+	}
+	check := sha1.New()
+	check.Write(passwd)
+	check.Write(plain)
+	protected = append(protected, check.Sum(nil)...)
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue},
+		EncryptedData: protected,
+	})
+}
+
+// jksPassword returns the password as the big endian UTF-16 code units used by Java
+func jksPassword(password string) []byte {
+	units := utf16.Encode([]rune(password))
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = binary.BigEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// jksWriter writes the big endian values of the Java DataOutputStream
+type jksWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (w *jksWriter) writeUint32(v uint32) {
+	w.buf.Write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (w *jksWriter) writeInt64(v int64) {
+	_ = binary.Write(&w.buf, binary.BigEndian, v)
+}
+
+// writeLen writes the length as an unsigned short if limit is math.MaxUint16, or as an
+// unsigned int
+func (w *jksWriter) writeLen(n int, limit uint32) {
+	if n < 0 || uint64(n) > uint64(limit) {
+		w.err = fmt.Errorf("length %d exceeds the maximum length %d", n, limit)
+		return
+	}
+	if limit == math.MaxUint16 {
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n))) //nolint:gosec // checked above
+		return
+	}
+	w.writeUint32(uint32(n)) //nolint:gosec // checked above
+}
+
+// writeUTF writes the string prefixed with its length in bytes
+func (w *jksWriter) writeUTF(s string) {
+	w.writeLen(len(s), math.MaxUint16)
+	w.buf.WriteString(s)
+}
+
+// writeBytes writes the bytes prefixed with their length
+func (w *jksWriter) writeBytes(b []byte) {
+	w.writeLen(len(b), math.MaxUint32)
+	w.buf.Write(b)
+}
+
+func (w *jksWriter) writeCert(cert *x509.Certificate) {
+	w.writeUTF(jksCertType)
+	w.writeBytes(cert.Raw)
+}
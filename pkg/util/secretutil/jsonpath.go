@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// ParseJSONPath parses the JSONPath expression used to select a field of an object.
+// The enclosing braces are optional, e.g. .username and {.username} are the same.
+func ParseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New("jsonPath")
+	if err := jp.Parse(path); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// ExtractField returns the scalar value selected by the JSONPath expression in the
+// JSON or YAML content.
+func ExtractField(content []byte, path string) ([]byte, error) {
+	value, err := selectValue(content, path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scalarString(value)
+	if err != nil {
+		return nil, fmt.Errorf("jsonPath %s %w", path, err)
+	}
+	return []byte(s), nil
+}
+
+// ExplodeFields returns the scalar values of the top-level fields of the map selected
+// by the JSONPath expression in the JSON or YAML content, by field name. The whole
+// content is used if path is empty.
+func ExplodeFields(content []byte, path string) (map[string][]byte, error) {
+	var value interface{}
+	var err error
+	if len(strings.TrimSpace(path)) == 0 {
+		value, err = decode(content)
+		path = "{}"
+	} else {
+		value, err = selectValue(content, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonPath %s resolves to %s, expected a map", path, kindOf(value))
+	}
+	data := make(map[string][]byte, len(fields))
+	for name, field := range fields {
+		s, err := scalarString(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s %w", name, err)
+		}
+		data[name] = []byte(s)
+	}
+	return data, nil
+}
+
+// selectValue returns the single value selected by the JSONPath expression
+func selectValue(content []byte, path string) (interface{}, error) {
+	jp, err := ParseJSONPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonPath %s, err: %w", path, err)
+	}
+	obj, err := decode(content)
+	if err != nil {
+		return nil, err
+	}
+	results, err := jp.FindResults(obj)
+	if err != nil {
+		return nil, fmt.Errorf("jsonPath %s not found, err: %w", path, err)
+	}
+	var values []reflect.Value
+	for _, result := range results {
+		values = append(values, result...)
+	}
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("jsonPath %s not found", path)
+	case 1:
+		if !values[0].IsValid() {
+			return nil, nil
+		}
+		return values[0].Interface(), nil
+	default:
+		return nil, fmt.Errorf("jsonPath %s resolves to %d values, expected a single value", path, len(values))
+	}
+}
+
+// decode decodes the JSON or YAML content, numbers are kept as is
+func decode(content []byte) (interface{}, error) {
+	b, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse object as JSON or YAML, err: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var obj interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to parse object as JSON or YAML, err: %w", err)
+	}
+	return obj, nil
+}
+
+// scalarString returns the string representation of a scalar value
+func scalarString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("resolves to %s, expected a string, number or boolean", kindOf(value))
+	}
+}
+
+func kindOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "a map"
+	case []interface{}:
+		return "a list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
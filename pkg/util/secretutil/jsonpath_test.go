@@ -0,0 +1,215 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	testJSONObject = `{"username":"admin","password":"secret","port":5432,"id":12345678901234567890,"tls":true,"nested":{"host":"db"},"hosts":["a","b"],"empty":null}`
+	testYAMLObject = "username: admin\nnested:\n  host: db\n  port: 5432\n"
+)
+
+func TestExtractField(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		path          string
+		expected      string
+		expectedError string
+	}{
+		{name: "string", content: testJSONObject, path: "{.username}", expected: "admin"},
+		{name: "without braces", content: testJSONObject, path: ".password", expected: "secret"},
+		{name: "number", content: testJSONObject, path: ".port", expected: "5432"},
+		{name: "large number", content: testJSONObject, path: ".id", expected: "12345678901234567890"},
+		{name: "boolean", content: testJSONObject, path: ".tls", expected: "true"},
+		{name: "nested", content: testJSONObject, path: ".nested.host", expected: "db"},
+		{name: "list item", content: testJSONObject, path: ".hosts[1]", expected: "b"},
+		{name: "yaml", content: testYAMLObject, path: ".nested.port", expected: "5432"},
+		{name: "missing", content: testJSONObject, path: ".user", expectedError: "jsonPath .user not found"},
+		{name: "map", content: testJSONObject, path: ".nested", expectedError: "jsonPath .nested resolves to a map"},
+		{name: "list", content: testJSONObject, path: ".hosts", expectedError: "jsonPath .hosts resolves to a list"},
+		{name: "multiple values", content: testJSONObject, path: ".hosts[*]", expectedError: "jsonPath .hosts[*] resolves to 2 values"},
+		{name: "null", content: testJSONObject, path: ".empty", expectedError: "jsonPath .empty resolves to null"},
+		{name: "invalid json path", content: testJSONObject, path: "{.username", expectedError: "invalid jsonPath"},
+		{name: "invalid object", content: "{", path: ".username", expectedError: "failed to parse object"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ExtractField([]byte(test.content), test.path)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if string(actual) != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestExplodeFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		path          string
+		expected      map[string][]byte
+		expectedError string
+	}{
+		{
+			name:     "top-level fields",
+			content:  `{"username":"admin","port":5432}`,
+			expected: map[string][]byte{"username": []byte("admin"), "port": []byte("5432")},
+		},
+		{
+			name:     "fields of the selected map",
+			content:  testYAMLObject,
+			path:     ".nested",
+			expected: map[string][]byte{"host": []byte("db"), "port": []byte("5432")},
+		},
+		{
+			name:          "nested field",
+			content:       testYAMLObject,
+			expectedError: "field nested resolves to a map",
+		},
+		{
+			name:          "not a map",
+			content:       testJSONObject,
+			path:          ".hosts",
+			expectedError: "jsonPath .hosts resolves to a list, expected a map",
+		},
+		{
+			name:          "plain text",
+			content:       "secret",
+			expectedError: "resolves to string, expected a map",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ExplodeFields([]byte(test.content), test.path)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetSecretDataJSONPath(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for objectName, content := range map[string]string{"db": testJSONObject, "config": testYAMLObject, "invalid-key": `{"key/1":"a"}`} {
+		files[objectName] = filepath.Join(dir, objectName)
+		if err := os.WriteFile(files[objectName], []byte(content), 0600); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		secretObj       secretsstorev1.SecretObject
+		expectedDataMap map[string][]byte
+		expectedError   bool
+	}{
+		{
+			name: "json path and data from",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data: []*secretsstorev1.SecretObjectData{
+					{Key: "user", ObjectName: "db", JSONPath: "{.username}"},
+				},
+				DataFrom: []*secretsstorev1.SecretObjectDataFrom{{ObjectName: "config", JSONPath: ".nested"}},
+			},
+			expectedDataMap: map[string][]byte{"user": []byte("admin"), "host": []byte("db"), "port": []byte("5432")},
+		},
+		{
+			name: "missing field",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data:       []*secretsstorev1.SecretObjectData{{Key: "user", ObjectName: "db", JSONPath: ".user"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "data from key already defined",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data:       []*secretsstorev1.SecretObjectData{{Key: "host", ObjectName: "db", JSONPath: ".nested.host"}},
+				DataFrom:   []*secretsstorev1.SecretObjectDataFrom{{ObjectName: "config", JSONPath: ".nested"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "data from invalid key",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				DataFrom:   []*secretsstorev1.SecretObjectDataFrom{{ObjectName: "invalid-key"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "data from object not found",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				DataFrom:   []*secretsstorev1.SecretObjectDataFrom{{ObjectName: "missing"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			datamap, _, err := GetSecretData(test.secretObj, corev1.SecretTypeOpaque, files, nil)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(datamap, test.expectedDataMap) {
+				t.Fatalf("expected data map %q, got %q", test.expectedDataMap, datamap)
+			}
+		})
+	}
+}
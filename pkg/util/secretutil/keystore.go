@@ -0,0 +1,145 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// defaultKeystoreAlias is the alias of the private key entry of JKS keystores
+const defaultKeystoreAlias = "1"
+
+// EncodeKeystore returns a keystore with a private key entry for the PEM private key
+// and the certificate chain of the key, protected with the password. The alias is
+// only used by the JKS format.
+func EncodeKeystore(format secretsstorev1.KeystoreFormat, key, cert []byte, alias, password string) ([]byte, error) {
+	keyBlocks := decodePEMBlocks(key)
+	privateKey, err := findPrivateKey(keyBlocks)
+	if err != nil {
+		return nil, err
+	}
+	certBlocks := decodePEMBlocks(cert)
+	chain, err := newCertChain(append(certBlocks, keyBlocks...))
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parseCertBlocks(chain.certs())
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case secretsstorev1.KeystoreFormatJKS:
+		if len(alias) == 0 {
+			alias = defaultKeystoreAlias
+		}
+		return encodeJKS([]jksEntry{{alias: strings.ToLower(alias), key: privateKey, certs: certs}}, password)
+	case secretsstorev1.KeystoreFormatPKCS12, "":
+		return pkcs12.Modern.Encode(privateKey, certs[0], certs[1:], password)
+	default:
+		return nil, fmt.Errorf("unsupported keystore format %s", format)
+	}
+}
+
+// EncodeTruststore returns a truststore with a trusted certificate entry for each
+// certificate of the PEM objects, protected with the password. The entries are named
+// after the certificate subjects.
+func EncodeTruststore(format secretsstorev1.KeystoreFormat, objects [][]byte, password string) ([]byte, error) {
+	var blocks []*pem.Block
+	for _, object := range objects {
+		for _, block := range decodePEMBlocks(object) {
+			if block.Type == certType {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	certs, err := parseCertBlocks(blocks)
+	if err != nil {
+		return nil, err
+	}
+	aliases := trustedCertAliases(certs)
+
+	switch format {
+	case secretsstorev1.KeystoreFormatJKS:
+		entries := make([]jksEntry, len(certs))
+		for i, cert := range certs {
+			entries[i] = jksEntry{alias: aliases[i], certs: []*x509.Certificate{cert}}
+		}
+		return encodeJKS(entries, password)
+	case secretsstorev1.KeystoreFormatPKCS12, "":
+		entries := make([]pkcs12.TrustStoreEntry, len(certs))
+		for i, cert := range certs {
+			entries[i] = pkcs12.TrustStoreEntry{Cert: cert, FriendlyName: aliases[i]}
+		}
+		return pkcs12.Modern.EncodeTrustStoreEntries(entries, password)
+	default:
+		return nil, fmt.Errorf("unsupported keystore format %s", format)
+	}
+}
+
+// decodePEMBlocks returns the PEM blocks of the object
+func decodePEMBlocks(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return blocks
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+// parseCertBlocks parses the certificates of the certificate blocks
+func parseCertBlocks(blocks []*pem.Block) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, len(blocks))
+	for i, block := range blocks {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate, err: %w", err)
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
+
+// trustedCertAliases returns unique aliases for the trusted certificates, the lower
+// case subject of the certificate followed by a counter if the subject isn't unique
+func trustedCertAliases(certs []*x509.Certificate) []string {
+	aliases := make([]string, len(certs))
+	used := make(map[string]bool, len(certs))
+	for i, cert := range certs {
+		base := strings.ToLower(cert.Subject.String())
+		alias := base
+		for n := 2; used[alias]; n++ {
+			alias = fmt.Sprintf("%s (%d)", base, n)
+		}
+		used[alias] = true
+		aliases[i] = alias
+	}
+	return aliases
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// decodeTestJKS decodes the Java KeyStore, verifies its integrity and recovers the
+// private keys protected with the password
+func decodeTestJKS(t *testing.T, data []byte, password string) []jksEntry {
+	t.Helper()
+	passwd := jksPassword(password)
+	if len(data) < sha1.Size {
+		t.Fatalf("keystore is too short")
+	}
+	content, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	h := sha1.New() //nolint:gosec
+	h.Write(passwd)
+	h.Write([]byte(jksWhitener))
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		t.Fatalf("keystore integrity check failed")
+	}
+
+	r := bytes.NewReader(content)
+	readUint32 := func() uint32 {
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		return v
+	}
+	readBytes := func(n int) []byte {
+		b := make([]byte, n)
+		if _, err := r.Read(b); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		return b
+	}
+	readUTF := func() string {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		return string(readBytes(int(n)))
+	}
+	readCert := func() *x509.Certificate {
+		if certType := readUTF(); certType != jksCertType {
+			t.Fatalf("expected certificate type %s, got %s", jksCertType, certType)
+		}
+		cert, err := x509.ParseCertificate(readBytes(int(readUint32())))
+		if err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		return cert
+	}
+
+	if magic, version := readUint32(), readUint32(); magic != jksMagic || version != jksVersion {
+		t.Fatalf("unexpected magic %x or version %d", magic, version)
+	}
+	entries := make([]jksEntry, readUint32())
+	for i := range entries {
+		tag := readUint32()
+		entries[i].alias = readUTF()
+		readBytes(8) // timestamp
+		if tag == jksTrustedCertTag {
+			entries[i].certs = []*x509.Certificate{readCert()}
+			continue
+		}
+
+		var info encryptedPrivateKeyInfo
+		if _, err := asn1.Unmarshal(readBytes(int(readUint32())), &info); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		if !info.Algorithm.Algorithm.Equal(oidJKSKeyProtector) {
+			t.Fatalf("unexpected key protection algorithm %s", info.Algorithm.Algorithm)
+		}
+		protected := info.EncryptedData
+		salt, encrypted, check := protected[:sha1.Size], protected[sha1.Size:len(protected)-sha1.Size], protected[len(protected)-sha1.Size:]
+		plain := make([]byte, len(encrypted))
+		digest := salt
+		for j := 0; j < len(encrypted); j += sha1.Size {
+			h := sha1.New() //nolint:gosec
+			h.Write(passwd)
+			h.Write(digest)
+			digest = h.Sum(nil)
+			for k := 0; k < sha1.Size && j+k < len(encrypted); k++ {
+				plain[j+k] = encrypted[j+k] ^ digest[k]
+			}
+		}
+		h := sha1.New() //nolint:gosec
+		h.Write(passwd)
+		h.Write(plain)
+		if !bytes.Equal(h.Sum(nil), check) {
+			t.Fatalf("private key integrity check failed")
+		}
+		key, err := x509.ParsePKCS8PrivateKey(plain)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+		entries[i].key = key
+		for n := readUint32(); n > 0; n-- {
+			entries[i].certs = append(entries[i].certs, readCert())
+		}
+	}
+	return entries
+}
+
+func certCommonNames(certs []*x509.Certificate) []string {
+	var names []string
+	for _, cert := range certs {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+// sameKey returns true if the private keys have the same public key
+func sameKey(t *testing.T, a, b crypto.PrivateKey) bool {
+	t.Helper()
+	pub, ok := publicKey(a).(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(publicKey(b))
+}
+
+func TestEncodeKeystore(t *testing.T) {
+	chain := newTestChain(t)
+	leafKey, err := findPrivateKey(decodePEMBlocks([]byte(chain.leafKey)))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	_, _, otherKey := newTestCert(t, "other", nil, nil, false)
+	otherKeyDER, err := x509.MarshalECPrivateKey(otherKey)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got: %+v", err)
+	}
+	otherKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: privateKeyTypeEC, Bytes: otherKeyDER}))
+
+	tests := []struct {
+		name          string
+		format        secretsstorev1.KeystoreFormat
+		key           string
+		cert          string
+		alias         string
+		expectedAlias string
+		expectedError string
+	}{
+		{name: "pkcs12 default format", cert: chain.root + chain.leaf + chain.intermediate},
+		{name: "pkcs12", format: secretsstorev1.KeystoreFormatPKCS12, cert: chain.leaf + chain.intermediate + chain.root},
+		{name: "jks default alias", format: secretsstorev1.KeystoreFormatJKS, cert: chain.leaf + chain.intermediate + chain.root, expectedAlias: "1"},
+		{name: "jks alias", format: secretsstorev1.KeystoreFormatJKS, cert: chain.intermediate + chain.root + chain.leaf, alias: "Server", expectedAlias: "server"},
+		{name: "key not matching the certificate", key: otherKeyPEM, cert: chain.leaf, expectedError: "no certificate matches the private key"},
+		{name: "no private key", key: chain.leaf, cert: chain.leaf, expectedError: "no private key found"},
+		{name: "no certificate", cert: chain.leafKey, expectedError: "no certificate found"},
+		{name: "unsupported format", format: "BKS", cert: chain.leaf, expectedError: "unsupported keystore format BKS"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := test.key
+			if key == "" {
+				key = chain.leafKey
+			}
+			data, err := EncodeKeystore(test.format, []byte(key), []byte(test.cert), test.alias, "changeit")
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+
+			var actualKey crypto.PrivateKey
+			var certs []*x509.Certificate
+			if test.format == secretsstorev1.KeystoreFormatJKS {
+				entries := decodeTestJKS(t, data, "changeit")
+				if len(entries) != 1 || entries[0].alias != test.expectedAlias {
+					t.Fatalf("expected a single entry with alias %q, got: %+v", test.expectedAlias, entries)
+				}
+				actualKey, certs = entries[0].key, entries[0].certs
+			} else {
+				var leaf *x509.Certificate
+				var cas []*x509.Certificate
+				if actualKey, leaf, cas, err = pkcs12.DecodeChain(data, "changeit"); err != nil {
+					t.Fatalf("expected err to be nil, got: %+v", err)
+				}
+				certs = append([]*x509.Certificate{leaf}, cas...)
+			}
+			if !sameKey(t, actualKey, leafKey) {
+				t.Fatalf("expected the private key of the leaf certificate")
+			}
+			if expected, actual := []string{"leaf", "intermediate", "root"}, certCommonNames(certs); !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected chain %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestEncodeTruststore(t *testing.T) {
+	chain := newTestChain(t)
+	other, _, _ := newTestCert(t, "root", nil, nil, true)
+
+	for _, format := range []secretsstorev1.KeystoreFormat{secretsstorev1.KeystoreFormatPKCS12, secretsstorev1.KeystoreFormatJKS} {
+		t.Run(string(format), func(t *testing.T) {
+			objects := [][]byte{[]byte(chain.root + chain.intermediate), []byte(other), []byte(chain.leafKey)}
+			data, err := EncodeTruststore(format, objects, "changeit")
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+
+			var certs []*x509.Certificate
+			if format == secretsstorev1.KeystoreFormatJKS {
+				var aliases []string
+				for _, entry := range decodeTestJKS(t, data, "changeit") {
+					if entry.key != nil {
+						t.Fatalf("expected trusted certificate entries only")
+					}
+					aliases = append(aliases, entry.alias)
+					certs = append(certs, entry.certs...)
+				}
+				if expected := []string{"cn=root", "cn=intermediate", "cn=root (2)"}; !reflect.DeepEqual(expected, aliases) {
+					t.Fatalf("expected aliases %v, got %v", expected, aliases)
+				}
+			} else if certs, err = pkcs12.DecodeTrustStore(data, "changeit"); err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if expected, actual := []string{"root", "intermediate", "root"}, certCommonNames(certs); !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected certificates %v, got %v", expected, actual)
+			}
+		})
+	}
+
+	if _, err := EncodeTruststore(secretsstorev1.KeystoreFormatJKS, [][]byte{[]byte(chain.leafKey)}, "changeit"); err == nil || !strings.Contains(err.Error(), "no certificate found") {
+		t.Fatalf("expected no certificate found error, got: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package secretutil
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -30,9 +31,10 @@ import (
 	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
 
 	"golang.org/x/crypto/cryptobyte"
-	"golang.org/x/crypto/pkcs12"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -40,120 +42,149 @@ const (
 	privateKeyType    = "PRIVATE KEY"
 	privateKeyTypeRSA = "RSA PRIVATE KEY"
 	privateKeyTypeEC  = "EC PRIVATE KEY"
+
+	privateKeyTypeEncrypted = "ENCRYPTED PRIVATE KEY"
+
+	// caCertKey is the key of the CA certificates in a kubernetes.io/tls secret
+	caCertKey = "ca.crt"
 )
 
-// GetCertPart returns the certificate or the private key part of the cert
-func GetCertPart(data []byte, key string) ([]byte, error) {
-	if key == corev1.TLSPrivateKeyKey {
-		return getPrivateKey(data)
-	}
-	if key == corev1.TLSCertKey {
-		return getCert(data)
-	}
-	return nil, fmt.Errorf("key '%s' is not supported. Only 'tls.key' and 'tls.crt' are supported", key)
+// GetCertPart returns the certificate chain, the CA certificates or the private key
+// part of the cert. The password is used to decrypt PKCS#12 certs and the private key
+// is returned with the key encoding.
+func GetCertPart(data []byte, key, password string, keyEncoding secretsstorev1.PrivateKeyEncoding) ([]byte, error) {
+	part, _, err := getCertPart(data, key, password, keyEncoding)
+	return part, err
 }
 
-// getCert returns the certificate part of a cert
-func getCert(data []byte) ([]byte, error) {
-	var certs []byte
-	for {
-		pemBlock, rest := pem.Decode(data)
-		if pemBlock == nil {
-			break
-		}
-		if pemBlock.Type == certType {
-			block := pem.EncodeToMemory(pemBlock)
-			certs = append(certs, block...)
-		}
-		data = rest
+// getCertPart returns the part of the cert like GetCertPart and the subjects of the
+// certificates that were dropped because they are not part of the chain
+func getCertPart(data []byte, key, password string, keyEncoding secretsstorev1.PrivateKeyEncoding) ([]byte, []string, error) {
+	switch key {
+	case corev1.TLSPrivateKeyKey:
+		privateKey, err := getPrivateKey(data, password, keyEncoding)
+		return privateKey, nil, err
+	case corev1.TLSCertKey:
+		return getCert(data, password)
+	case caCertKey:
+		return getCACert(data, password)
 	}
+	return nil, nil, fmt.Errorf("key '%s' is not supported. Only 'tls.key', 'tls.crt' and 'ca.crt' are supported", key)
+}
 
-	// if cert is nil, then it might be a pfx cert
-	if certs == nil {
-		pemBlocks, err := pkcs12.ToPEM(data, "")
-		if err != nil {
-			return nil, err
-		}
+// getCert returns the certificate part of a cert, the certificates are ordered
+// leaf first
+func getCert(data []byte, password string) ([]byte, []string, error) {
+	chain, err := parseCertChain(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeBlocks(chain.certs()), chain.dropped, nil
+}
 
-		// pem Blocks returns both the certificate and private key types
-		for _, block := range pemBlocks {
-			// get bytes for certificate
-			if block.Type == certType {
-				certs = append(certs, pem.EncodeToMemory(block)...)
+// getCACert returns the CA certificates of the chain of a cert, from the issuer
+// of the leaf certificate to the root certificate. The certificates of an object
+// with only CA certificates are returned as is, the object is a CA bundle.
+func getCACert(data []byte, password string) ([]byte, []string, error) {
+	blocks, err := decodeBlocks(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	var certBlocks []*pem.Block
+	bundle := true
+	for _, block := range blocks {
+		if block.Type == certType {
+			certBlocks = append(certBlocks, block)
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse certificate, err: %w", err)
 			}
+			bundle = bundle && cert.IsCA
+		} else if isPrivateKeyBlock(block) {
+			bundle = false
 		}
 	}
-
-	return certs, nil
+	if len(certBlocks) == 0 {
+		return nil, nil, fmt.Errorf("no certificate found")
+	}
+	if bundle {
+		return encodeBlocks(certBlocks), nil, nil
+	}
+	chain, err := newCertChain(blocks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chain.cas) == 0 {
+		return nil, nil, fmt.Errorf("no CA certificates found")
+	}
+	return encodeBlocks(chain.cas), chain.dropped, nil
 }
 
-// getPrivateKey returns the private key part of a cert
-func getPrivateKey(data []byte) ([]byte, error) {
-	var der, derKey, rest []byte
-	var pemBlock *pem.Block
-	privKeyType := privateKeyType
-
-	for {
-		pemBlock, rest = pem.Decode(data)
-		if pemBlock == nil {
-			break
-		}
-		if pemBlock.Type != certType {
-			der = pemBlock.Bytes
-		}
-		data = rest
+func encodeBlocks(blocks []*pem.Block) []byte {
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
 	}
+	return data
+}
 
-	// if both der is nil, then certificate might be in the pfx format
-	if der == nil {
-		pemBlocks, err := pkcs12.ToPEM(data, "")
-		if err != nil {
-			return nil, err
-		}
+// getPrivateKey returns the private key part of a cert. The key is encoded as PKCS#8,
+// or as PKCS#1 for RSA keys and SEC 1 for ECDSA keys if the encoding is PKCS1 or empty.
+func getPrivateKey(data []byte, password string, encoding secretsstorev1.PrivateKeyEncoding) ([]byte, error) {
+	blocks, err := decodeBlocks(data, password)
+	if err != nil {
+		return nil, err
+	}
+	key, err := findPrivateKey(blocks)
+	if err != nil {
+		return nil, err
+	}
+	return marshalPrivateKey(key, encoding)
+}
 
-		// pem blocks returns both the certificate and private key types
-		for _, block := range pemBlocks {
-			// get bytes for private key
-			if block.Type == privateKeyType {
-				der = block.Bytes
-			}
+// findPrivateKey parses the last private key block of the blocks
+func findPrivateKey(blocks []*pem.Block) (crypto.PrivateKey, error) {
+	var keyBlock *pem.Block
+	for _, block := range blocks {
+		if isPrivateKeyBlock(block) {
+			keyBlock = block
 		}
 	}
-
-	// parses an RSA private key in PKCS #1, ASN.1 DER form
-	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
-		privKeyType = privateKeyTypeRSA
-		derKey = x509.MarshalPKCS1PrivateKey(key)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no private key found")
 	}
-	// parses an unencrypted private key in PKCS #8, ASN.1 DER form
-	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
-		switch key := key.(type) {
-		case *rsa.PrivateKey:
-			derKey = x509.MarshalPKCS1PrivateKey(key)
-			privKeyType = privateKeyTypeRSA
-		case *ecdsa.PrivateKey:
-			derKey, err = x509.MarshalECPrivateKey(key)
-			privKeyType = privateKeyTypeEC
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return nil, fmt.Errorf("unknown private key type found while getting key. Only rsa and ecdsa are supported")
-		}
+	if keyBlock.Type == privateKeyTypeEncrypted || len(keyBlock.Headers["DEK-Info"]) > 0 {
+		return nil, fmt.Errorf("encrypted PEM private keys are not supported")
 	}
-	// parses an EC private key in SEC 1, ASN.1 DER form
-	if key, err := x509.ParseECPrivateKey(der); err == nil {
-		derKey, err = x509.MarshalECPrivateKey(key)
-		if err != nil {
-			return nil, err
+	return parsePrivateKey(keyBlock.Bytes)
+}
+
+// marshalPrivateKey returns the PEM encoded private key
+func marshalPrivateKey(key crypto.PrivateKey, encoding secretsstorev1.PrivateKeyEncoding) ([]byte, error) {
+	block := &pem.Block{Type: privateKeyType}
+	var err error
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if encoding == secretsstorev1.PrivateKeyEncodingPKCS8 {
+			block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+		} else {
+			block.Type = privateKeyTypeRSA
+			block.Bytes = x509.MarshalPKCS1PrivateKey(key)
+		}
+	case *ecdsa.PrivateKey:
+		if encoding == secretsstorev1.PrivateKeyEncodingPKCS8 {
+			block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+		} else {
+			block.Type = privateKeyTypeEC
+			block.Bytes, err = x509.MarshalECPrivateKey(key)
 		}
-		privKeyType = privateKeyTypeEC
+	default:
+		// Ed25519 and X25519 keys are only encoded as PKCS#8
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
 	}
-	block := &pem.Block{
-		Type:  privKeyType,
-		Bytes: derKey,
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key, err: %w", err)
 	}
-
 	return pem.EncodeToMemory(block), nil
 }
 
@@ -168,7 +199,7 @@ func GetSecretType(sType string) corev1.SecretType {
 }
 
 // ValidateSecretObject performs basic validation of the secret provider class
-// secret object to check if the mandatory fields - name, type and data are defined
+// secret object to check if the mandatory fields - name, type and data, dataFrom or template are defined
 func ValidateSecretObject(secretObj secretsstorev1.SecretObject) error {
 	if len(secretObj.SecretName) == 0 {
 		return fmt.Errorf("secret name is empty")
@@ -176,44 +207,178 @@ func ValidateSecretObject(secretObj secretsstorev1.SecretObject) error {
 	if len(secretObj.Type) == 0 {
 		return fmt.Errorf("secret type is empty")
 	}
-	if len(secretObj.Data) == 0 {
-		return fmt.Errorf("data is empty")
+	if len(secretObj.Data) == 0 && len(secretObj.DataFrom) == 0 && len(secretObj.Template) == 0 {
+		return fmt.Errorf("data, dataFrom and template are empty")
 	}
 	return nil
 }
 
 // GetSecretData gets the object contents from the pods target path and returns a
-// map that will be populated in the Kubernetes secret data field
-func GetSecretData(secretObjData []*secretsstorev1.SecretObjectData, secretType corev1.SecretType, files map[string]string) (map[string][]byte, error) {
+// map that will be populated in the Kubernetes secret data field. The data keys with
+// a template and the template of the secret object are rendered with the contents of
+// the mounted objects. The fields of the JSON or YAML objects in dataFrom are each
+// added as a data key. For kubernetes.io/tls secrets the nodePublishSecrets are the
+// contents of the nodePublishSecretRef secret used for the PKCS#12 password, and the
+// subjects of the certificates dropped from the tls.crt and ca.crt chains are returned.
+func GetSecretData(secretObj secretsstorev1.SecretObject, secretType corev1.SecretType, files map[string]string, nodePublishSecrets map[string][]byte) (map[string][]byte, []string, error) {
 	datamap := make(map[string][]byte)
-	for _, data := range secretObjData {
+	var objects map[string]string
+	var password string
+	var dropped []string
+	if secretType == corev1.SecretTypeTLS && secretObj.PKCS12Password != nil {
+		var err error
+		if password, err = getPKCS12Password(secretObj.PKCS12Password, files, nodePublishSecrets); err != nil {
+			return datamap, nil, err
+		}
+	}
+	for _, data := range secretObj.Data {
 		objectName := strings.TrimSpace(data.ObjectName)
 		dataKey := strings.TrimSpace(data.Key)
 
-		if len(objectName) == 0 {
-			return datamap, fmt.Errorf("object name in secretObjects.data")
-		}
 		if len(dataKey) == 0 {
-			return datamap, fmt.Errorf("key in secretObjects.data is empty")
+			return datamap, nil, fmt.Errorf("key in secretObjects.data is empty")
+		}
+		if len(data.Template) > 0 {
+			if len(objectName) > 0 {
+				return datamap, nil, fmt.Errorf("object name and template are both set for key %s in secretObjects.data", dataKey)
+			}
+			var err error
+			if objects, err = readObjects(objects, files); err != nil {
+				return datamap, nil, err
+			}
+			content, err := RenderTemplate(dataKey, data.Template, objects)
+			if err != nil {
+				return datamap, nil, fmt.Errorf("failed to render template for key %s, err: %w", dataKey, err)
+			}
+			datamap[dataKey] = content
+			continue
+		}
+		if len(objectName) == 0 {
+			return datamap, nil, fmt.Errorf("object name in secretObjects.data is empty")
 		}
 		file, ok := files[objectName]
 		if !ok {
-			return datamap, fmt.Errorf("file matching objectName %s not found in the pod", objectName)
+			return datamap, nil, fmt.Errorf("file matching objectName %s not found in the pod", objectName)
 		}
 		content, err := os.ReadFile(file)
 		if err != nil {
-			return datamap, fmt.Errorf("failed to read file %s, err: %w", objectName, err)
+			return datamap, nil, fmt.Errorf("failed to read file %s, err: %w", objectName, err)
+		}
+		if len(data.JSONPath) > 0 {
+			if content, err = ExtractField(content, data.JSONPath); err != nil {
+				return datamap, nil, fmt.Errorf("failed to get key %s from object %s, err: %w", dataKey, objectName, err)
+			}
 		}
 		datamap[dataKey] = content
 		if secretType == corev1.SecretTypeTLS {
-			c, err := GetCertPart(content, dataKey)
+			c, d, err := getCertPart(content, dataKey, password, secretObj.PrivateKeyEncoding)
 			if err != nil {
-				return datamap, fmt.Errorf("failed to get cert data from file %s, err: %w", file, err)
+				return datamap, nil, fmt.Errorf("failed to get cert data from file %s, err: %w", file, err)
 			}
 			datamap[dataKey] = c
+			dropped = append(dropped, d...)
+		}
+	}
+
+	for _, dataFrom := range secretObj.DataFrom {
+		objectName := strings.TrimSpace(dataFrom.ObjectName)
+		if len(objectName) == 0 {
+			return datamap, nil, fmt.Errorf("object name in secretObjects.dataFrom is empty")
+		}
+		file, ok := files[objectName]
+		if !ok {
+			return datamap, nil, fmt.Errorf("file matching objectName %s not found in the pod", objectName)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return datamap, nil, fmt.Errorf("failed to read file %s, err: %w", objectName, err)
+		}
+		fields, err := ExplodeFields(content, dataFrom.JSONPath)
+		if err != nil {
+			return datamap, nil, fmt.Errorf("failed to get fields from object %s, err: %w", objectName, err)
+		}
+		for key, value := range fields {
+			if msgs := validation.IsConfigMapKey(key); len(msgs) > 0 {
+				return datamap, nil, fmt.Errorf("invalid key %q in object %s: %s", key, objectName, strings.Join(msgs, ", "))
+			}
+			if _, ok := datamap[key]; ok {
+				return datamap, nil, fmt.Errorf("key %s from object %s is already defined in secret %s", key, objectName, secretObj.SecretName)
+			}
+			datamap[key] = value
+		}
+	}
+
+	if len(secretObj.Template) > 0 {
+		var err error
+		if objects, err = readObjects(objects, files); err != nil {
+			return datamap, nil, err
+		}
+		content, err := RenderTemplate(secretObj.SecretName, secretObj.Template, objects)
+		if err != nil {
+			return datamap, nil, fmt.Errorf("failed to render template for secret %s, err: %w", secretObj.SecretName, err)
+		}
+		rendered := make(map[string]string)
+		if err := yaml.Unmarshal(content, &rendered); err != nil {
+			return datamap, nil, fmt.Errorf("template for secret %s must render a map of keys to string values, err: %w", secretObj.SecretName, err)
+		}
+		for key, value := range rendered {
+			if msgs := validation.IsConfigMapKey(key); len(msgs) > 0 {
+				return datamap, nil, fmt.Errorf("invalid key %q rendered by template for secret %s: %s", key, secretObj.SecretName, strings.Join(msgs, ", "))
+			}
+			if _, ok := datamap[key]; ok {
+				return datamap, nil, fmt.Errorf("key %s rendered by template for secret %s is already defined in data or dataFrom", key, secretObj.SecretName)
+			}
+			datamap[key] = []byte(value)
 		}
 	}
-	return datamap, nil
+
+	return datamap, sets.List(sets.New(dropped...)), nil
+}
+
+// getPKCS12Password returns the password of the PKCS#12 objects from the mounted
+// object or the nodePublishSecretRef secret. Trailing line breaks are removed.
+func getPKCS12Password(source *secretsstorev1.PKCS12PasswordSource, files map[string]string, nodePublishSecrets map[string][]byte) (string, error) {
+	objectName := strings.TrimSpace(source.ObjectName)
+	secretKey := strings.TrimSpace(source.NodePublishSecretRefKey)
+	switch {
+	case len(objectName) > 0 && len(secretKey) > 0:
+		return "", fmt.Errorf("object name and nodePublishSecretRef key are both set in secretObjects.pkcs12Password")
+	case len(objectName) > 0:
+		file, ok := files[objectName]
+		if !ok {
+			return "", fmt.Errorf("file matching objectName %s not found in the pod", objectName)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s, err: %w", objectName, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case len(secretKey) > 0:
+		value, ok := nodePublishSecrets[secretKey]
+		if !ok {
+			return "", fmt.Errorf("key %s not found in the nodePublishSecretRef secret", secretKey)
+		}
+		return strings.TrimRight(string(value), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("object name or nodePublishSecretRef key is required in secretObjects.pkcs12Password")
+	}
+}
+
+// readObjects reads the contents of the mounted objects by object name, the
+// objects are only read once for all the templates of the secret object.
+func readObjects(objects map[string]string, files map[string]string) (map[string]string, error) {
+	if objects != nil {
+		return objects, nil
+	}
+	objects = make(map[string]string, len(files))
+	for objectName, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s, err: %w", objectName, err)
+		}
+		objects[objectName] = string(content)
+	}
+	return objects, nil
 }
 
 // GetSHAFromSecret gets SHA for the secret data
@@ -236,7 +401,8 @@ func GetSHAFromSecret(data map[string][]byte) (string, error) {
 		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
 			b.AddBytes([]byte(k))
 		})
-		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		// secret values can be larger than 64KiB
+		b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
 			b.AddBytes(data[k])
 		})
 	}
@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available in the templates in addition to the
+// text/template builtins. They don't have access to the node, e.g. the environment
+// or the file system.
+var templateFuncs = template.FuncMap{
+	"b64enc":   b64enc,
+	"b64dec":   b64dec,
+	"default":  defaultValue,
+	"dict":     dict,
+	"indent":   indent,
+	"join":     join,
+	"list":     list,
+	"lower":    strings.ToLower,
+	"nindent":  nindent,
+	"quote":    quote,
+	"replace":  replace,
+	"required": required,
+	"squote":   squote,
+	"toJson":   toJSON,
+	"trim":     strings.TrimSpace,
+	"upper":    strings.ToUpper,
+}
+
+// ParseTemplate parses the template used to compose the secret data
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+}
+
+// RenderTemplate renders the template with the contents of the objects, the object
+// contents are available by object name, e.g. {{ .username }} or {{ index . "db-host" }}.
+func RenderTemplate(name, text string, objects map[string]string) ([]byte, error) {
+	tmpl, err := ParseTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, objects); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// defaultValue returns the given value, or the default value if the given value is empty.
+// It's used as {{ .port | default "5432" }}.
+func defaultValue(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// required returns an error with the message if the value is empty
+func required(msg string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, fmt.Errorf("%s", msg)
+	}
+	return value, nil
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// dict returns a map from the list of key value pairs
+func dict(kv ...interface{}) (map[string]interface{}, error) {
+	if len(kv)%2 != 0 {
+		return nil, fmt.Errorf("dict requires an even number of arguments")
+	}
+	m := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", kv[i])
+		}
+		m[key] = kv[i+1]
+	}
+	return m, nil
+}
+
+func list(items ...interface{}) []interface{} {
+	return items
+}
+
+// join joins the items of a list with the separator
+func join(sep string, items interface{}) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join requires a list, got %T", items)
+	}
+	s := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		s = append(s, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(s, sep), nil
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func nindent(spaces int, s string) string {
+	return "\n" + indent(spaces, s)
+}
+
+func quote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+func squote(s string) string {
+	return "'" + s + "'"
+}
+
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func toJSON(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	objects := map[string]string{
+		"username": "admin",
+		"password": "p@ss",
+		"db-host":  "db.example.com",
+		"empty":    "",
+	}
+
+	tests := []struct {
+		name          string
+		template      string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "object by name",
+			template: `postgres://{{ .username }}:{{ .password }}@{{ index . "db-host" }}:5432`,
+			expected: "postgres://admin:p@ss@db.example.com:5432",
+		},
+		{
+			name:     "docker config json",
+			template: `{{ dict "auths" (dict "registry.example.com" (dict "username" .username "password" .password "auth" (printf "%s:%s" .username .password | b64enc))) | toJson }}`,
+			expected: `{"auths":{"registry.example.com":{"auth":"YWRtaW46cEBzcw==","password":"p@ss","username":"admin"}}}`,
+		},
+		{
+			name:     "string functions",
+			template: `{{ .username | upper }} {{ "  x  " | trim }} {{ .password | quote }} {{ .password | squote }} {{ replace "." "_" (index . "db-host") }} {{ list "a" "b" | join "," }}`,
+			expected: `ADMIN x "p@ss" 'p@ss' db_example_com a,b`,
+		},
+		{
+			name:     "indent",
+			template: `config:{{ "a: 1\nb: 2" | nindent 2 }}`,
+			expected: "config:\n  a: 1\n  b: 2",
+		},
+		{
+			name:     "default",
+			template: `{{ .empty | default "none" }} {{ .username | default "none" }}`,
+			expected: "none admin",
+		},
+		{
+			name:     "base64 decode",
+			template: `{{ "YWRtaW4=" | b64dec }}`,
+			expected: "admin",
+		},
+		{
+			name:          "required value is empty",
+			template:      `{{ required "empty is required" .empty }}`,
+			expectedError: true,
+		},
+		{
+			name:          "missing object",
+			template:      `{{ .missing }}`,
+			expectedError: true,
+		},
+		{
+			name:          "function not available",
+			template:      `{{ env "HOME" }}`,
+			expectedError: true,
+		},
+		{
+			name:          "invalid base64",
+			template:      `{{ "%%%" | b64dec }}`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := RenderTemplate(test.name, test.template, objects)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if string(actual) != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestGetSecretDataTemplate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for objectName, content := range map[string]string{"username": "admin", "password": "secret", "host": "db"} {
+		files[objectName] = filepath.Join(dir, objectName)
+		if err := os.WriteFile(files[objectName], []byte(content), 0600); err != nil {
+			t.Fatalf("expected err to be nil, got: %+v", err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		secretObj       secretsstorev1.SecretObject
+		expectedDataMap map[string][]byte
+		expectedError   bool
+	}{
+		{
+			name: "data key template",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data: []*secretsstorev1.SecretObjectData{
+					{Key: "username", ObjectName: "username"},
+					{Key: "url", Template: "postgres://{{ .username }}:{{ .password }}@{{ .host }}"},
+				},
+			},
+			expectedDataMap: map[string][]byte{"username": []byte("admin"), "url": []byte("postgres://admin:secret@db")},
+		},
+		{
+			name: "secret object template",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data: []*secretsstorev1.SecretObjectData{
+					{Key: "username", ObjectName: "username"},
+				},
+				Template: "application.properties: |\n  db.user={{ .username }}\n  db.password={{ .password }}\nhost: {{ .host }}\n",
+			},
+			expectedDataMap: map[string][]byte{
+				"username":               []byte("admin"),
+				"application.properties": []byte("db.user=admin\ndb.password=secret\n"),
+				"host":                   []byte("db"),
+			},
+		},
+		{
+			name: "object name and template set",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data:       []*secretsstorev1.SecretObjectData{{Key: "url", ObjectName: "host", Template: "{{ .host }}"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "template references missing object",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data:       []*secretsstorev1.SecretObjectData{{Key: "url", Template: "{{ .port }}"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "secret object template doesn't render a map",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Template:   "{{ .username }}",
+			},
+			expectedError: true,
+		},
+		{
+			name: "secret object template renders an invalid key",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Template:   "key/1: {{ .username }}",
+			},
+			expectedError: true,
+		},
+		{
+			name: "secret object template renders a key defined in data",
+			secretObj: secretsstorev1.SecretObject{
+				SecretName: "secret1",
+				Data:       []*secretsstorev1.SecretObjectData{{Key: "username", ObjectName: "username"}},
+				Template:   "username: {{ .username }}",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			datamap, _, err := GetSecretData(test.secretObj, corev1.SecretTypeOpaque, files, nil)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected err to be nil, got: %+v", err)
+			}
+			if !reflect.DeepEqual(datamap, test.expectedDataMap) {
+				t.Fatalf("expected data map %q, got %q", test.expectedDataMap, datamap)
+			}
+		})
+	}
+}
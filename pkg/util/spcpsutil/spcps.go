@@ -30,3 +30,14 @@ func OrderSecretProviderClassObjectByID(objects []secretsstorev1.SecretProviderC
 	})
 	return objects
 }
+
+// OrderSecretProviderClassObjectByIDAndVersion sorts SecretProviderClassObjects by ID and then by Version
+func OrderSecretProviderClassObjectByIDAndVersion(objects []secretsstorev1.SecretProviderClassObject) []secretsstorev1.SecretProviderClassObject {
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].ID != objects[j].ID {
+			return objects[i].ID < objects[j].ID
+		}
+		return objects[i].Version < objects[j].Version
+	})
+	return objects
+}
@@ -144,3 +144,19 @@ func TestOrderSecretProviderClassObjectByID(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderSecretProviderClassObjectByIDAndVersion(t *testing.T) {
+	objs := []secretsstorev1.SecretProviderClassObject{
+		{ID: "b", Version: "v1"},
+		{ID: "a", Version: "v2"},
+		{ID: "a", Version: "v1"},
+	}
+	want := []secretsstorev1.SecretProviderClassObject{
+		{ID: "a", Version: "v1"},
+		{ID: "a", Version: "v2"},
+		{ID: "b", Version: "v1"},
+	}
+	if got := OrderSecretProviderClassObjectByIDAndVersion(objs); !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderSecretProviderClassObjectByIDAndVersion() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,399 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation contains the validation of the SecretProviderClass spec
+// shared by the CSI driver and the validating admission webhook.
+package validation
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/util/secretutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// providerNameRe is the regular expression used to validate provider names.
+// The provider name is used to find the provider socket <provider>.sock.
+var providerNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{0,30}$`)
+
+// supportedProviderCapabilities are the provider capabilities that can be required
+// by a SecretProviderClass
+var supportedProviderCapabilities = sets.New(
+	secretsstorev1.ProviderCapabilityMountStream,
+	secretsstorev1.ProviderCapabilityUnmountNotification,
+	secretsstorev1.ProviderCapabilityPerObjectErrors,
+	secretsstorev1.ProviderCapabilityObjectTTL,
+)
+
+// supportedObjectEncodings are the encodings that can be declared for the objects
+var supportedObjectEncodings = sets.New(
+	secretsstorev1.ObjectEncodingBase64,
+	secretsstorev1.ObjectEncodingBase64URL,
+	secretsstorev1.ObjectEncodingHex,
+	secretsstorev1.ObjectEncodingUTF8,
+)
+
+// supportedPrivateKeyEncodings are the encodings of the private keys synced to TLS secrets
+var supportedPrivateKeyEncodings = sets.New(
+	secretsstorev1.PrivateKeyEncodingPKCS1,
+	secretsstorev1.PrivateKeyEncodingPKCS8,
+)
+
+// supportedKeystoreFormats are the formats of the derived keystore files
+var supportedKeystoreFormats = sets.New(
+	secretsstorev1.KeystoreFormatPKCS12,
+	secretsstorev1.KeystoreFormatJKS,
+)
+
+// IsValidProviderName returns true if the provider name matches the provider name
+// regular expression.
+func IsValidProviderName(provider string) bool {
+	return providerNameRe.MatchString(provider)
+}
+
+// ValidateSecretProviderClass validates the SecretProviderClass spec and returns
+// the list of field errors.
+func ValidateSecretProviderClass(spc *secretsstorev1.SecretProviderClass) field.ErrorList {
+	return ValidateSecretProviderClassSpec(&spc.Spec, field.NewPath("spec"))
+}
+
+// ValidateSecretProviderClassSpec validates the SecretProviderClass spec. The checks
+// are the same that are done when the volume is mounted and the secrets are synced.
+func ValidateSecretProviderClassSpec(spec *secretsstorev1.SecretProviderClassSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	provider := string(spec.Provider)
+	if len(provider) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("provider"), ""))
+	} else if !IsValidProviderName(provider) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("provider"), provider, "must match the regex "+providerNameRe.String()))
+	}
+
+	if len(spec.Parameters) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("parameters"), ""))
+	}
+
+	secretNames := sets.New[string]()
+	for i, secretObj := range spec.SecretObjects {
+		idxPath := fldPath.Child("secretObjects").Index(i)
+		if secretObj == nil {
+			allErrs = append(allErrs, field.Required(idxPath, ""))
+			continue
+		}
+		allErrs = append(allErrs, validateSecretObject(secretObj, idxPath)...)
+
+		if len(secretObj.SecretName) == 0 {
+			continue
+		}
+		if secretNames.Has(secretObj.SecretName) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("secretName"), secretObj.SecretName))
+		}
+		secretNames.Insert(secretObj.SecretName)
+	}
+
+	objectNames := sets.New[string]()
+	for i, obj := range spec.Objects {
+		idxPath := fldPath.Child("objects").Index(i)
+		if len(obj.ObjectName) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("objectName"), ""))
+		} else if objectNames.Has(obj.ObjectName) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("objectName"), obj.ObjectName))
+		}
+		objectNames.Insert(obj.ObjectName)
+		if len(obj.Encoding) > 0 && !supportedObjectEncodings.Has(obj.Encoding) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("encoding"), obj.Encoding, sets.List(supportedObjectEncodings)))
+		}
+		if obj.Explode != nil {
+			allErrs = append(allErrs, validateObjectExplode(obj.Explode, idxPath.Child("explode"))...)
+		}
+	}
+
+	fileObjects := sets.New[string]()
+	filePaths := sets.New[string]()
+	for i, file := range spec.Files {
+		idxPath := fldPath.Child("files").Index(i)
+		if len(file.ObjectName) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("objectName"), ""))
+		} else if fileObjects.Has(file.ObjectName) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("objectName"), file.ObjectName))
+		}
+		fileObjects.Insert(file.ObjectName)
+
+		path := file.Path
+		if len(path) == 0 {
+			path = file.ObjectName
+		}
+		if len(path) > 0 {
+			allErrs = append(allErrs, validateRelativePath(path, idxPath.Child("path"))...)
+			if filePaths.Has(path) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), path))
+			}
+			filePaths.Insert(path)
+		}
+		allErrs = append(allErrs, validateFileMode(file.Mode, idxPath.Child("mode"))...)
+	}
+
+	derivedPaths := sets.New[string]()
+	for i, derivedFile := range spec.DerivedFiles {
+		idxPath := fldPath.Child("derivedFiles").Index(i)
+		allErrs = append(allErrs, validateDerivedFile(&derivedFile, idxPath)...)
+		if len(derivedFile.Path) == 0 {
+			continue
+		}
+		if derivedPaths.Has(derivedFile.Path) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), derivedFile.Path))
+		}
+		derivedPaths.Insert(derivedFile.Path)
+	}
+
+	if spec.TemplateConfigMap != nil {
+		cmPath := fldPath.Child("templateConfigMap")
+		if len(spec.TemplateConfigMap.Name) == 0 {
+			allErrs = append(allErrs, field.Required(cmPath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(spec.TemplateConfigMap.Name) {
+				allErrs = append(allErrs, field.Invalid(cmPath.Child("name"), spec.TemplateConfigMap.Name, msg))
+			}
+		}
+		allErrs = append(allErrs, validateFileMode(spec.TemplateConfigMap.DefaultMode, cmPath.Child("defaultMode"))...)
+	}
+
+	capabilities := sets.New[secretsstorev1.ProviderCapability]()
+	for i, capability := range spec.RequiredProviderCapabilities {
+		idxPath := fldPath.Child("requiredProviderCapabilities").Index(i)
+		if !supportedProviderCapabilities.Has(capability) {
+			allErrs = append(allErrs, field.NotSupported(idxPath, capability, sets.List(supportedProviderCapabilities)))
+			continue
+		}
+		if capabilities.Has(capability) {
+			allErrs = append(allErrs, field.Duplicate(idxPath, capability))
+		}
+		capabilities.Insert(capability)
+	}
+
+	return allErrs
+}
+
+// validateSecretObject validates a secret object in the SecretProviderClass spec
+func validateSecretObject(secretObj *secretsstorev1.SecretObject, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(secretObj.SecretName) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("secretName"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(secretObj.SecretName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("secretName"), secretObj.SecretName, msg))
+		}
+	}
+
+	if len(secretObj.Type) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("type"), ""))
+	}
+
+	if len(secretObj.Data) == 0 && len(secretObj.DataFrom) == 0 && len(secretObj.Template) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("data"), ""))
+	}
+	if len(secretObj.Template) > 0 {
+		if _, err := secretutil.ParseTemplate(secretObj.SecretName, secretObj.Template); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template"), secretObj.Template, err.Error()))
+		}
+	}
+
+	keys := sets.New[string]()
+	for i, data := range secretObj.Data {
+		idxPath := fldPath.Child("data").Index(i)
+		if data == nil {
+			allErrs = append(allErrs, field.Required(idxPath, ""))
+			continue
+		}
+		switch {
+		case len(data.Template) > 0 && len(data.ObjectName) > 0:
+			allErrs = append(allErrs, field.Forbidden(idxPath.Child("template"), "may not be set together with objectName"))
+		case len(data.Template) > 0:
+			if _, err := secretutil.ParseTemplate(data.Key, data.Template); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("template"), data.Template, err.Error()))
+			}
+		case len(strings.TrimSpace(data.ObjectName)) == 0:
+			allErrs = append(allErrs, field.Required(idxPath.Child("objectName"), ""))
+		}
+		if len(data.JSONPath) > 0 {
+			if len(data.Template) > 0 {
+				allErrs = append(allErrs, field.Forbidden(idxPath.Child("jsonPath"), "may not be set together with template"))
+			} else if _, err := secretutil.ParseJSONPath(data.JSONPath); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("jsonPath"), data.JSONPath, err.Error()))
+			}
+		}
+
+		key := strings.TrimSpace(data.Key)
+		if len(key) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("key"), ""))
+			continue
+		}
+		for _, msg := range validation.IsConfigMapKey(key) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("key"), data.Key, msg))
+		}
+		if keys.Has(key) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("key"), data.Key))
+		}
+		keys.Insert(key)
+	}
+
+	for i, dataFrom := range secretObj.DataFrom {
+		idxPath := fldPath.Child("dataFrom").Index(i)
+		if dataFrom == nil {
+			allErrs = append(allErrs, field.Required(idxPath, ""))
+			continue
+		}
+		if len(strings.TrimSpace(dataFrom.ObjectName)) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("objectName"), ""))
+		}
+		if len(dataFrom.JSONPath) > 0 {
+			if _, err := secretutil.ParseJSONPath(dataFrom.JSONPath); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("jsonPath"), dataFrom.JSONPath, err.Error()))
+			}
+		}
+	}
+
+	if secretObj.PKCS12Password != nil {
+		pwdPath := fldPath.Child("pkcs12Password")
+		hasObjectName := len(strings.TrimSpace(secretObj.PKCS12Password.ObjectName)) > 0
+		hasSecretKey := len(strings.TrimSpace(secretObj.PKCS12Password.NodePublishSecretRefKey)) > 0
+		switch {
+		case hasObjectName && hasSecretKey:
+			allErrs = append(allErrs, field.Forbidden(pwdPath.Child("nodePublishSecretRefKey"), "may not be set together with objectName"))
+		case !hasObjectName && !hasSecretKey:
+			allErrs = append(allErrs, field.Required(pwdPath, ""))
+		}
+		if secretObj.Type != string(corev1.SecretTypeTLS) {
+			allErrs = append(allErrs, field.Forbidden(pwdPath, "may only be set for secrets of type "+string(corev1.SecretTypeTLS)))
+		}
+	}
+
+	if len(secretObj.PrivateKeyEncoding) > 0 {
+		encodingPath := fldPath.Child("privateKeyEncoding")
+		if !supportedPrivateKeyEncodings.Has(secretObj.PrivateKeyEncoding) {
+			allErrs = append(allErrs, field.NotSupported(encodingPath, secretObj.PrivateKeyEncoding, sets.List(supportedPrivateKeyEncodings)))
+		} else if secretObj.Type != string(corev1.SecretTypeTLS) {
+			allErrs = append(allErrs, field.Forbidden(encodingPath, "may only be set for secrets of type "+string(corev1.SecretTypeTLS)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateObjectExplode validates the explode options of an object in the
+// SecretProviderClass spec
+func validateObjectExplode(explode *secretsstorev1.ObjectExplode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(explode.JSONPath) > 0 {
+		if _, err := secretutil.ParseJSONPath(explode.JSONPath); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("jsonPath"), explode.JSONPath, err.Error()))
+		}
+	}
+	allErrs = append(allErrs, validateFileMode(explode.DefaultMode, fldPath.Child("defaultMode"))...)
+
+	keys := sets.New[string]()
+	paths := sets.New[string]()
+	for i, item := range explode.Items {
+		idxPath := fldPath.Child("items").Index(i)
+		if len(item.Key) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("key"), ""))
+		} else if keys.Has(item.Key) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("key"), item.Key))
+		}
+		keys.Insert(item.Key)
+
+		path := item.Path
+		if len(path) == 0 {
+			path = item.Key
+		}
+		if len(path) > 0 {
+			allErrs = append(allErrs, validateRelativePath(path, idxPath.Child("path"))...)
+			if paths.Has(path) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), path))
+			}
+			paths.Insert(path)
+		}
+		allErrs = append(allErrs, validateFileMode(item.Mode, idxPath.Child("mode"))...)
+	}
+
+	return allErrs
+}
+
+// validateFileMode validates the mode of a file written to the mount
+func validateFileMode(mode *int32, fldPath *field.Path) field.ErrorList {
+	if mode != nil && (*mode < 0 || *mode > 0777) {
+		return field.ErrorList{field.Invalid(fldPath, *mode, "must be a number between 0 and 0777 (octal), both inclusive")}
+	}
+	return nil
+}
+
+// validateRelativePath validates the path of a file relative to the mount
+func validateRelativePath(path string, fldPath *field.Path) field.ErrorList {
+	switch {
+	case filepath.IsAbs(path) || strings.HasPrefix(path, "/"):
+		return field.ErrorList{field.Invalid(fldPath, path, "must be a relative path")}
+	case slices.Contains(strings.Split(filepath.ToSlash(path), "/"), ".."):
+		return field.ErrorList{field.Invalid(fldPath, path, "must not contain '..'")}
+	}
+	return nil
+}
+
+// validateDerivedFile validates a derived file in the SecretProviderClass spec
+func validateDerivedFile(derivedFile *secretsstorev1.DerivedFile, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(derivedFile.Path) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("path"), ""))
+	} else {
+		allErrs = append(allErrs, validateRelativePath(derivedFile.Path, fldPath.Child("path"))...)
+	}
+
+	keystore := derivedFile.Keystore
+	keystorePath := fldPath.Child("keystore")
+	if keystore == nil {
+		return append(allErrs, field.Required(keystorePath, ""))
+	}
+	if len(keystore.Format) > 0 && !supportedKeystoreFormats.Has(keystore.Format) {
+		allErrs = append(allErrs, field.NotSupported(keystorePath.Child("format"), keystore.Format, sets.List(supportedKeystoreFormats)))
+	}
+	if len(keystore.PasswordObjectName) == 0 {
+		allErrs = append(allErrs, field.Required(keystorePath.Child("passwordObjectName"), ""))
+	}
+
+	hasKey := len(keystore.KeyObjectName) > 0
+	hasCert := len(keystore.CertObjectName) > 0
+	hasTrustedCerts := len(keystore.TrustedCertObjectNames) > 0
+	switch {
+	case hasKey != hasCert:
+		allErrs = append(allErrs, field.Invalid(keystorePath, "", "keyObjectName and certObjectName must be set together"))
+	case hasKey && hasTrustedCerts:
+		allErrs = append(allErrs, field.Forbidden(keystorePath.Child("trustedCertObjectNames"), "may not be set together with keyObjectName"))
+	case !hasKey && !hasTrustedCerts:
+		allErrs = append(allErrs, field.Required(keystorePath, "keyObjectName or trustedCertObjectNames must be set"))
+	}
+
+	return allErrs
+}
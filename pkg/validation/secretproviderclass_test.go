@@ -0,0 +1,453 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func validSpec() secretsstorev1.SecretProviderClassSpec {
+	return secretsstorev1.SecretProviderClassSpec{
+		Provider:   "provider1",
+		Parameters: map[string]string{"foo": "bar"},
+		SecretObjects: []*secretsstorev1.SecretObject{
+			{
+				SecretName: "secret1",
+				Type:       "Opaque",
+				Data: []*secretsstorev1.SecretObjectData{
+					{ObjectName: "object1", Key: "key1"},
+					{ObjectName: "object2", Key: "key2"},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateSecretProviderClass(t *testing.T) {
+	tests := []struct {
+		name           string
+		mutate         func(spec *secretsstorev1.SecretProviderClassSpec)
+		expectedErrors []string
+	}{
+		{
+			name:   "valid spec",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {},
+		},
+		{
+			name:   "valid spec without secret objects",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) { spec.SecretObjects = nil },
+		},
+		{
+			name:           "provider not set",
+			mutate:         func(spec *secretsstorev1.SecretProviderClassSpec) { spec.Provider = "" },
+			expectedErrors: []string{"spec.provider: Required value"},
+		},
+		{
+			name:           "invalid provider name",
+			mutate:         func(spec *secretsstorev1.SecretProviderClassSpec) { spec.Provider = "../provider" },
+			expectedErrors: []string{`spec.provider: Invalid value: "../provider": must match the regex ^[a-zA-Z0-9_-]{0,30}$`},
+		},
+		{
+			name:           "parameters not set",
+			mutate:         func(spec *secretsstorev1.SecretProviderClassSpec) { spec.Parameters = nil },
+			expectedErrors: []string{"spec.parameters: Required value"},
+		},
+		{
+			name: "nil secret object",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects = append(spec.SecretObjects, nil)
+			},
+			expectedErrors: []string{"spec.secretObjects[1]: Required value"},
+		},
+		{
+			name: "secret object without name, type and data",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects = []*secretsstorev1.SecretObject{{}}
+			},
+			expectedErrors: []string{
+				"spec.secretObjects[0].secretName: Required value",
+				"spec.secretObjects[0].type: Required value",
+				"spec.secretObjects[0].data: Required value",
+			},
+		},
+		{
+			name:   "invalid secret name",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) { spec.SecretObjects[0].SecretName = "Secret_1" },
+			expectedErrors: []string{
+				`spec.secretObjects[0].secretName: Invalid value: "Secret_1": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`,
+			},
+		},
+		{
+			name: "duplicate secret name",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects = append(spec.SecretObjects, spec.SecretObjects[0])
+			},
+			expectedErrors: []string{`spec.secretObjects[1].secretName: Duplicate value: "secret1"`},
+		},
+		{
+			name: "data without object name and key",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data = append(spec.SecretObjects[0].Data, &secretsstorev1.SecretObjectData{ObjectName: " "}, nil)
+			},
+			expectedErrors: []string{
+				"spec.secretObjects[0].data[2].objectName: Required value",
+				"spec.secretObjects[0].data[2].key: Required value",
+				"spec.secretObjects[0].data[3]: Required value",
+			},
+		},
+		{
+			name: "invalid data key",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data[0].Key = "key/1"
+			},
+			expectedErrors: []string{
+				`spec.secretObjects[0].data[0].key: Invalid value: "key/1": a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`,
+			},
+		},
+		{
+			name: "duplicate data key",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data[1].Key = " key1"
+			},
+			expectedErrors: []string{`spec.secretObjects[0].data[1].key: Duplicate value: " key1"`},
+		},
+		{
+			name: "data and secret templates",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data = append(spec.SecretObjects[0].Data, &secretsstorev1.SecretObjectData{Key: "url", Template: "postgres://{{ .username }}@{{ .host }}"})
+				spec.SecretObjects = append(spec.SecretObjects, &secretsstorev1.SecretObject{SecretName: "secret2", Type: "Opaque", Template: "key: {{ .obj1 | b64enc }}"})
+			},
+		},
+		{
+			name: "invalid templates",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data[0].Template = "{{ .username }}"
+				spec.SecretObjects[0].Data = append(spec.SecretObjects[0].Data, &secretsstorev1.SecretObjectData{Key: "url", Template: "{{ .username"})
+				spec.SecretObjects[0].Template = "{{ env \"HOME\" }}"
+			},
+			expectedErrors: []string{
+				`spec.secretObjects[0].template: Invalid value: "{{ env \"HOME\" }}": template: secret1:1: function "env" not defined`,
+				"spec.secretObjects[0].data[0].template: Forbidden: may not be set together with objectName",
+				`spec.secretObjects[0].data[2].template: Invalid value: "{{ .username": template: url:1: unclosed action`,
+			},
+		},
+		{
+			name: "json path and data from",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data[0].JSONPath = "{.username}"
+				spec.SecretObjects = append(spec.SecretObjects, &secretsstorev1.SecretObject{
+					SecretName: "secret2",
+					Type:       "Opaque",
+					DataFrom:   []*secretsstorev1.SecretObjectDataFrom{{ObjectName: "obj1"}, {ObjectName: "obj2", JSONPath: ".credentials"}},
+				})
+			},
+		},
+		{
+			name: "invalid json path and data from",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Data[0].JSONPath = "{.username"
+				spec.SecretObjects[0].Data = append(spec.SecretObjects[0].Data, &secretsstorev1.SecretObjectData{Key: "url", Template: "{{ .url }}", JSONPath: ".url"})
+				spec.SecretObjects[0].DataFrom = []*secretsstorev1.SecretObjectDataFrom{{JSONPath: ".a"}, nil}
+			},
+			expectedErrors: []string{
+				`spec.secretObjects[0].data[0].jsonPath: Invalid value: "{.username": unclosed action`,
+				"spec.secretObjects[0].data[2].jsonPath: Forbidden: may not be set together with template",
+				"spec.secretObjects[0].dataFrom[0].objectName: Required value",
+				"spec.secretObjects[0].dataFrom[1]: Required value",
+			},
+		},
+		{
+			name: "pkcs12 password",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Type = "kubernetes.io/tls"
+				spec.SecretObjects[0].PKCS12Password = &secretsstorev1.PKCS12PasswordSource{NodePublishSecretRefKey: "pfx-password"}
+			},
+		},
+		{
+			name: "invalid pkcs12 passwords",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].PKCS12Password = &secretsstorev1.PKCS12PasswordSource{ObjectName: "password"}
+				spec.SecretObjects = append(spec.SecretObjects,
+					&secretsstorev1.SecretObject{
+						SecretName:     "secret2",
+						Type:           "kubernetes.io/tls",
+						Data:           []*secretsstorev1.SecretObjectData{{ObjectName: "cert", Key: "tls.crt"}},
+						PKCS12Password: &secretsstorev1.PKCS12PasswordSource{ObjectName: "password", NodePublishSecretRefKey: "pfx-password"},
+					},
+					&secretsstorev1.SecretObject{
+						SecretName:     "secret3",
+						Type:           "kubernetes.io/tls",
+						Data:           []*secretsstorev1.SecretObjectData{{ObjectName: "cert", Key: "tls.crt"}},
+						PKCS12Password: &secretsstorev1.PKCS12PasswordSource{},
+					},
+				)
+			},
+			expectedErrors: []string{
+				"spec.secretObjects[0].pkcs12Password: Forbidden: may only be set for secrets of type kubernetes.io/tls",
+				"spec.secretObjects[1].pkcs12Password.nodePublishSecretRefKey: Forbidden: may not be set together with objectName",
+				"spec.secretObjects[2].pkcs12Password: Required value",
+			},
+		},
+		{
+			name: "private key encoding",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].Type = "kubernetes.io/tls"
+				spec.SecretObjects[0].PrivateKeyEncoding = secretsstorev1.PrivateKeyEncodingPKCS8
+			},
+		},
+		{
+			name: "invalid private key encodings",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.SecretObjects[0].PrivateKeyEncoding = secretsstorev1.PrivateKeyEncodingPKCS8
+				spec.SecretObjects = append(spec.SecretObjects, &secretsstorev1.SecretObject{
+					SecretName:         "secret2",
+					Type:               "kubernetes.io/tls",
+					Data:               []*secretsstorev1.SecretObjectData{{ObjectName: "cert", Key: "tls.key"}},
+					PrivateKeyEncoding: "SEC1",
+				})
+			},
+			expectedErrors: []string{
+				"spec.secretObjects[0].privateKeyEncoding: Forbidden: may only be set for secrets of type kubernetes.io/tls",
+				`spec.secretObjects[1].privateKeyEncoding: Unsupported value: "SEC1": supported values: "PKCS1", "PKCS8"`,
+			},
+		},
+		{
+			name: "object encodings",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.Objects = []secretsstorev1.MountObject{
+					{ObjectName: "keystore", Encoding: secretsstorev1.ObjectEncodingBase64},
+					{ObjectName: "keytab", Encoding: secretsstorev1.ObjectEncodingHex},
+					{ObjectName: "password"},
+				}
+			},
+		},
+		{
+			name: "invalid object encodings",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.Objects = []secretsstorev1.MountObject{
+					{ObjectName: "keystore", Encoding: "base32"},
+					{ObjectName: "keystore", Encoding: secretsstorev1.ObjectEncodingBase64},
+					{Encoding: secretsstorev1.ObjectEncodingHex},
+				}
+			},
+			expectedErrors: []string{
+				`spec.objects[0].encoding: Unsupported value: "base32": supported values: "base64", "base64url", "hex", "utf-8"`,
+				`spec.objects[1].objectName: Duplicate value: "keystore"`,
+				"spec.objects[2].objectName: Required value",
+			},
+		},
+		{
+			name: "exploded objects",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(0400)
+				spec.Objects = []secretsstorev1.MountObject{
+					{ObjectName: "db", Explode: &secretsstorev1.ObjectExplode{}},
+					{ObjectName: "config", Explode: &secretsstorev1.ObjectExplode{
+						JSONPath:    ".database",
+						DefaultMode: &mode,
+						Items: []secretsstorev1.ObjectExplodeItem{
+							{Key: "username"},
+							{Key: "password", Path: "credentials/password", Mode: &mode},
+						},
+					}},
+				}
+			},
+		},
+		{
+			name: "invalid exploded objects",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(01000)
+				spec.Objects = []secretsstorev1.MountObject{
+					{ObjectName: "config", Explode: &secretsstorev1.ObjectExplode{
+						JSONPath:    "{.database",
+						DefaultMode: &mode,
+						Items: []secretsstorev1.ObjectExplodeItem{
+							{Key: "username", Mode: &mode},
+							{Key: "username", Path: "../username"},
+							{Key: "password", Path: "username"},
+							{Path: "/password"},
+						},
+					}},
+				}
+			},
+			expectedErrors: []string{
+				`spec.objects[0].explode.jsonPath: Invalid value: "{.database": unclosed action`,
+				"spec.objects[0].explode.defaultMode: Invalid value: 512: must be a number between 0 and 0777 (octal), both inclusive",
+				"spec.objects[0].explode.items[0].mode: Invalid value: 512: must be a number between 0 and 0777 (octal), both inclusive",
+				`spec.objects[0].explode.items[1].key: Duplicate value: "username"`,
+				`spec.objects[0].explode.items[1].path: Invalid value: "../username": must not contain '..'`,
+				`spec.objects[0].explode.items[2].path: Duplicate value: "username"`,
+				"spec.objects[0].explode.items[3].key: Required value",
+				`spec.objects[0].explode.items[3].path: Invalid value: "/password": must be a relative path`,
+			},
+		},
+		{
+			name: "files",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(0400)
+				spec.Files = []secretsstorev1.ObjectFile{
+					{ObjectName: "username"},
+					{ObjectName: "password", Path: "db/password", Mode: &mode},
+					{ObjectName: "api-key", Optional: true},
+				}
+			},
+		},
+		{
+			name: "invalid files",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(01000)
+				spec.Files = []secretsstorev1.ObjectFile{
+					{ObjectName: "username", Path: "/username", Mode: &mode},
+					{ObjectName: "username", Path: "../username"},
+					{ObjectName: "password", Path: "/username"},
+					{Path: "password"},
+				}
+			},
+			expectedErrors: []string{
+				`spec.files[0].path: Invalid value: "/username": must be a relative path`,
+				"spec.files[0].mode: Invalid value: 512: must be a number between 0 and 0777 (octal), both inclusive",
+				`spec.files[1].objectName: Duplicate value: "username"`,
+				`spec.files[1].path: Invalid value: "../username": must not contain '..'`,
+				`spec.files[2].path: Invalid value: "/username": must be a relative path`,
+				`spec.files[2].path: Duplicate value: "/username"`,
+				"spec.files[3].objectName: Required value",
+			},
+		},
+		{
+			name: "derived files",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.DerivedFiles = []secretsstorev1.DerivedFile{
+					{
+						Path:     "keystore.p12",
+						Keystore: &secretsstorev1.Keystore{KeyObjectName: "tls.key", CertObjectName: "tls.crt", PasswordObjectName: "password"},
+					},
+					{
+						Path:     "certs/truststore.jks",
+						Keystore: &secretsstorev1.Keystore{Format: secretsstorev1.KeystoreFormatJKS, TrustedCertObjectNames: []string{"ca.crt"}, PasswordObjectName: "password"},
+					},
+				}
+			},
+		},
+		{
+			name: "invalid derived files",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.DerivedFiles = []secretsstorev1.DerivedFile{
+					{Path: "/keystore.p12", Keystore: &secretsstorev1.Keystore{Format: "BKS", KeyObjectName: "tls.key"}},
+					{Path: "../truststore.p12", Keystore: &secretsstorev1.Keystore{KeyObjectName: "tls.key", CertObjectName: "tls.crt", TrustedCertObjectNames: []string{"ca.crt"}, PasswordObjectName: "password"}},
+					{Path: "../truststore.p12", Keystore: &secretsstorev1.Keystore{PasswordObjectName: "password"}},
+					{},
+				}
+			},
+			expectedErrors: []string{
+				`spec.derivedFiles[0].path: Invalid value: "/keystore.p12": must be a relative path`,
+				`spec.derivedFiles[0].keystore.format: Unsupported value: "BKS": supported values: "JKS", "PKCS12"`,
+				"spec.derivedFiles[0].keystore.passwordObjectName: Required value",
+				`spec.derivedFiles[0].keystore: Invalid value: "": keyObjectName and certObjectName must be set together`,
+				`spec.derivedFiles[1].path: Invalid value: "../truststore.p12": must not contain '..'`,
+				"spec.derivedFiles[1].keystore.trustedCertObjectNames: Forbidden: may not be set together with keyObjectName",
+				`spec.derivedFiles[2].path: Invalid value: "../truststore.p12": must not contain '..'`,
+				"spec.derivedFiles[2].keystore: Required value: keyObjectName or trustedCertObjectNames must be set",
+				`spec.derivedFiles[2].path: Duplicate value: "../truststore.p12"`,
+				"spec.derivedFiles[3].path: Required value",
+				"spec.derivedFiles[3].keystore: Required value",
+			},
+		},
+		{
+			name: "template configmap",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(0400)
+				spec.TemplateConfigMap = &secretsstorev1.TemplateConfigMap{Name: "app-templates", DefaultMode: &mode}
+			},
+		},
+		{
+			name: "invalid template configmap",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				mode := int32(-1)
+				spec.TemplateConfigMap = &secretsstorev1.TemplateConfigMap{Name: "App_Templates", DefaultMode: &mode}
+			},
+			expectedErrors: []string{
+				`spec.templateConfigMap.name: Invalid value: "App_Templates": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`,
+				"spec.templateConfigMap.defaultMode: Invalid value: -1: must be a number between 0 and 0777 (octal), both inclusive",
+			},
+		},
+		{
+			name: "required provider capabilities",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.RequiredProviderCapabilities = []secretsstorev1.ProviderCapability{
+					secretsstorev1.ProviderCapabilityMountStream,
+					secretsstorev1.ProviderCapabilityObjectTTL,
+				}
+			},
+		},
+		{
+			name: "unsupported and duplicate provider capabilities",
+			mutate: func(spec *secretsstorev1.SecretProviderClassSpec) {
+				spec.RequiredProviderCapabilities = []secretsstorev1.ProviderCapability{"Foo", "MountStream", "MountStream"}
+			},
+			expectedErrors: []string{
+				`spec.requiredProviderCapabilities[0]: Unsupported value: "Foo": supported values: "MountStream", "ObjectTTL", "PerObjectErrors", "UnmountNotification"`,
+				`spec.requiredProviderCapabilities[2]: Duplicate value: "MountStream"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spc := &secretsstorev1.SecretProviderClass{Spec: validSpec()}
+			test.mutate(&spc.Spec)
+
+			errs := ValidateSecretProviderClass(spc)
+			if got := errorStrings(errs); !reflect.DeepEqual(got, test.expectedErrors) {
+				t.Errorf("ValidateSecretProviderClass() = %q, expected %q", got, test.expectedErrors)
+			}
+		})
+	}
+}
+
+func TestIsValidProviderName(t *testing.T) {
+	tests := []struct {
+		provider string
+		expected bool
+	}{
+		{provider: "provider1", expected: true},
+		{provider: "my_provider-1", expected: true},
+		{provider: "provider.sock", expected: false},
+		{provider: "../provider", expected: false},
+		{provider: "a-provider-name-longer-than-30-chars", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.provider, func(t *testing.T) {
+			if got := IsValidProviderName(test.provider); got != test.expected {
+				t.Errorf("IsValidProviderName() = %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func errorStrings(errs field.ErrorList) []string {
+	var out []string
+	for _, err := range errs {
+		out = append(out, err.Error())
+	}
+	return out
+}
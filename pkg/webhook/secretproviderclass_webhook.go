@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook contains the admission webhooks for the secrets store CSI driver resources.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+	"sigs.k8s.io/secrets-store-csi-driver/pkg/validation"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SecretProviderClassValidator validates SecretProviderClasses when they are created or updated
+type SecretProviderClassValidator struct{}
+
+var _ admission.CustomValidator = &SecretProviderClassValidator{}
+
+// SetupWithManager registers the SecretProviderClass validating webhook with the manager
+func (v *SecretProviderClassValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&secretsstorev1.SecretProviderClass{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates the SecretProviderClass spec
+func (v *SecretProviderClassValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	spc, ok := obj.(*secretsstorev1.SecretProviderClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a SecretProviderClass but got %T", obj)
+	}
+	return nil, validateSecretProviderClass(spc)
+}
+
+// ValidateUpdate validates the SecretProviderClass spec if it changed. Updates that
+// don't change the spec are allowed so SecretProviderClasses created before the
+// webhook was installed can still be updated, e.g. to remove a finalizer.
+func (v *SecretProviderClassValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldSPC, ok := oldObj.(*secretsstorev1.SecretProviderClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a SecretProviderClass but got %T", oldObj)
+	}
+	spc, ok := newObj.(*secretsstorev1.SecretProviderClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a SecretProviderClass but got %T", newObj)
+	}
+	if reflect.DeepEqual(oldSPC.Spec, spc.Spec) {
+		return nil, nil
+	}
+	return nil, validateSecretProviderClass(spc)
+}
+
+// ValidateDelete allows all deletes
+func (v *SecretProviderClassValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateSecretProviderClass(spc *secretsstorev1.SecretProviderClass) error {
+	errs := validation.ValidateSecretProviderClass(spc)
+	if len(errs) == 0 {
+		return nil
+	}
+	klog.V(3).InfoS("rejecting invalid secret provider class", "spc", klog.KObj(spc), "errors", errs.ToAggregate().Error())
+	return apierrors.NewInvalid(secretsstorev1.SchemeGroupVersion.WithKind("SecretProviderClass").GroupKind(), spc.Name, errs)
+}
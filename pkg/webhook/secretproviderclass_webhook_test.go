@@ -0,0 +1,109 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	secretsstorev1 "sigs.k8s.io/secrets-store-csi-driver/apis/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSecretProviderClass(provider string) *secretsstorev1.SecretProviderClass {
+	return &secretsstorev1.SecretProviderClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "spc1", Namespace: "default"},
+		Spec: secretsstorev1.SecretProviderClassSpec{
+			Provider:   secretsstorev1.Provider(provider),
+			Parameters: map[string]string{"foo": "bar"},
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name          string
+		spc           *secretsstorev1.SecretProviderClass
+		expectInvalid bool
+	}{
+		{
+			name: "valid secret provider class",
+			spc:  newSecretProviderClass("provider1"),
+		},
+		{
+			name:          "invalid secret provider class",
+			spc:           newSecretProviderClass("provider.1"),
+			expectInvalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := (&SecretProviderClassValidator{}).ValidateCreate(context.TODO(), test.spc)
+			if test.expectInvalid != apierrors.IsInvalid(err) {
+				t.Fatalf("expected invalid error: %v, got: %v", test.expectInvalid, err)
+			}
+			if !test.expectInvalid && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	invalid := newSecretProviderClass("provider.1")
+	invalidWithLabels := invalid.DeepCopy()
+	invalidWithLabels.Labels = map[string]string{"foo": "bar"}
+
+	tests := []struct {
+		name          string
+		oldSPC        *secretsstorev1.SecretProviderClass
+		newSPC        *secretsstorev1.SecretProviderClass
+		expectInvalid bool
+	}{
+		{
+			name:   "valid update",
+			oldSPC: newSecretProviderClass("provider1"),
+			newSPC: newSecretProviderClass("provider2"),
+		},
+		{
+			name:          "invalid spec change",
+			oldSPC:        newSecretProviderClass("provider1"),
+			newSPC:        invalid,
+			expectInvalid: true,
+		},
+		{
+			name:   "unchanged invalid spec",
+			oldSPC: invalid,
+			newSPC: invalidWithLabels,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := (&SecretProviderClassValidator{}).ValidateUpdate(context.TODO(), test.oldSPC, test.newSPC)
+			if test.expectInvalid != apierrors.IsInvalid(err) {
+				t.Fatalf("expected invalid error: %v, got: %v", test.expectInvalid, err)
+			}
+			if !test.expectInvalid && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
@@ -29,6 +29,8 @@ import (
 )
 
 type MockCSIProviderServer struct {
+	v1alpha1.UnimplementedCSIDriverProviderServer
+
 	grpcServer *grpc.Server
 	listener   net.Listener
 	socketPath string
@@ -36,6 +38,9 @@ type MockCSIProviderServer struct {
 	errorCode  string
 	objects    []*v1alpha1.ObjectVersion
 	files      []*v1alpha1.File
+	chunkSize  int
+	version    string
+	caps       []string
 }
 
 // NewMocKCSIProviderServer returns a mock csi-provider grpc server
@@ -44,6 +49,7 @@ func NewMocKCSIProviderServer(socketPath string) (*MockCSIProviderServer, error)
 	s := &MockCSIProviderServer{
 		grpcServer: server,
 		socketPath: socketPath,
+		version:    "v1alpha1",
 	}
 	v1alpha1.RegisterCSIDriverProviderServer(server, s)
 	return s, nil
@@ -81,6 +87,22 @@ func (m *MockCSIProviderServer) SetProviderErrorCode(errorCode string) {
 	m.errorCode = errorCode
 }
 
+// SetMountStreamChunkSize sets the size of the file chunks to return on MountStream.
+// MountStream is not implemented if the chunk size is 0.
+func (m *MockCSIProviderServer) SetMountStreamChunkSize(chunkSize int) {
+	m.chunkSize = chunkSize
+}
+
+// SetVersion sets the provider API version to return on Version
+func (m *MockCSIProviderServer) SetVersion(version string) {
+	m.version = version
+}
+
+// SetCapabilities sets the capabilities to return on Version
+func (m *MockCSIProviderServer) SetCapabilities(capabilities []string) {
+	m.caps = capabilities
+}
+
 func (m *MockCSIProviderServer) Start() error {
 	var err error
 	m.listener, err = net.Listen("unix", m.socketPath)
@@ -126,11 +148,50 @@ func (m *MockCSIProviderServer) Mount(ctx context.Context, req *v1alpha1.MountRe
 	}, nil
 }
 
+// MountStream implements provider csi-provider method
+func (m *MockCSIProviderServer) MountStream(req *v1alpha1.MountRequest, stream v1alpha1.CSIDriverProvider_MountStreamServer) error {
+	if m.chunkSize == 0 {
+		return m.UnimplementedCSIDriverProviderServer.MountStream(req, stream)
+	}
+	if m.returnErr != nil {
+		return m.returnErr
+	}
+	if err := stream.Send(&v1alpha1.MountStreamResponse{
+		ObjectVersion: m.objects,
+		Error: &v1alpha1.Error{
+			Code: m.errorCode,
+		},
+	}); err != nil {
+		return err
+	}
+	for _, f := range m.files {
+		contents := f.Contents
+		for {
+			n := min(m.chunkSize, len(contents))
+			if err := stream.Send(&v1alpha1.MountStreamResponse{
+				FileChunk: &v1alpha1.FileChunk{
+					Path:     f.Path,
+					Mode:     f.Mode,
+					Contents: contents[:n],
+				},
+			}); err != nil {
+				return err
+			}
+			contents = contents[n:]
+			if len(contents) == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
 // Version implements provider csi-provider method
 func (m *MockCSIProviderServer) Version(ctx context.Context, req *v1alpha1.VersionRequest) (*v1alpha1.VersionResponse, error) {
 	return &v1alpha1.VersionResponse{
-		Version:        "v1alpha1",
+		Version:        m.version,
 		RuntimeName:    "fakeprovider",
 		RuntimeVersion: "0.0.10",
+		Capabilities:   m.caps,
 	}, nil
 }
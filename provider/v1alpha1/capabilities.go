@@ -0,0 +1,29 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// Capabilities a provider can advertise in VersionResponse.Capabilities
+const (
+	// CapabilityMountStream is set if the provider implements the MountStream RPC
+	CapabilityMountStream = "MountStream"
+	// CapabilityUnmountNotification is set if the provider is notified when a volume is unmounted
+	CapabilityUnmountNotification = "UnmountNotification"
+	// CapabilityPerObjectErrors is set if the provider reports errors for individual objects
+	CapabilityPerObjectErrors = "PerObjectErrors"
+	// CapabilityObjectTTL is set if the provider returns a time to live for the objects
+	CapabilityObjectTTL = "ObjectTTL"
+)
@@ -34,8 +34,9 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	CSIDriverProvider_Version_FullMethodName = "/v1alpha1.CSIDriverProvider/Version"
-	CSIDriverProvider_Mount_FullMethodName   = "/v1alpha1.CSIDriverProvider/Mount"
+	CSIDriverProvider_Version_FullMethodName     = "/v1alpha1.CSIDriverProvider/Version"
+	CSIDriverProvider_Mount_FullMethodName       = "/v1alpha1.CSIDriverProvider/Mount"
+	CSIDriverProvider_MountStream_FullMethodName = "/v1alpha1.CSIDriverProvider/MountStream"
 )
 
 // CSIDriverProviderClient is the client API for CSIDriverProvider service.
@@ -43,11 +44,15 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CSIDriverProviderClient interface {
 	// Version returns the runtime name and runtime version of the Secrets Store CSI Driver Provider
-	// TODO (aramase) This will be used later to ensure the provider the driver is talking to supports
-	// the current version
+	// and the capabilities it supports. The driver calls it before the first mount to ensure the
+	// provider supports the current version.
 	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
 	// Execute mount operation in provider
 	Mount(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (*MountResponse, error)
+	// Execute mount operation in provider and stream the files in chunks. This
+	// allows responses larger than the maximum gRPC message size. The driver
+	// falls back to Mount if the provider doesn't implement MountStream.
+	MountStream(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (CSIDriverProvider_MountStreamClient, error)
 }
 
 type cSIDriverProviderClient struct {
@@ -76,16 +81,52 @@ func (c *cSIDriverProviderClient) Mount(ctx context.Context, in *MountRequest, o
 	return out, nil
 }
 
+func (c *cSIDriverProviderClient) MountStream(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (CSIDriverProvider_MountStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CSIDriverProvider_ServiceDesc.Streams[0], CSIDriverProvider_MountStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cSIDriverProviderMountStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CSIDriverProvider_MountStreamClient interface {
+	Recv() (*MountStreamResponse, error)
+	grpc.ClientStream
+}
+
+type cSIDriverProviderMountStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *cSIDriverProviderMountStreamClient) Recv() (*MountStreamResponse, error) {
+	m := new(MountStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CSIDriverProviderServer is the server API for CSIDriverProvider service.
 // All implementations should embed UnimplementedCSIDriverProviderServer
 // for forward compatibility
 type CSIDriverProviderServer interface {
 	// Version returns the runtime name and runtime version of the Secrets Store CSI Driver Provider
-	// TODO (aramase) This will be used later to ensure the provider the driver is talking to supports
-	// the current version
+	// and the capabilities it supports. The driver calls it before the first mount to ensure the
+	// provider supports the current version.
 	Version(context.Context, *VersionRequest) (*VersionResponse, error)
 	// Execute mount operation in provider
 	Mount(context.Context, *MountRequest) (*MountResponse, error)
+	// Execute mount operation in provider and stream the files in chunks. This
+	// allows responses larger than the maximum gRPC message size. The driver
+	// falls back to Mount if the provider doesn't implement MountStream.
+	MountStream(*MountRequest, CSIDriverProvider_MountStreamServer) error
 }
 
 // UnimplementedCSIDriverProviderServer should be embedded to have forward compatible implementations.
@@ -98,6 +139,9 @@ func (UnimplementedCSIDriverProviderServer) Version(context.Context, *VersionReq
 func (UnimplementedCSIDriverProviderServer) Mount(context.Context, *MountRequest) (*MountResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Mount not implemented")
 }
+func (UnimplementedCSIDriverProviderServer) MountStream(*MountRequest, CSIDriverProvider_MountStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method MountStream not implemented")
+}
 
 // UnsafeCSIDriverProviderServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to CSIDriverProviderServer will
@@ -146,6 +190,27 @@ func _CSIDriverProvider_Mount_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CSIDriverProvider_MountStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MountRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CSIDriverProviderServer).MountStream(m, &cSIDriverProviderMountStreamServer{stream})
+}
+
+type CSIDriverProvider_MountStreamServer interface {
+	Send(*MountStreamResponse) error
+	grpc.ServerStream
+}
+
+type cSIDriverProviderMountStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *cSIDriverProviderMountStreamServer) Send(m *MountStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CSIDriverProvider_ServiceDesc is the grpc.ServiceDesc for CSIDriverProvider service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -162,6 +227,12 @@ var CSIDriverProvider_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _CSIDriverProvider_Mount_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "MountStream",
+			Handler:       _CSIDriverProvider_MountStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "provider/v1alpha1/service.proto",
 }
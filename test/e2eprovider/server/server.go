@@ -58,6 +58,8 @@ This is mock key
 
 // Server is a mock csi-provider server
 type Server struct {
+	v1alpha1.UnimplementedCSIDriverProviderServer
+
 	grpcServer *grpc.Server
 	socketPath string
 	network    string
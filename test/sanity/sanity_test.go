@@ -36,7 +36,11 @@ const (
 )
 
 func TestSanity(t *testing.T) {
-	driver := secretsstore.NewSecretsStoreDriver("secrets-store.csi.k8s.io", "somenodeid", endpoint, nil, nil, nil, false, time.Minute)
+	reporter, err := secretsstore.NewStatsReporter()
+	if err != nil {
+		t.Fatalf("failed to initialize stats reporter: %v", err)
+	}
+	driver := secretsstore.NewSecretsStoreDriver("secrets-store.csi.k8s.io", "somenodeid", endpoint, nil, nil, nil, false, time.Minute, nil, nil, reporter, nil)
 	go func() {
 		driver.Run(context.Background())
 	}()